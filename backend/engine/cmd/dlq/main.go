@@ -0,0 +1,295 @@
+// dlq inspects and repairs messages the engine parked on its dead-letter queue.
+//
+//	dlq list
+//	dlq show <message-id>
+//	dlq replay [-set field=value ...] [-file payload.json] [-edit] [-queue queue] <message-id>
+//	dlq purge <message-id> | dlq purge -all
+//
+// A replayed message goes to the order queue of the partition its event hashes
+// to, from where the engine forwards it if the event has moved; -queue sends
+// it to a queue of your choosing instead.
+package main
+
+import (
+	"bytes"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/deadletter"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// setFlags collects repeated -set field=value overrides
+type setFlags map[string]string
+
+func (s setFlags) String() string { return fmt.Sprint(map[string]string(s)) }
+
+func (s setFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected field=value, got %q", v)
+	}
+	s[key] = value
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	config.LoadConfig()
+	cfg := config.AppConfig.RabbitMQ
+
+	conn, err := amqp.Dial(cfg.URL)
+	if err != nil {
+		fail("connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		fail("open channel: %v", err)
+	}
+	defer ch.Close()
+
+	q := deadletter.NewQueue(ch, cfg.DeadLetterQueue)
+	defer q.Close()
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "list":
+		err = list(q)
+	case "show":
+		err = show(q, args)
+	case "replay":
+		err = replay(q, args)
+	case "purge":
+		err = purge(q, args)
+	default:
+		usage()
+	}
+	if err != nil {
+		q.Close()
+		fail("%s: %v", cmd, err)
+	}
+}
+
+func list(q *deadletter.Queue) error {
+	messages, err := q.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tREJECTED AT\tQUEUE\tREASON")
+	for _, m := range messages {
+		rejectedAt := "-"
+		if !m.RejectedAt.IsZero() {
+			rejectedAt = m.RejectedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID, rejectedAt, m.OriginalQueue, m.Reason)
+	}
+	w.Flush()
+	fmt.Printf("%d message(s) in %s\n", len(messages), q.Name)
+	return nil
+}
+
+func show(q *deadletter.Queue, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: dlq show <message-id>")
+	}
+	m, err := q.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID:           %s\n", m.ID)
+	fmt.Printf("Reason:       %s\n", m.Reason)
+	fmt.Printf("Queue:        %s\n", m.OriginalQueue)
+	fmt.Printf("Content-Type: %s\n", m.ContentType)
+	for k, v := range m.Headers {
+		fmt.Printf("Header:       %s=%v\n", k, v)
+	}
+	fmt.Println()
+	body, err := m.JSONBody()
+	if err != nil {
+		fmt.Printf("(undecodable payload: %v)\n%q\n", err, m.Body)
+		return nil
+	}
+	fmt.Println(prettyBody(body))
+	return nil
+}
+
+func replay(q *deadletter.Queue, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	sets := setFlags{}
+	fs.Var(sets, "set", "override a top-level JSON field, e.g. -set price=55 (repeatable)")
+	file := fs.String("file", "", "replace the payload with the contents of this file")
+	edit := fs.Bool("edit", false, "open the payload in $EDITOR before replaying")
+	target := fs.String("queue", "", "queue to replay the message into (default: the order queue of its event's partition)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: dlq replay [flags] <message-id>")
+	}
+
+	m, err := q.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Untouched messages go back byte for byte; edited ones are re-encoded as JSON
+	body, contentType := m.Body, m.ContentType
+	if *file != "" || len(sets) > 0 || *edit {
+		contentType = wire.ContentTypeJSON
+		if body, err = m.JSONBody(); err != nil {
+			return err
+		}
+	}
+	if *file != "" {
+		if body, err = os.ReadFile(*file); err != nil {
+			return err
+		}
+	}
+	if len(sets) > 0 {
+		if body, err = deadletter.EditJSON(body, sets); err != nil {
+			return err
+		}
+	}
+	if *edit {
+		if body, err = editInEditor(body); err != nil {
+			return err
+		}
+	}
+
+	exchange, key := "", *target
+	if key == "" {
+		if exchange, key, err = eventRoute(contentType, body); err != nil {
+			return fmt.Errorf("%w; name a queue with -queue", err)
+		}
+	}
+	if err := q.Replay(m, exchange, key, contentType, body); err != nil {
+		return err
+	}
+	fmt.Printf("🔁 Replayed %s to %s\n", m.ID, key)
+	return nil
+}
+
+// eventRoute returns the exchange and routing key of the order queue owning
+// the message's event on the partition ring
+func eventRoute(contentType string, body []byte) (string, string, error) {
+	contentType, err := wire.Negotiate(contentType)
+	if err != nil {
+		return "", "", err
+	}
+	var msg wire.EventMessage
+	if err := wire.Unmarshal(contentType, body, &msg); err != nil {
+		return "", "", fmt.Errorf("decode payload: %w", err)
+	}
+	eventID, ok := new(big.Int).SetString(msg.ID, 10)
+	if !ok {
+		return "", "", fmt.Errorf("message names no event")
+	}
+
+	cfg := config.AppConfig.Partition
+	ring, err := partition.NewMap(cfg.Count, cfg.VirtualNodes, "")
+	if err != nil {
+		return "", "", err
+	}
+	return cfg.OrderExchange, partition.RoutingKey(cfg.OrderRoutingKey, ring.Hashed(eventID), cfg.Count), nil
+}
+
+func purge(q *deadletter.Queue, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	all := fs.Bool("all", false, "purge every parked message")
+	fs.Parse(args)
+
+	if *all {
+		count, err := q.PurgeAll()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🗑️ Purged %d message(s) from %s\n", count, q.Name)
+		return nil
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: dlq purge <message-id> | dlq purge -all")
+	}
+	m, err := q.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := q.Purge(m); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️ Purged %s\n", m.ID)
+	return nil
+}
+
+// editInEditor round-trips the payload through $EDITOR (vi by default)
+func editInEditor(body []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "dlq-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(prettyBody(body)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, edited); err != nil {
+		return nil, fmt.Errorf("edited payload is not valid JSON: %w", err)
+	}
+	return compact.Bytes(), nil
+}
+
+func prettyBody(body []byte) string {
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, body, "", "  "); err != nil {
+		return fmt.Sprintf("%q", body)
+	}
+	return out.String()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  dlq list
+  dlq show <message-id>
+  dlq replay [-set field=value ...] [-file payload.json] [-edit] [-queue queue] <message-id>
+  dlq purge <message-id> | dlq purge -all`)
+	os.Exit(2)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,213 @@
+// eventsync reconciles the engine's event registry with the Event table in
+// Postgres. It only reads the database; with -apply it sends CreateEvent for
+// running events the engine does not know, through the engine's order exchange.
+//
+//	eventsync [-db postgres://...] [-registry data/events.json] [-apply]
+package main
+
+import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/registry"
+	"dummyengine/pkg/wire"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/streadway/amqp"
+)
+
+// dbEvent is one row of the Event table
+type dbEvent struct {
+	ID     *big.Int
+	Name   string
+	Status string // "RUNNING" || "CLOSED" || "SETTLEMENT" || "PROCESSED"
+}
+
+// finding is one difference between the database and the registry
+type finding struct {
+	EventID *big.Int
+	Kind    string // "missing" || "stale" || "settled" || "unknown"
+	Detail  string
+}
+
+func main() {
+	config.LoadConfig()
+	dataDir := config.AppConfig.Server.DataDir
+
+	defaultDSN := os.Getenv("EVENT_DATABASE_URL")
+	if defaultDSN == "" {
+		defaultDSN = os.Getenv("DATABASE_URL")
+	}
+	dsn := flag.String("db", defaultDSN, "Postgres URL of the event database (EVENT_DATABASE_URL or DATABASE_URL)")
+	registryPath := flag.String("registry", filepath.Join(dataDir, "events.json"), "event registry of this engine partition")
+	apply := flag.Bool("apply", false, "send CreateEvent for running events missing from the registry")
+	timeout := flag.Duration("timeout", 30*time.Second, "database query timeout")
+	flag.Parse()
+	if *dsn == "" {
+		fail("no database URL: set -db or EVENT_DATABASE_URL")
+	}
+
+	reg, err := registry.NewRegistry(*registryPath)
+	if err != nil {
+		fail("load registry: %v", err)
+	}
+
+	partitions := config.AppConfig.Partition
+	owners, err := partition.NewMap(partitions.Count, partitions.VirtualNodes, filepath.Join(dataDir, "partitions.json"))
+	if err != nil {
+		fail("load partition map: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	events, err := readEvents(ctx, *dsn)
+	if err != nil {
+		fail("read events: %v", err)
+	}
+
+	// Only this partition's events belong in its registry
+	var owned []dbEvent
+	for _, ev := range events {
+		if owners.Owner(ev.ID) == partitions.Index {
+			owned = append(owned, ev)
+		}
+	}
+
+	findings := reconcile(owned, reg.Entries())
+	report(findings, len(owned), len(reg.Entries()))
+
+	if !*apply {
+		return
+	}
+	var missing []*big.Int
+	for _, f := range findings {
+		if f.Kind == "missing" {
+			missing = append(missing, f.EventID)
+		}
+	}
+	if err := createEvents(missing, owners); err != nil {
+		fail("create events: %v", err)
+	}
+}
+
+// readEvents loads the Event table inside a read-only transaction
+func readEvents(ctx context.Context, dsn string) ([]dbEvent, error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	rows, err := tx.Query(ctx, `SELECT id, name, status FROM "Event" ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []dbEvent
+	for rows.Next() {
+		var id int64
+		var ev dbEvent
+		if err := rows.Scan(&id, &ev.Name, &ev.Status); err != nil {
+			return nil, err
+		}
+		ev.ID = big.NewInt(id)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// reconcile compares the database's view of each event with the registry's
+func reconcile(events []dbEvent, entries []registry.Entry) []finding {
+	known := make(map[string]registry.Entry, len(entries))
+	for _, entry := range entries {
+		known[entry.EventID.String()] = entry
+	}
+
+	var findings []finding
+	for _, ev := range events {
+		entry, ok := known[ev.ID.String()]
+		delete(known, ev.ID.String())
+		switch {
+		case !ok && ev.Status == "RUNNING":
+			findings = append(findings, finding{ev.ID, "missing", fmt.Sprintf("%q is running but the engine has no book", ev.Name)})
+		case !ok:
+		case ev.Status == "RUNNING" && entry.State == registry.StateSettled:
+			findings = append(findings, finding{ev.ID, "settled", fmt.Sprintf("%q is running but the engine settled it", ev.Name)})
+		case ev.Status != "RUNNING" && entry.State != registry.StateSettled:
+			findings = append(findings, finding{ev.ID, "stale", fmt.Sprintf("%q is %s but the engine is still %s", ev.Name, ev.Status, entry.State)})
+		}
+	}
+	for _, entry := range entries {
+		if _, left := known[entry.EventID.String()]; left {
+			findings = append(findings, finding{entry.EventID, "unknown", fmt.Sprintf("%s in the engine but not in the Event table", entry.State)})
+		}
+	}
+	return findings
+}
+
+func report(findings []finding, events, registered int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tFINDING\tDETAIL")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.EventID, f.Kind, f.Detail)
+	}
+	w.Flush()
+	fmt.Printf("%d event(s) in the database for this partition, %d registered, %d difference(s)\n", events, registered, len(findings))
+}
+
+// createEvents sends a CreateEvent with default rules for each event, routed
+// to its owning partition
+func createEvents(eventIDs []*big.Int, owners *partition.Map) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	conn, err := amqp.Dial(config.AppConfig.RabbitMQ.URL)
+	if err != nil {
+		return fmt.Errorf("connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	partitions := config.AppConfig.Partition
+	for _, eventID := range eventIDs {
+		body, err := wire.Marshal(wire.ContentTypeJSON, &wire.EventMessage{Task: "CreateEvent", ID: eventID.String()})
+		if err != nil {
+			return err
+		}
+		routingKey := partition.RoutingKey(partitions.OrderRoutingKey, owners.Owner(eventID), partitions.Count)
+		err = ch.Publish(partitions.OrderExchange, routingKey, false, false, amqp.Publishing{
+			ContentType:  wire.ContentTypeJSON,
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		})
+		if err != nil {
+			return fmt.Errorf("event %s: %w", eventID, err)
+		}
+		fmt.Printf("📌 Sent CreateEvent for %s\n", eventID)
+	}
+	return nil
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	os.Exit(1)
+}
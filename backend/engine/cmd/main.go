@@ -1,9 +1,25 @@
 package main
 
 import (
+	"context"
+	"dummyengine/pkg/candles"
 	"dummyengine/pkg/config"
+	"dummyengine/pkg/grpcapi"
+	"dummyengine/pkg/partition"
 	"dummyengine/pkg/rabbitmqQueue"
+	"dummyengine/pkg/registry"
+	"dummyengine/pkg/ticker"
+	"dummyengine/pkg/wire"
+	"expvar"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"dummyengine/pkg/leader"
 	"dummyengine/pkg/logger"
 )
 
@@ -19,12 +35,157 @@ func main() {
 	// Initialize RabbitMQ Publisher
 	consumer := rabbitmqQueue.NewRabbitMQQueue(
 		rabbitMQURL,
-		"order_queue",
+		config.AppConfig.Partition.Queue,
 		"trade_queue",
 		"price_queue",
 		"orderBook_queue",
 	)
-	consumer.Connect()
 
-	select {}
+	// Several instances split the events between them by consistent hashing
+	if partitions := config.AppConfig.Partition; partitions.Count > 1 {
+		partitionMap, err := partition.NewMap(partitions.Count, partitions.VirtualNodes, filepath.Join(config.AppConfig.Server.DataDir, "partitions.json"))
+		if err != nil {
+			log.Fatalf("Failed to load partition map: %v", err)
+		}
+		partitionMap.Hold = consumer.Following
+		consumer.Exchange.Partitions = partitionMap
+		consumer.Exchange.Partition = partitions.Index
+		logger.Info("🗺️ Running as one partition", "partition", partitions.Index, "partitions", partitions.Count, "queue", partitions.Queue)
+	}
+
+	// Primary/standby pair: whoever holds the lease leads, the other follows its journal
+	if standby := config.AppConfig.Standby; standby.Enabled {
+		consumer.Lease = leader.NewLease(standby.LeasePath, standby.InstanceID, standby.LeaseTTL)
+		logger.Info("🫂 Hot standby enabled", "instance", standby.InstanceID, "lease", standby.LeasePath, "journal", standby.JournalQueue)
+	}
+
+	// Events are remembered apart from their orders, so a restart without a
+	// snapshot still knows them
+	eventRegistry, err := registry.NewRegistry(filepath.Join(config.AppConfig.Server.DataDir, "events.json"))
+	if err != nil {
+		log.Fatalf("Failed to load event registry: %v", err)
+	}
+	eventRegistry.Hold = consumer.Following
+	consumer.Exchange.Registry = eventRegistry
+
+	snapshotPath := filepath.Join(config.AppConfig.Server.DataDir, "snapshot.json")
+	if err := consumer.Exchange.LoadSnapshot(snapshotPath); err != nil {
+		log.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	consumer.Exchange.LoadRegistry()
+
+	// Background publishers run until shutdown closes stop
+	stop := make(chan struct{})
+	var background sync.WaitGroup
+
+	// Candle aggregation from executed trades
+	candleAggregator := candles.NewAggregator(
+		filepath.Join(config.AppConfig.Server.DataDir, "candles.json"),
+		func(candle *wire.Candle) {
+			consumer.Exchange.Publish("price_exchange", "candles", candle)
+		},
+	)
+	candleAggregator.Hold = consumer.Following
+	consumer.Exchange.Observers = append(consumer.Exchange.Observers, candleAggregator)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		candleAggregator.Run(time.Second, stop)
+	}()
+
+	// A standby leaves its state files alone while it follows and writes
+	// them out once it has taken over
+	consumer.OnLead = func() {
+		if err := eventRegistry.Flush(); err != nil {
+			logger.Error("❌ Failed to save event registry", "error", err)
+		}
+		if partitionMap := consumer.Exchange.Partitions; partitionMap != nil {
+			if err := partitionMap.Flush(); err != nil {
+				logger.Error("❌ Failed to save partition overrides", "error", err)
+			}
+		}
+		candleAggregator.Flush()
+	}
+
+	// Per-event ticker with best bid/ask and 24h statistics
+	marketTicker := ticker.NewTracker(func(t *wire.Ticker) {
+		consumer.Exchange.Publish("price_exchange", "ticker.update", t)
+	})
+	consumer.Exchange.Observers = append(consumer.Exchange.Observers, marketTicker)
+	consumer.Exchange.BookObservers = append(consumer.Exchange.BookObservers, marketTicker)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		marketTicker.Run(time.Minute, stop)
+	}()
+
+	// gRPC order entry shares the consumer's sequencing; book streams observe the exchange
+	var api *grpcapi.Server
+	if addr := config.AppConfig.Server.GRPCAddr; addr != "" {
+		api = grpcapi.NewServer(consumer, config.AppConfig.Server.GRPCTimeout, config.AppConfig.Server.GRPCStreamBuffer)
+		consumer.Exchange.Observers = append(consumer.Exchange.Observers, api)
+		consumer.Exchange.BookObservers = append(consumer.Exchange.BookObservers, api)
+		go func() {
+			if err := api.Serve(addr); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
+
+	// Throttle counters are served with the other expvars at /debug/vars on PORT
+	if limiter := consumer.Exchange.RateLimiter; limiter != nil {
+		expvar.Publish("ratelimit", expvar.Func(func() any { return limiter.Stats() }))
+		logger.Info("🚦 Order rate limits enabled", "tiers", len(limiter.Tiers))
+	}
+	go func() {
+		addr := ":" + config.AppConfig.Server.Port
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			logger.Error("❌ Monitoring endpoint failed", "addr", addr, "error", err)
+		}
+	}()
+
+	go consumer.Connect()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logger.Info("🛑 Shutting down", "signal", sig.String(), "timeout", config.AppConfig.Server.ShutdownTimeout.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.Server.ShutdownTimeout)
+	defer cancel()
+	shutdown(ctx, consumer, api, stop, &background, snapshotPath)
+}
+
+// shutdown stops intake, lets in-flight work and publishes settle, saves the
+// engine state and closes the broker connection, all within ctx
+func shutdown(ctx context.Context, consumer *rabbitmqQueue.RabbitMQQueue, api *grpcapi.Server, stop chan struct{}, background *sync.WaitGroup, snapshotPath string) {
+	// Order requests already accepted still need the consumer, so the API stops first
+	if api != nil {
+		api.Stop()
+	}
+
+	if err := consumer.StopConsuming(ctx); err != nil {
+		// Orders may still be mid-flight, so a snapshot now could be inconsistent
+		logger.Error("❌ Consumer did not drain in time, exiting without snapshot", "error", err)
+		os.Exit(1)
+	}
+
+	close(stop)
+	background.Wait()
+
+	if err := consumer.WaitForConfirms(ctx); err != nil {
+		logger.Error("❌ Not all publishes were confirmed", "error", err)
+	}
+
+	if consumer.Following() {
+		// The standby's books are the leader's, mid-replay; they are not its to save
+		logger.Info("👀 Standby stopping without a snapshot")
+	} else if err := consumer.Exchange.WriteSnapshot(snapshotPath); err != nil {
+		logger.Error("❌ Failed to write snapshot", "path", snapshotPath, "error", err)
+	} else {
+		logger.Info("📸 Snapshot written", "path", snapshotPath, "events", len(consumer.Exchange.OrderBooks))
+	}
+
+	consumer.Close()
+	logger.Info("✅ Engine stopped cleanly")
 }
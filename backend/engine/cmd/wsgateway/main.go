@@ -0,0 +1,73 @@
+// wsgateway streams the engine's market data to WebSocket clients.
+//
+// Clients connect to /ws, optionally with ?event=<id> for each event to
+// follow, and send {"action":"subscribe"|"unsubscribe","event_id":"<id>"} to
+// change subscriptions. Each subscription starts with a snapshot frame
+// carrying the latest book and price, followed by book, price and trade
+// frames as the engine publishes them.
+package main
+
+import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/wsgateway"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	logger.InitLogger()
+	config.LoadConfig()
+	cfg := config.AppConfig.Gateway
+
+	hub := wsgateway.NewHub(cfg.SendBuffer, cfg.MaxSubscriptions)
+	consumer := &wsgateway.Consumer{
+		URL:        config.AppConfig.RabbitMQ.URL,
+		Queue:      cfg.Queue,
+		Hub:        hub,
+		MinBackoff: config.AppConfig.RabbitMQ.ReconnectMinDelay,
+		MaxBackoff: config.AppConfig.RabbitMQ.ReconnectMaxDelay,
+	}
+
+	stop := make(chan struct{})
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		consumer.Run(stop)
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", wsgateway.NewHandler(hub, cfg.WriteTimeout, cfg.PingInterval))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		clients, events := hub.Stats()
+		fmt.Fprintf(w, "ok clients=%d events=%d\n", clients, events)
+	})
+	server := &http.Server{Addr: cfg.Addr, Handler: mux}
+
+	go func() {
+		logger.Info("🌐 WebSocket gateway listening", "addr", cfg.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("WebSocket gateway failed", "error", err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logger.Info("🛑 Shutting down", "signal", sig.String())
+
+	// Hijacked WebSocket connections are not tracked by Shutdown, so the hub closes them
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+	hub.CloseAll()
+	close(stop)
+	<-consumed
+	logger.Info("✅ WebSocket gateway stopped")
+}
@@ -0,0 +1,269 @@
+// dummyengine/pkg/candles/candles.go
+package candles
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Intervals maintained for every market, keyed by the name used on the wire
+var Intervals = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+// HistoryLimit is how many closed bars are kept per market and interval
+const HistoryLimit = 1000
+
+// DefaultSaveInterval is how often Run saves changed state when SaveInterval is unset
+const DefaultSaveInterval = 10 * time.Second
+
+// Aggregator builds OHLCV bars from executed trades, bucketed by the time
+// each trade happened. Bars close when a trade lands in a later bucket or
+// when the wall clock passes the bar's end, and are handed to Publish as they
+// close. Open bars and history are saved to Path every SaveInterval while
+// they change, and on Flush, so that they survive restarts.
+type Aggregator struct {
+	Path         string
+	Publish      func(candle *wire.Candle)
+	Hold         func() bool // while true, nothing is saved
+	SaveInterval time.Duration
+
+	mu      sync.Mutex
+	open    map[string]map[string]*wire.Candle   // market -> interval -> open bar
+	history map[string]map[string][]*wire.Candle // market -> interval -> closed bars
+	dirty   bool                                 // changed since the last save
+}
+
+// state is the on-disk form of the aggregator
+type state struct {
+	Open    map[string]map[string]*wire.Candle   `json:"open"`
+	History map[string]map[string][]*wire.Candle `json:"history"`
+}
+
+// NewAggregator restores state from path if it exists
+func NewAggregator(path string, publish func(candle *wire.Candle)) *Aggregator {
+	a := &Aggregator{
+		Path:    path,
+		Publish: publish,
+		open:    make(map[string]map[string]*wire.Candle),
+		history: make(map[string]map[string][]*wire.Candle),
+	}
+	a.load()
+	return a
+}
+
+// OnTrade folds one execution into every interval's current bar
+func (a *Aggregator) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	eventKey := wire.MarketKey(eventID, outcome)
+	bars, ok := a.open[eventKey]
+	if !ok {
+		bars = make(map[string]*wire.Candle)
+		a.open[eventKey] = bars
+	}
+
+	for _, iv := range Intervals {
+		start := at.Truncate(iv.Duration)
+		bar := bars[iv.Name]
+
+		if bar != nil && bar.OpenTime != start.Unix() {
+			a.close(eventKey, bar)
+			bar = nil
+		}
+		if bar == nil {
+			bar = a.reopen(eventKey, iv.Name, start.Unix())
+		}
+		if bar == nil {
+			bar = &wire.Candle{
+				EventID:   eventID,
+				Outcome:   outcome,
+				Interval:  iv.Name,
+				OpenTime:  start.Unix(),
+				CloseTime: start.Add(iv.Duration).Unix(),
+				Open:      price,
+				High:      price,
+				Low:       price,
+			}
+		}
+		bars[iv.Name] = bar
+
+		bar.High = max(bar.High, price)
+		bar.Low = min(bar.Low, price)
+		bar.Close = price
+		bar.Volume += int64(quantity)
+		bar.Trades++
+	}
+	a.dirty = true
+}
+
+// reopen takes the market's last closed bar of an interval back from history
+// if it opened at openTime, for a trade that happened before the bar closed
+// but was applied after, as on catching up; it is published again when it
+// closes. Callers hold mu.
+func (a *Aggregator) reopen(eventKey, interval string, openTime int64) *wire.Candle {
+	bars := a.history[eventKey][interval]
+	if len(bars) == 0 || bars[len(bars)-1].OpenTime != openTime {
+		return nil
+	}
+	bar := bars[len(bars)-1]
+	a.history[eventKey][interval] = bars[:len(bars)-1]
+	return bar
+}
+
+// History returns up to limit of the most recent closed bars, oldest first
+func (a *Aggregator) History(eventID *big.Int, outcome int, interval string, limit int) []wire.Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	bars := a.history[wire.MarketKey(eventID, outcome)][interval]
+	if limit > 0 && len(bars) > limit {
+		bars = bars[len(bars)-limit:]
+	}
+	out := make([]wire.Candle, len(bars))
+	for i, bar := range bars {
+		out[i] = *bar
+	}
+	return out
+}
+
+// Run closes bars whose period has ended, checking every tick, and saves
+// changes every SaveInterval, until stop is closed
+func (a *Aggregator) Run(tick time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	interval := a.SaveInterval
+	if interval <= 0 {
+		interval = DefaultSaveInterval
+	}
+	saver := time.NewTicker(interval)
+	defer saver.Stop()
+
+	for {
+		select {
+		case <-stop:
+			a.Flush()
+			return
+		case now := <-ticker.C:
+			a.closeExpired(now)
+		case <-saver.C:
+			a.saveChanged()
+		}
+	}
+}
+
+// Flush persists the current state, open bars included
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.save()
+}
+
+// saveChanged persists the state if it changed since the last save
+func (a *Aggregator) saveChanged() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.dirty {
+		a.save()
+	}
+}
+
+func (a *Aggregator) closeExpired(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for eventKey, bars := range a.open {
+		for name, bar := range bars {
+			if now.Unix() >= bar.CloseTime {
+				a.close(eventKey, bar)
+				delete(bars, name)
+				a.dirty = true
+			}
+		}
+	}
+}
+
+// close moves a finished bar into history and publishes it; callers hold mu
+func (a *Aggregator) close(eventKey string, bar *wire.Candle) {
+	byInterval, ok := a.history[eventKey]
+	if !ok {
+		byInterval = make(map[string][]*wire.Candle)
+		a.history[eventKey] = byInterval
+	}
+
+	bars := append(byInterval[bar.Interval], bar)
+	if len(bars) > HistoryLimit {
+		bars = bars[len(bars)-HistoryLimit:]
+	}
+	byInterval[bar.Interval] = bars
+
+	if a.Publish != nil {
+		a.Publish(bar)
+	}
+}
+
+func (a *Aggregator) load() {
+	if a.Path == "" {
+		return
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("❌ Failed to read candle state", "path", a.Path, "error", err)
+		}
+		return
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		logger.Error("❌ Failed to parse candle state", "path", a.Path, "error", err)
+		return
+	}
+	if s.Open != nil {
+		a.open = s.Open
+	}
+	if s.History != nil {
+		a.history = s.History
+	}
+	logger.Info("🕯️ Candle state restored", "path", a.Path, "events", len(a.open))
+}
+
+// save writes state atomically through a temp file; callers hold mu
+func (a *Aggregator) save() {
+	if a.Path == "" || (a.Hold != nil && a.Hold()) {
+		return
+	}
+	data, err := json.Marshal(state{Open: a.open, History: a.history})
+	if err != nil {
+		logger.Error("❌ Failed to encode candle state", "error", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.Path), 0755); err != nil {
+		logger.Error("❌ Failed to create candle state directory", "path", a.Path, "error", err)
+		return
+	}
+	tmp := a.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		logger.Error("❌ Failed to write candle state", "path", tmp, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, a.Path); err != nil {
+		logger.Error("❌ Failed to replace candle state", "path", a.Path, "error", err)
+		return
+	}
+	a.dirty = false
+}
@@ -0,0 +1,128 @@
+package candles
+
+import (
+	"dummyengine/pkg/wire"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type trade struct {
+	price, qty int
+	at         time.Time
+	appliedAt  time.Time // wall clock when applied, closing expired bars first; zero for at
+}
+
+func TestOnTradeBuckets(t *testing.T) {
+	base := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		trades     []trade
+		expireAt   time.Time // wall clock at the end, zero to close nothing more
+		wantClosed []wire.Candle
+		wantOpen   *wire.Candle
+	}{
+		{
+			name:     "one bar",
+			trades:   []trade{{500, 1, base, time.Time{}}, {520, 2, base.Add(10 * time.Second), time.Time{}}, {490, 3, base.Add(50 * time.Second), time.Time{}}},
+			wantOpen: &wire.Candle{OpenTime: base.Unix(), Open: 500, High: 520, Low: 490, Close: 490, Volume: 6, Trades: 3},
+		},
+		{
+			name:       "later bucket closes the bar",
+			trades:     []trade{{500, 1, base, time.Time{}}, {510, 1, base.Add(time.Minute), time.Time{}}},
+			wantClosed: []wire.Candle{{OpenTime: base.Unix(), Open: 500, High: 500, Low: 500, Close: 500, Volume: 1, Trades: 1}},
+			wantOpen:   &wire.Candle{OpenTime: base.Add(time.Minute).Unix(), Open: 510, High: 510, Low: 510, Close: 510, Volume: 1, Trades: 1},
+		},
+		{
+			name:       "wall clock closes the bar",
+			trades:     []trade{{500, 1, base, time.Time{}}},
+			expireAt:   base.Add(2 * time.Minute),
+			wantClosed: []wire.Candle{{OpenTime: base.Unix(), Open: 500, High: 500, Low: 500, Close: 500, Volume: 1, Trades: 1}},
+		},
+		{
+			name:       "late trade reopens its closed bar",
+			trades:     []trade{{500, 1, base, time.Time{}}, {530, 4, base.Add(30 * time.Second), base.Add(90 * time.Second)}},
+			expireAt:   base.Add(2 * time.Minute),
+			wantClosed: []wire.Candle{{OpenTime: base.Unix(), Open: 500, High: 530, Low: 500, Close: 530, Volume: 5, Trades: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAggregator("", nil)
+			eventID := big.NewInt(7)
+			for _, tr := range tt.trades {
+				if !tr.appliedAt.IsZero() {
+					a.closeExpired(tr.appliedAt)
+				}
+				a.OnTrade(eventID, 0, tr.price, tr.qty, tr.at)
+			}
+			if !tt.expireAt.IsZero() {
+				a.closeExpired(tt.expireAt)
+			}
+
+			history := a.History(eventID, 0, "1m", 0)
+			if len(history) != len(tt.wantClosed) {
+				t.Fatalf("%d closed 1m bars, want %d", len(history), len(tt.wantClosed))
+			}
+			for i := range history {
+				if got := strip(history[i]); got != tt.wantClosed[i] {
+					t.Errorf("closed bar %+v, want %+v", got, tt.wantClosed[i])
+				}
+			}
+
+			bar := a.open[eventID.String()]["1m"]
+			switch {
+			case tt.wantOpen == nil && bar != nil:
+				t.Errorf("open bar %+v, want none", *bar)
+			case tt.wantOpen != nil && bar == nil:
+				t.Error("no open bar")
+			case tt.wantOpen != nil && strip(*bar) != *tt.wantOpen:
+				t.Errorf("open bar %+v, want %+v", strip(*bar), *tt.wantOpen)
+			}
+		})
+	}
+}
+
+// strip clears the fields the bucket tests do not set
+func strip(c wire.Candle) wire.Candle {
+	c.EventID, c.Interval, c.CloseTime = nil, "", 0
+	return c
+}
+
+func TestSave(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		hold     bool
+		save     func(a *Aggregator)
+		wantFile bool
+	}{
+		{"flush saves open bars", false, (*Aggregator).Flush, true},
+		{"timer saves changes", false, (*Aggregator).saveChanged, true},
+		{"a trade alone saves nothing", false, func(*Aggregator) {}, false},
+		{"held", true, (*Aggregator).Flush, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "candles.json")
+			a := NewAggregator(path, nil)
+			a.Hold = func() bool { return tt.hold }
+			a.OnTrade(big.NewInt(7), 1, 500, 2, at)
+			tt.save(a)
+
+			if _, err := os.Stat(path); (err == nil) != tt.wantFile {
+				t.Fatalf("state file written = %v, want %v", err == nil, tt.wantFile)
+			}
+			if !tt.wantFile {
+				return
+			}
+			restored := NewAggregator(path, nil)
+			bar := restored.open[wire.MarketKey(big.NewInt(7), 1)]["1m"]
+			if bar == nil || bar.Volume != 2 {
+				t.Fatalf("restored open bar %+v", bar)
+			}
+		})
+	}
+}
@@ -1,18 +1,106 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 	"github.com/joho/godotenv"
 	"dummyengine/pkg/logger"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/ratelimit"
+	"dummyengine/pkg/wire"
 )
 
 type Config struct {
-	Server   ServerConfig
-	RabbitMQ RabbitMQConfig
+	Server    ServerConfig
+	RabbitMQ  RabbitMQConfig
+	Limits    LimitsConfig
+	Partition PartitionConfig
+	Standby   StandbyConfig
+	Gateway   GatewayConfig
+	Maker     MakerConfig
+}
+
+// MakerConfig names the user LMSR market makers trade as, from
+// ENGINE_MAKER_USER_ID. Their trades carry it, so settlement can book them;
+// no one else may use it. Unset, events cannot ask for a maker.
+type MakerConfig struct {
+	UserID *big.Int
+}
+
+// GatewayConfig drives the WebSocket market data gateway (cmd/wsgateway),
+// which follows the engine's book, price and trade exchanges
+type GatewayConfig struct {
+	Addr  string
+	Queue string // empty for a broker-named queue
+
+	SendBuffer       int           // frames a client may lag before it is evicted
+	WriteTimeout     time.Duration // a single frame write slower than this evicts the client
+	PingInterval     time.Duration
+	MaxSubscriptions int // events per connection
+}
+
+// StandbyConfig pairs a primary with hot standbys. The leader journals every
+// input to JournalQueue before processing it; a standby follows the journal
+// and takes over when it wins the lease at LeasePath, which must be on
+// storage both instances share. The DataDir of each instance must not be
+// shared: a standby keeps its own state files and leaves them alone while
+// it follows.
+type StandbyConfig struct {
+	Enabled    bool
+	InstanceID string
+	LeasePath  string
+	LeaseTTL   time.Duration
+
+	JournalExchange   string
+	JournalQueue      string
+	JournalRoutingKey string
+}
+
+// PartitionConfig places this instance among Count engines sharing the
+// events by consistent hashing. Orders for partition Index arrive on Queue,
+// bound to OrderExchange with the per-partition routing key.
+type PartitionConfig struct {
+	Count        int
+	Index        int
+	VirtualNodes int
+
+	OrderExchange   string
+	OrderRoutingKey string // base key, suffixed with the partition when Count > 1
+	Queue           string
+}
+
+// LimitsConfig holds engine-wide per-user limits; zero means unlimited.
+// Open orders and notional are summed over all events, position is per event.
+type LimitsConfig struct {
+	MaxOpenOrders   int
+	MaxOpenNotional int64
+	MaxPosition     int
+
+	// Order rate limits by user tier, from ENGINE_RATE_TIERS as JSON, e.g.
+	// {"default":{"userRate":20,"userBurst":40,"eventRate":10}}. Empty disables them.
+	RateTiers map[string]ratelimit.Tier
 }
 
 type ServerConfig struct {
-	Port string
+	Port    string
+	DataDir string // where the engine keeps its local state files
+
+	// ShutdownTimeout bounds the drain, confirm wait and snapshot on SIGTERM
+	ShutdownTimeout time.Duration
+
+	// gRPC order entry and market data; ENGINE_GRPC_ADDR set to an empty
+	// address disables it
+	GRPCAddr         string
+	GRPCTimeout      time.Duration // per order request
+	GRPCStreamBuffer int           // book events a subscriber may lag before it is dropped
 }
 
 type RabbitMQConfig struct {
@@ -21,7 +109,110 @@ type RabbitMQConfig struct {
 	Host      string
 	Port      string
 	URL       string
-	Exchanges []string
+	Exchanges []ExchangeConfig
+	Queues    []QueueConfig
+	Bindings  []BindingConfig
+
+	// Dead-lettering: rejected messages are routed to DeadLetterQueue through
+	// DeadLetterExchange using DeadLetterRoutingKey
+	DeadLetterExchange   string
+	DeadLetterQueue      string
+	DeadLetterRoutingKey string
+
+	// Content type for published messages, from ENGINE_WIRE_FORMAT ("json" || "protobuf").
+	// Consumed messages are decoded according to their own content type.
+	ContentType string
+
+	// Reconnect backoff bounds for the consumer supervisor
+	ReconnectMinDelay time.Duration
+	ReconnectMaxDelay time.Duration
+
+	// Unacked deliveries the broker may push ahead of processing; it also
+	// bounds how much is left to finish when shutting down
+	Prefetch int
+}
+
+// TopologyConfig is the broker topology declared besides what the engine
+// consumes (its order exchange, queue and binding, and the journal). It is
+// read as JSON from the file named by RABBITMQ_TOPOLOGY_FILE, e.g.
+// {"exchanges":[{"name":"trade_exchange","kind":"topic"}],"queues":[],"bindings":[]},
+// and defaults to the exchanges the engine publishes to.
+type TopologyConfig struct {
+	Exchanges []ExchangeConfig `json:"exchanges"`
+	Queues    []QueueConfig    `json:"queues"`
+	Bindings  []BindingConfig  `json:"bindings"`
+}
+
+// ExchangeConfig describes an exchange the engine declares at startup
+type ExchangeConfig struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // "direct" || "topic" || "fanout" || "headers"
+}
+
+// QueueConfig describes a queue the engine declares at startup
+type QueueConfig struct {
+	Name       string `json:"name"`
+	DeadLetter bool   `json:"deadLetter"` // route rejected messages to the dead-letter exchange
+}
+
+// BindingConfig binds a queue to an exchange with a routing key
+type BindingConfig struct {
+	Queue      string `json:"queue"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routingKey"`
+}
+
+// defaultTopology declares the exchanges the engine publishes to
+var defaultTopology = TopologyConfig{
+	Exchanges: []ExchangeConfig{
+		{Name: "trade_exchange", Kind: "topic"},
+		{Name: "price_exchange", Kind: "topic"},
+		{Name: "order_book_exchange", Kind: "topic"},
+		{Name: "execution_exchange", Kind: "topic"},
+	},
+}
+
+// loadTopology reads a topology file, or returns the default without one
+func loadTopology(path string) (TopologyConfig, error) {
+	if path == "" {
+		return defaultTopology, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TopologyConfig{}, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var topology TopologyConfig
+	if err := decoder.Decode(&topology); err != nil {
+		return TopologyConfig{}, err
+	}
+	return topology, topology.Validate()
+}
+
+// Validate checks that every declaration is named and every exchange has a known kind
+func (t TopologyConfig) Validate() error {
+	for _, ex := range t.Exchanges {
+		switch ex.Kind {
+		case "direct", "topic", "fanout", "headers":
+		default:
+			return fmt.Errorf("exchange %q has invalid kind %q", ex.Name, ex.Kind)
+		}
+		if ex.Name == "" {
+			return errors.New("exchange without a name")
+		}
+	}
+	for _, q := range t.Queues {
+		if q.Name == "" {
+			return errors.New("queue without a name")
+		}
+	}
+	for _, b := range t.Bindings {
+		if b.Queue == "" || b.Exchange == "" {
+			return fmt.Errorf("binding of queue %q to exchange %q is incomplete", b.Queue, b.Exchange)
+		}
+	}
+	return nil
 }
 
 var AppConfig Config
@@ -34,13 +225,42 @@ func LoadConfig() {
 	requiredVars := []string{"PORT", "RABBITMQ_USER", "RABBITMQ_PASSWORD", "RABBITMQ_HOST", "RABBITMQ_PORT"}
 	for _, v := range requiredVars {
 		if os.Getenv(v) == "" {
-			logger.Fatal("Environment variable is required but not set", "variable", "v")
+			logger.Fatal("Environment variable is required but not set", "variable", v)
 		}
 	}
 
+	contentType, err := wire.FormatContentType(os.Getenv("ENGINE_WIRE_FORMAT"))
+	if err != nil {
+		logger.Fatal("Invalid ENGINE_WIRE_FORMAT", "error", err)
+	}
+
+	partitions := PartitionConfig{
+		Count:           int(getEnvInt("ENGINE_PARTITIONS", 1)),
+		Index:           int(getEnvInt("ENGINE_PARTITION", 0)),
+		VirtualNodes:    int(getEnvInt("ENGINE_PARTITION_VNODES", partition.DefaultVirtualNodes)),
+		OrderExchange:   getEnv("ENGINE_ORDER_EXCHANGE", "Order_Exchange"),
+		OrderRoutingKey: getEnv("ENGINE_ORDER_ROUTING_KEY", "Order.add"),
+	}
+	if partitions.Count < 1 || partitions.Index < 0 || partitions.Index >= partitions.Count {
+		logger.Fatal("Invalid partition", "partition", partitions.Index, "partitions", partitions.Count)
+	}
+	partitions.Queue = partition.QueueName(getEnv("ENGINE_ORDER_QUEUE", "order_queue"), partitions.Index, partitions.Count)
+
+	topology, err := loadTopology(os.Getenv("RABBITMQ_TOPOLOGY_FILE"))
+	if err != nil {
+		logger.Fatal("Invalid RABBITMQ_TOPOLOGY_FILE", "error", err)
+	}
+
 	AppConfig = Config{
 		Server: ServerConfig{
-			Port: os.Getenv("PORT"),
+			Port:    os.Getenv("PORT"),
+			DataDir: getEnv("ENGINE_DATA_DIR", "data"),
+
+			ShutdownTimeout: getEnvDuration("ENGINE_SHUTDOWN_TIMEOUT", 30*time.Second),
+
+			GRPCAddr:         getEnvOrEmpty("ENGINE_GRPC_ADDR", ":50051"),
+			GRPCTimeout:      getEnvDuration("ENGINE_GRPC_TIMEOUT", 5*time.Second),
+			GRPCStreamBuffer: int(getEnvInt("ENGINE_GRPC_STREAM_BUFFER", 256)),
 		},
 		RabbitMQ: RabbitMQConfig{
 			User:      os.Getenv("RABBITMQ_USER"),
@@ -48,9 +268,79 @@ func LoadConfig() {
 			Host:      os.Getenv("RABBITMQ_HOST"),
 			Port:      os.Getenv("RABBITMQ_PORT"),
 			URL:       buildRabbitMQURL(),
-			Exchanges: []string{"auth_exchange", "dlx_exchange"},
+			Exchanges: append([]ExchangeConfig{{Name: partitions.OrderExchange, Kind: "direct"}}, topology.Exchanges...),
+			Queues:    append([]QueueConfig{{Name: partitions.Queue, DeadLetter: true}}, topology.Queues...),
+			Bindings: append([]BindingConfig{{
+				Queue:      partitions.Queue,
+				Exchange:   partitions.OrderExchange,
+				RoutingKey: partition.RoutingKey(partitions.OrderRoutingKey, partitions.Index, partitions.Count),
+			}}, topology.Bindings...),
+			DeadLetterExchange:   getEnv("RABBITMQ_DLX", "dlx_exchange"),
+			DeadLetterQueue:      getEnv("RABBITMQ_DLQ", "engine_dlq"),
+			DeadLetterRoutingKey: getEnv("RABBITMQ_DLX_ROUTING_KEY", "engine.dlx"),
+
+			ContentType: contentType,
+
+			ReconnectMinDelay: getEnvDuration("RABBITMQ_RECONNECT_MIN_DELAY", 1*time.Second),
+			ReconnectMaxDelay: getEnvDuration("RABBITMQ_RECONNECT_MAX_DELAY", 30*time.Second),
+
+			Prefetch: int(getEnvInt("RABBITMQ_PREFETCH", 100)),
 		},
 	}
+	AppConfig.Partition = partitions
+
+	instanceID, _ := os.Hostname()
+	AppConfig.Standby = StandbyConfig{
+		Enabled:           os.Getenv("ENGINE_HA") == "true",
+		InstanceID:        getEnv("ENGINE_INSTANCE_ID", instanceID),
+		LeasePath:         os.Getenv("ENGINE_LEASE_PATH"),
+		LeaseTTL:          getEnvDuration("ENGINE_LEASE_TTL", 10*time.Second),
+		JournalExchange:   getEnv("ENGINE_JOURNAL_EXCHANGE", "journal_exchange"),
+		JournalQueue:      partition.QueueName("engine_journal", partitions.Index, partitions.Count),
+		JournalRoutingKey: partition.RoutingKey("journal", partitions.Index, partitions.Count),
+	}
+	if standby := AppConfig.Standby; standby.Enabled {
+		if standby.LeaseTTL <= 0 || standby.InstanceID == "" {
+			logger.Fatal("Invalid standby configuration", "lease_ttl", standby.LeaseTTL.String(), "instance", standby.InstanceID)
+		}
+		if standby.LeasePath == "" || inDir(standby.LeasePath, AppConfig.Server.DataDir) {
+			logger.Fatal("ENGINE_LEASE_PATH must name shared storage outside the instance's data directory", "lease", standby.LeasePath, "data_dir", AppConfig.Server.DataDir)
+		}
+		rabbit := &AppConfig.RabbitMQ
+		rabbit.Exchanges = append(rabbit.Exchanges, ExchangeConfig{Name: standby.JournalExchange, Kind: "direct"})
+		rabbit.Queues = append(rabbit.Queues, QueueConfig{Name: standby.JournalQueue})
+		rabbit.Bindings = append(rabbit.Bindings, BindingConfig{Queue: standby.JournalQueue, Exchange: standby.JournalExchange, RoutingKey: standby.JournalRoutingKey})
+	}
+	AppConfig.Gateway = GatewayConfig{
+		Addr:             getEnv("WS_GATEWAY_ADDR", ":8081"),
+		Queue:            os.Getenv("WS_GATEWAY_QUEUE"),
+		SendBuffer:       int(getEnvInt("WS_SEND_BUFFER", 256)),
+		WriteTimeout:     getEnvDuration("WS_WRITE_TIMEOUT", 10*time.Second),
+		PingInterval:     getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
+		MaxSubscriptions: int(getEnvInt("WS_MAX_SUBSCRIPTIONS", 100)),
+	}
+	AppConfig.Limits = LimitsConfig{
+		MaxOpenOrders:   int(getEnvInt("ENGINE_MAX_OPEN_ORDERS", 0)),
+		MaxOpenNotional: getEnvInt("ENGINE_MAX_OPEN_NOTIONAL", 0),
+		MaxPosition:     int(getEnvInt("ENGINE_MAX_POSITION", 0)),
+	}
+	if tiers := os.Getenv("ENGINE_RATE_TIERS"); tiers != "" {
+		if err := json.Unmarshal([]byte(tiers), &AppConfig.Limits.RateTiers); err != nil {
+			logger.Fatal("Invalid ENGINE_RATE_TIERS", "error", err)
+		}
+		for name, tier := range AppConfig.Limits.RateTiers {
+			if err := tier.Validate(); err != nil {
+				logger.Fatal("Invalid ENGINE_RATE_TIERS", "tier", name, "error", err)
+			}
+		}
+	}
+	if id := os.Getenv("ENGINE_MAKER_USER_ID"); id != "" {
+		userID, ok := new(big.Int).SetString(id, 10)
+		if !ok || userID.Sign() <= 0 {
+			logger.Fatal("Invalid ENGINE_MAKER_USER_ID", "value", id)
+		}
+		AppConfig.Maker.UserID = userID
+	}
 	logger.Info("Configuration loaded successfully")
 }
 
@@ -61,3 +351,54 @@ func buildRabbitMQURL() string {
 	port := os.Getenv("RABBITMQ_PORT")
 	return "amqp://" + user + ":" + password + "@" + host + ":" + port
 }
+
+// getEnv reads an optional variable and falls back to def when unset
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvOrEmpty is getEnv for a variable whose empty value means something,
+// falling back to def only when it is unset
+func getEnvOrEmpty(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
+
+// getEnvDuration reads an optional duration (e.g. "5s") and falls back to def when unset or invalid
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Warn("Invalid duration in environment, using default", "variable", key, "value", value)
+		return def
+	}
+	return d
+}
+
+// getEnvInt reads an optional integer and falls back to def when unset or invalid
+func getEnvInt(key string, def int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		logger.Warn("Invalid integer in environment, using default", "variable", key, "value", value)
+		return def
+	}
+	return n
+}
+
+// inDir reports whether path lies inside dir
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTopology(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          string // contents, empty for no file
+		wantExchanges int
+		wantErr       bool
+	}{
+		{"default", "", len(defaultTopology.Exchanges), false},
+		{"from file", `{"exchanges":[{"name":"trade_exchange","kind":"topic"}],"queues":[{"name":"audit","deadLetter":true}],"bindings":[{"queue":"audit","exchange":"trade_exchange","routingKey":"#"}]}`, 1, false},
+		{"invalid kind", `{"exchanges":[{"name":"trade_exchange","kind":"round-robin"}]}`, 0, true},
+		{"incomplete binding", `{"bindings":[{"queue":"audit"}]}`, 0, true},
+		{"unknown field", `{"exchange":[]}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "topology.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			topology, err := loadTopology(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(topology.Exchanges) != tt.wantExchanges {
+				t.Errorf("%d exchanges, want %d", len(topology.Exchanges), tt.wantExchanges)
+			}
+		})
+	}
+}
+
+func TestGetEnvOrEmpty(t *testing.T) {
+	const key = "ENGINE_TEST_ADDR"
+	if got := getEnvOrEmpty(key, ":50051"); got != ":50051" {
+		t.Errorf("unset: %q, want the default", got)
+	}
+	t.Setenv(key, "")
+	if got := getEnvOrEmpty(key, ":50051"); got != "" {
+		t.Errorf("set empty: %q, want it kept empty", got)
+	}
+	t.Setenv(key, ":6000")
+	if got := getEnvOrEmpty(key, ":50051"); got != ":6000" {
+		t.Errorf("set: %q", got)
+	}
+}
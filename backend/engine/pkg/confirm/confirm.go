@@ -0,0 +1,138 @@
+// dummyengine/pkg/confirm/confirm.go
+package confirm
+
+import (
+	"context"
+	"dummyengine/pkg/logger"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrClosed is returned when the channel closes with confirms still outstanding
+var ErrClosed = errors.New("channel closed before all publishes were confirmed")
+
+// Tracker counts publishes on a channel in confirm mode against the broker's
+// acks and nacks, so shutdown can wait until nothing is left in flight
+type Tracker struct {
+	mu        sync.Mutex
+	published uint64
+	acked     uint64
+	nacked    uint64
+	reported  uint64 // nacks already returned by Wait
+	closed    bool
+	changed   chan struct{} // closed and replaced on every update
+}
+
+var (
+	registryMu sync.Mutex
+	trackers   = map[*amqp.Channel]*Tracker{}
+)
+
+// Enable puts ch into confirm mode and starts tracking its confirmations
+func Enable(ch *amqp.Channel) (*Tracker, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, err
+	}
+
+	t := &Tracker{changed: make(chan struct{})}
+	// Buffered and always drained: the library blocks publishing on a full notify channel
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1024))
+
+	registryMu.Lock()
+	trackers[ch] = t
+	registryMu.Unlock()
+
+	go t.listen(ch, confirms)
+	return t, nil
+}
+
+// Published records a successful publish on ch; a no-op outside confirm mode
+func Published(ch *amqp.Channel) {
+	registryMu.Lock()
+	t := trackers[ch]
+	registryMu.Unlock()
+
+	if t != nil {
+		t.update(func() { t.published++ })
+	}
+}
+
+// Outstanding returns how many publishes the broker has not confirmed yet
+func (t *Tracker) Outstanding() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.outstanding()
+}
+
+// Wait blocks until every publish so far is confirmed. It fails if the
+// broker nacked any publish since the previous Wait, the channel closed
+// first, or ctx expired.
+func (t *Tracker) Wait(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		outstanding, closed, changed := t.outstanding(), t.closed, t.changed
+		nacked := t.nacked - t.reported
+		if outstanding == 0 {
+			t.reported = t.nacked
+		}
+		t.mu.Unlock()
+
+		if outstanding == 0 {
+			if nacked > 0 {
+				return fmt.Errorf("broker nacked %d publishes", nacked)
+			}
+			return nil
+		}
+		if closed {
+			return fmt.Errorf("%w: %d outstanding", ErrClosed, outstanding)
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %d publish confirms: %w", outstanding, ctx.Err())
+		}
+	}
+}
+
+func (t *Tracker) listen(ch *amqp.Channel, confirms <-chan amqp.Confirmation) {
+	for c := range confirms {
+		if !c.Ack {
+			logger.Error("❌ Broker nacked a publish", "delivery_tag", c.DeliveryTag)
+		}
+		t.update(func() {
+			if c.Ack {
+				t.acked++
+			} else {
+				t.nacked++
+			}
+		})
+	}
+
+	registryMu.Lock()
+	delete(trackers, ch)
+	registryMu.Unlock()
+	t.update(func() { t.closed = true })
+}
+
+// update applies fn under the lock and wakes every waiter
+func (t *Tracker) update(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn()
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// outstanding is published minus confirmed; a confirm can race ahead of its
+// Published call, so it never goes below zero. Callers hold mu.
+func (t *Tracker) outstanding() uint64 {
+	confirmed := t.acked + t.nacked
+	if confirmed >= t.published {
+		return 0
+	}
+	return t.published - confirmed
+}
@@ -0,0 +1,271 @@
+// dummyengine/pkg/deadletter/deadletter.go
+package deadletter
+
+import (
+	"context"
+	"dummyengine/pkg/confirm"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Headers stamped on every parked message by the engine
+const (
+	ReasonHeader        = "x-reject-reason"
+	OriginalQueueHeader = "x-original-queue"
+	RejectedAtHeader    = "x-rejected-at"
+	ReplayCountHeader   = "x-replay-count"
+)
+
+var ErrNotFound = errors.New("message not found in dead-letter queue")
+
+// confirmTimeout bounds the wait for the broker to confirm a replayed message
+const confirmTimeout = 10 * time.Second
+
+// Message is a parked delivery together with the metadata the engine attached
+type Message struct {
+	ID            string
+	Reason        string
+	OriginalQueue string
+	RejectedAt    time.Time
+	ContentType   string
+	Headers       amqp.Table
+	Body          []byte
+
+	delivery amqp.Delivery
+}
+
+// Queue gives access to the messages parked in a dead-letter queue. Messages
+// fetched through it stay unacked until Replay or Purge settles them; anything
+// left over is returned to the queue by Close.
+type Queue struct {
+	Ch   *amqp.Channel
+	Name string
+
+	fetched  []*Message
+	confirms *confirm.Tracker // set once the first replay puts Ch in confirm mode
+}
+
+func NewQueue(ch *amqp.Channel, name string) *Queue {
+	return &Queue{Ch: ch, Name: name}
+}
+
+// List drains the queue into memory without acking, so every parked message is
+// visible exactly once for the lifetime of the channel.
+func (q *Queue) List() ([]*Message, error) {
+	for {
+		d, ok, err := q.Ch.Get(q.Name, false)
+		if err != nil {
+			return nil, fmt.Errorf("get from %q: %w", q.Name, err)
+		}
+		if !ok {
+			break
+		}
+		q.fetched = append(q.fetched, fromDelivery(d))
+	}
+	return q.fetched, nil
+}
+
+// Find returns the parked message with the given ID
+func (q *Queue) Find(id string) (*Message, error) {
+	messages, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range messages {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// JSONBody returns the payload as JSON, decoding binary engine messages so
+// that they can be shown and edited like any other
+func (m *Message) JSONBody() ([]byte, error) {
+	contentType, err := wire.Negotiate(m.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == wire.ContentTypeJSON {
+		return m.Body, nil
+	}
+
+	var event wire.EventMessage
+	if err := wire.Unmarshal(contentType, m.Body, &event); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&event)
+}
+
+// Replay publishes body (the original payload or an edited one) through
+// exchange with routingKey, "" and a queue name reaching the queue directly.
+// The parked copy is removed only once the broker has confirmed the publish;
+// otherwise it stays parked and an error is returned.
+func (q *Queue) Replay(m *Message, exchange, routingKey, contentType string, body []byte) error {
+	if q.confirms == nil {
+		confirms, err := confirm.Enable(q.Ch)
+		if err != nil {
+			return fmt.Errorf("enable publish confirms: %w", err)
+		}
+		q.confirms = confirms
+	}
+
+	headers := amqp.Table{}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	headers[ReplayCountHeader] = replayCount(m.Headers) + 1
+	delete(headers, ReasonHeader)
+	delete(headers, RejectedAtHeader)
+
+	err := q.Ch.Publish(
+		exchange,
+		routingKey,
+		false, // Mandatory
+		false, // Immediate
+		amqp.Publishing{
+			ContentType:  contentType,
+			MessageId:    m.ID,
+			Headers:      headers,
+			Body:         body,
+			Timestamp:    time.Now(),
+			DeliveryMode: amqp.Persistent,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("publish to %q: %w", routingKey, err)
+	}
+	confirm.Published(q.Ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), confirmTimeout)
+	defer cancel()
+	if err := q.confirms.Wait(ctx); err != nil {
+		return fmt.Errorf("replay to %q not confirmed: %w", routingKey, err)
+	}
+	return q.settle(m)
+}
+
+// Purge drops a single parked message
+func (q *Queue) Purge(m *Message) error {
+	return q.settle(m)
+}
+
+// PurgeAll drops every parked message and returns how many were removed
+func (q *Queue) PurgeAll() (int, error) {
+	messages, err := q.List()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, m := range messages {
+		if err := q.settle(m); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Close requeues everything that was fetched but not settled
+func (q *Queue) Close() error {
+	for _, m := range q.fetched {
+		if err := m.delivery.Nack(false, true); err != nil {
+			return err
+		}
+	}
+	q.fetched = nil
+	return nil
+}
+
+func (q *Queue) settle(m *Message) error {
+	if err := m.delivery.Ack(false); err != nil {
+		return err
+	}
+	for i, f := range q.fetched {
+		if f == m {
+			q.fetched = append(q.fetched[:i], q.fetched[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// EditJSON applies top-level field overrides to a JSON payload. Values are
+// parsed as JSON when possible (numbers, booleans, objects) and as plain
+// strings otherwise.
+func EditJSON(body []byte, fields map[string]string) ([]byte, error) {
+	payload := map[string]interface{}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	for key, raw := range fields {
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		payload[key] = value
+	}
+	return json.Marshal(payload)
+}
+
+func fromDelivery(d amqp.Delivery) *Message {
+	m := &Message{
+		ID:          d.MessageId,
+		ContentType: d.ContentType,
+		Headers:     d.Headers,
+		Body:        d.Body,
+		delivery:    d,
+	}
+	if m.ID == "" {
+		m.ID = fmt.Sprintf("tag-%d", d.DeliveryTag)
+	}
+	if reason, ok := d.Headers[ReasonHeader].(string); ok {
+		m.Reason = reason
+	} else if reason := deathReason(d.Headers); reason != "" {
+		m.Reason = reason
+	}
+	if queue, ok := d.Headers[OriginalQueueHeader].(string); ok {
+		m.OriginalQueue = queue
+	}
+	if ts, ok := d.Headers[RejectedAtHeader].(int64); ok {
+		m.RejectedAt = time.Unix(ts, 0)
+	} else if !d.Timestamp.IsZero() {
+		m.RejectedAt = d.Timestamp
+	}
+	return m
+}
+
+// deathReason reads the broker's own x-death header, set when a message was
+// dead-lettered by a plain nack rather than parked by the engine
+func deathReason(headers amqp.Table) string {
+	deaths, ok := headers["x-death"].([]interface{})
+	if !ok || len(deaths) == 0 {
+		return ""
+	}
+	death, ok := deaths[0].(amqp.Table)
+	if !ok {
+		return ""
+	}
+	reason, _ := death["reason"].(string)
+	if queue, ok := death["queue"].(string); ok {
+		return fmt.Sprintf("broker %s from %s", reason, queue)
+	}
+	return "broker " + reason
+}
+
+func replayCount(headers amqp.Table) int32 {
+	switch v := headers[ReplayCountHeader].(type) {
+	case int32:
+		return v
+	case int64:
+		return int32(v)
+	case int:
+		return int32(v)
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestEditJSON(t *testing.T) {
+	body := []byte(`{"task":"Order","eventId":"7","price":500,"quantity":3}`)
+
+	edited, err := EditJSON(body, map[string]string{
+		"price":    "450",       // number
+		"type":     "SELL",      // not JSON, kept as a string
+		"eventId":  `"8"`,       // quoted JSON string
+		"postOnly": "true",      // boolean
+		"limits":   `{"max":1}`, // object
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(edited, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"task":     "Order",
+		"eventId":  "8",
+		"price":    450.0,
+		"quantity": 3.0,
+		"type":     "SELL",
+		"postOnly": true,
+		"limits":   map[string]interface{}{"max": 1.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edited payload %v, want %v", got, want)
+	}
+
+	for _, body := range []string{`[1,2]`, `not json`, `"Order"`} {
+		if _, err := EditJSON([]byte(body), map[string]string{"price": "1"}); err == nil {
+			t.Errorf("EditJSON(%s) took a payload that is not an object", body)
+		}
+	}
+}
+
+func TestFromDeliveryParkedByEngine(t *testing.T) {
+	m := fromDelivery(amqp.Delivery{
+		MessageId:   "42",
+		ContentType: "application/json",
+		Headers: amqp.Table{
+			ReasonHeader:        "invalid eventId",
+			OriginalQueueHeader: "order_queue.1",
+			RejectedAtHeader:    int64(1700000000),
+			"x-death":           []interface{}{amqp.Table{"reason": "rejected", "queue": "order_queue.1"}},
+			ReplayCountHeader:   int32(2),
+			"x-something-extra": "kept",
+		},
+		Timestamp: time.Unix(1600000000, 0),
+		Body:      []byte(`{}`),
+	})
+
+	if m.ID != "42" || m.ContentType != "application/json" {
+		t.Errorf("id %q content type %q", m.ID, m.ContentType)
+	}
+	// The engine's own reason and time win over the broker's
+	if m.Reason != "invalid eventId" {
+		t.Errorf("reason %q", m.Reason)
+	}
+	if !m.RejectedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("rejected at %v", m.RejectedAt)
+	}
+	if m.OriginalQueue != "order_queue.1" {
+		t.Errorf("original queue %q", m.OriginalQueue)
+	}
+	if replayCount(m.Headers) != 2 {
+		t.Errorf("replay count %d", replayCount(m.Headers))
+	}
+}
+
+func TestFromDeliveryDeadLetteredByBroker(t *testing.T) {
+	published := time.Unix(1600000000, 0)
+	m := fromDelivery(amqp.Delivery{
+		DeliveryTag: 9,
+		Headers: amqp.Table{
+			"x-death": []interface{}{amqp.Table{"reason": "expired", "queue": "order_queue"}},
+		},
+		Timestamp: published,
+	})
+
+	// Without a message ID the delivery tag is the handle
+	if m.ID != "tag-9" {
+		t.Errorf("id %q, want tag-9", m.ID)
+	}
+	if m.Reason != "broker expired from order_queue" {
+		t.Errorf("reason %q", m.Reason)
+	}
+	if !m.RejectedAt.Equal(published) {
+		t.Errorf("rejected at %v, want the publish time %v", m.RejectedAt, published)
+	}
+	if m.OriginalQueue != "" {
+		t.Errorf("original queue %q, want none", m.OriginalQueue)
+	}
+
+	if m := fromDelivery(amqp.Delivery{DeliveryTag: 1}); m.Reason != "" || !m.RejectedAt.IsZero() {
+		t.Errorf("bare delivery read as reason %q at %v", m.Reason, m.RejectedAt)
+	}
+}
@@ -0,0 +1,57 @@
+// dummyengine/pkg/exchange/auction.go
+package exchange
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Uncross ends an event's opening auction on request, in every outcome's book
+func (e *Exchange) Uncross(eventID *big.Int) error {
+	books, err := e.books(eventID)
+	if err != nil {
+		return err
+	}
+	if !books[0].Auction {
+		return fmt.Errorf("event %s is not in its opening auction", eventID)
+	}
+	for _, orderBook := range books {
+		orderBook.Uncross()
+	}
+	e.record(books[0])
+	return nil
+}
+
+// UncrossDue ends every opening auction whose end time has passed and
+// reports how many books it uncrossed. It runs before each input, judged at
+// the input's receive time, so a replica uncrosses at the same point in the
+// stream as the leader; the leader also runs it between inputs, see Tick.
+func (e *Exchange) UncrossDue() int {
+	at := e.receivedAt().Unix()
+	var due []string
+	for eventKey, orderBook := range e.OrderBooks {
+		if orderBook.Auction && orderBook.Params.AuctionEnd > 0 && at >= orderBook.Params.AuctionEnd {
+			due = append(due, eventKey)
+		}
+	}
+	sort.Strings(due)
+	for _, eventKey := range due {
+		e.OrderBooks[eventKey].Uncross()
+		e.record(e.OrderBooks[eventKey])
+	}
+	return len(due)
+}
+
+// Tick advances the book clock to at without an input, uncrossing the
+// auctions that are due so a quiet event does not wait for its next order.
+// Inputs are stamped when received, so every later input is at or past at
+// and a replica following the journal uncrosses the same books before
+// applying it.
+func (e *Exchange) Tick(at time.Time) {
+	e.ReceivedAt = at
+	if e.UncrossDue() > 0 {
+		e.Requote()
+	}
+}
@@ -0,0 +1,117 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUncrossDueAtReceivedTime(t *testing.T) {
+	e := newTestExchange(t)
+	start := time.Unix(1000, 0)
+	e.ReceivedAt = start
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{OpeningAuction: true, AuctionEnd: 1060}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddEvent(big.NewInt(2), orderbook.Params{OpeningAuction: true}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 490, Quantity: 5})
+	e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(2), UserID: big.NewInt(2), Price: 510, Quantity: 5})
+
+	e.ReceivedAt = start.Add(59 * time.Second)
+	e.UncrossDue()
+	if !e.OrderBooks["1"].Auction {
+		t.Fatal("uncrossed before its end")
+	}
+
+	// Judged by the input's time, not the clock
+	e.ReceivedAt = start.Add(time.Minute)
+	e.UncrossDue()
+	if e.OrderBooks["1"].Auction || e.OrderBooks["1"].GetTopBuyOrder() != nil {
+		t.Error("event 1 not uncrossed at its end")
+	}
+	if !e.OrderBooks["2"].Auction {
+		t.Error("an auction with no end time waits for an Uncross task")
+	}
+}
+
+func TestUncrossTask(t *testing.T) {
+	e := newTestExchange(t)
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{OpeningAuction: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Uncross(big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Uncross(big.NewInt(1)); err == nil {
+		t.Error("uncrossed twice")
+	}
+	if err := e.Uncross(big.NewInt(9)); err == nil {
+		t.Error("uncrossed an unknown event")
+	}
+}
+
+func TestAllOrNoneBatchDuringTheCall(t *testing.T) {
+	e, got, traded := batchExchange(t)
+	if err := e.AddEvent(big.NewInt(8), orderbook.Params{OpeningAuction: true}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(8), orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(2), Price: 600, Quantity: 3})
+
+	// The trial runs in the call too: the post-only leg is refused, and with
+	// it the crossing one
+	crossing := wire.BatchOrderEntry{OrderID: "10", OrderUserID: "1", Type: "BUY", OrderPrice: 610, OrderQuantity: 3}
+	passive := wire.BatchOrderEntry{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 500, OrderQuantity: 1, PostOnly: true}
+	e.AddBatch(big.NewInt(8), 0, "b1", []wire.BatchOrderEntry{crossing, passive}, true)
+	if statuses := got.statuses(); statuses[10] != wire.StatusRejected || statuses[11] != wire.StatusRejected {
+		t.Errorf("statuses %v, want both legs rejected", statuses)
+	}
+	if e.OrderBooks["8"].GetTopBuyOrder() != nil {
+		t.Error("a leg of the refused batch rests")
+	}
+
+	// Alone, the crossing leg rests until the uncross
+	e.AddBatch(big.NewInt(8), 0, "b2", []wire.BatchOrderEntry{crossing}, true)
+	if *traded != 0 || e.OrderBooks["8"].GetTopBuyOrder() == nil {
+		t.Errorf("%d fills: want the crossing leg resting unfilled", *traded)
+	}
+}
+
+func TestTick(t *testing.T) {
+	end := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		at          time.Time
+		wantOpen    bool // still in the auction afterwards
+		wantResting int  // buyer's orders resting afterwards
+	}{
+		{"before the end", end.Add(-time.Second), true, 1},
+		{"at the end", end, false, 0},
+		{"after the end", end.Add(time.Minute), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExchange(t)
+			eventID := big.NewInt(7)
+			if err := e.AddEvent(eventID, orderbook.Params{OpeningAuction: true, AuctionEnd: end.Unix()}); err != nil {
+				t.Fatal(err)
+			}
+			e.ReceivedAt = end.Add(-time.Minute)
+			e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 500, Quantity: 2})
+			e.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(2), UserID: big.NewInt(2), Price: 520, Quantity: 2})
+
+			e.Tick(tt.at)
+
+			book := e.OrderBooks[eventID.String()]
+			if book.Auction != tt.wantOpen {
+				t.Fatalf("auction open = %v, want %v", book.Auction, tt.wantOpen)
+			}
+			if open := book.Usage(big.NewInt(2)).OpenOrders; open != tt.wantResting {
+				t.Errorf("%d buy orders resting, want %d", open, tt.wantResting)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// dummyengine/pkg/exchange/batch.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// AddBatch validates and places a batch of orders for one outcome's book in a
+// single pass. With allOrNone a single invalid order, or one the book would
+// refuse once placed, rejects the whole batch, so a quote ladder is never left
+// half on the book. Every order gets an execution report.
+func (e *Exchange) AddBatch(eventID *big.Int, outcome int, batchID string, entries []wire.BatchOrderEntry, allOrNone bool) {
+	eventKey := wire.MarketKey(eventID, outcome)
+
+	requests := make([]orderbook.OrderRequest, len(entries))
+	errs := make([]error, len(entries))
+	invalid := 0
+	for i, entry := range entries {
+		requests[i], errs[i] = parseBatchEntry(entry, outcome)
+		if errs[i] == nil {
+			errs[i] = e.checkUser(requests[i].UserID)
+		}
+		if errs[i] != nil {
+			invalid++
+		}
+	}
+
+	if err := e.checkOwner(eventID); err != nil {
+		logger.Warn("⛔ Batch for an event owned elsewhere", "batch_id", batchID, "error", err)
+		for i := range requests {
+			e.publishReport(rejectedReport(eventID, batchID, requests[i], err.Error()))
+		}
+		return
+	}
+
+	orderBook, exists := e.OrderBooks[eventKey]
+	if !exists {
+		logger.Error("OrderBook not found", "event_key", eventKey, "batch_id", batchID)
+		for i := range requests {
+			e.publishReport(rejectedReport(eventID, batchID, requests[i], "OrderBook not found"))
+		}
+		return
+	}
+
+	// The event's own rules need its book
+	for i := range requests {
+		if errs[i] == nil {
+			if errs[i] = orderBook.CheckOrder(requests[i], e.receivedAt()); errs[i] != nil {
+				invalid++
+			}
+		}
+	}
+
+	if allOrNone && invalid > 0 {
+		logger.Warn("⚠️ Rejecting all-or-none batch", "event_key", eventKey, "batch_id", batchID, "invalid", invalid)
+		for i := range requests {
+			reason := fmt.Sprintf("batch rejected: %d invalid order(s)", invalid)
+			if errs[i] != nil {
+				reason = errs[i].Error()
+			}
+			e.publishReport(rejectedReport(eventID, batchID, requests[i], reason))
+		}
+		return
+	}
+
+	valid := make([]orderbook.OrderRequest, 0, len(requests)-invalid)
+	for i := range requests {
+		if errs[i] != nil {
+			e.publishReport(rejectedReport(eventID, batchID, requests[i], errs[i].Error()))
+			continue
+		}
+		valid = append(valid, requests[i])
+	}
+
+	// Rate limits come first, so a throttled order is never charged to limits
+	if allOrNone {
+		if err := e.throttleBatch(eventID, valid); err != nil {
+			logger.Warn("🚦 All-or-none batch throttled", "event_key", eventKey, "batch_id", batchID, "error", err)
+			for _, req := range valid {
+				e.publishReport(rejectedReport(eventID, batchID, req, err.Error()))
+			}
+			return
+		}
+	} else {
+		admitted := valid[:0:0]
+		for _, req := range valid {
+			if err := e.throttle(eventID, req.UserID, req.Tier, 1); err != nil {
+				invalid++
+				e.publishReport(rejectedReport(eventID, batchID, req, err.Error()))
+				continue
+			}
+			admitted = append(admitted, req)
+		}
+		if throttled := len(valid) - len(admitted); throttled > 0 {
+			logger.Warn("🚦 Batch orders throttled", "event_key", eventKey, "batch_id", batchID, "throttled", throttled)
+		}
+		valid = admitted
+	}
+
+	// Limits are checked cumulatively: as a whole for all-or-none batches,
+	// otherwise order by order on top of those already accepted
+	if allOrNone {
+		if err := e.checkLimits(orderBook, valid); err != nil {
+			logger.Warn("⛔ All-or-none batch rejected by limits", "event_key", eventKey, "batch_id", batchID, "error", err)
+			for _, req := range valid {
+				e.publishReport(rejectedReport(eventID, batchID, req, err.Error()))
+			}
+			return
+		}
+	} else {
+		accepted := valid[:0:0]
+		for _, req := range valid {
+			if err := e.checkLimits(orderBook, append(accepted, req)); err != nil {
+				invalid++
+				e.publishReport(rejectedReport(eventID, batchID, req, err.Error()))
+				continue
+			}
+			accepted = append(accepted, req)
+		}
+		valid = accepted
+	}
+
+	// The book's own refusals, post-only legs that would take and minimum or
+	// all-or-none quantities that cannot fill, show only on placing
+	if allOrNone {
+		if i, err := orderBook.TryBatch(valid); err != nil {
+			logger.Warn("⛔ All-or-none batch refused by the book", "event_key", eventKey, "batch_id", batchID, "order_id", valid[i].OrderID, "error", err)
+			for j, req := range valid {
+				reason := fmt.Sprintf("batch rejected: order %s refused: %v", valid[i].OrderID, err)
+				if j == i {
+					reason = err.Error()
+				}
+				e.publishReport(rejectedReport(eventID, batchID, req, reason))
+			}
+			return
+		}
+	}
+
+	placements := orderBook.AddBatch(valid)
+	for i, req := range valid {
+		e.publishReport(placedReport(eventID, batchID, req, placements[i]))
+	}
+	logger.Info("📦 Added order batch", "event_key", eventKey, "batch_id", batchID, "accepted", len(valid), "rejected", invalid)
+}
+
+func parseBatchEntry(entry wire.BatchOrderEntry, outcome int) (orderbook.OrderRequest, error) {
+	req := orderbook.OrderRequest{
+		Outcome:         outcome,
+		Side:            entry.Type,
+		Price:           entry.OrderPrice,
+		Quantity:        entry.OrderQuantity,
+		DisplayQuantity: entry.DisplayQuantity,
+		Tier:            entry.Tier,
+		PostOnly:        entry.PostOnly,
+		PostOnlyReprice: entry.PostOnlyReprice,
+		MinQty:          entry.MinQty,
+		AllOrNone:       entry.AllOrNone,
+	}
+
+	orderID, ok := new(big.Int).SetString(entry.OrderID, 10)
+	if !ok {
+		return req, fmt.Errorf("invalid orderId: %q", entry.OrderID)
+	}
+	req.OrderID = orderID
+
+	userID, ok := new(big.Int).SetString(entry.OrderUserID, 10)
+	if !ok {
+		return req, fmt.Errorf("invalid userId: %q", entry.OrderUserID)
+	}
+	req.UserID = userID
+
+	return req, ValidateOrder(req)
+}
+
+func rejectedReport(eventID *big.Int, batchID string, req orderbook.OrderRequest, reason string) *wire.ExecutionReport {
+	return &wire.ExecutionReport{
+		EventID:   eventID,
+		OrderID:   req.OrderID,
+		UserID:    req.UserID,
+		BatchID:   batchID,
+		Side:      req.Side,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		Status:    wire.StatusRejected,
+		Reason:    reason,
+		Timestamp: time.Now().Unix(),
+		Outcome:   req.Outcome,
+	}
+}
+
+func filledReport(eventID *big.Int, batchID string, req orderbook.OrderRequest, filled int) *wire.ExecutionReport {
+	status := wire.StatusNew
+	if filled >= req.Quantity {
+		status = wire.StatusFilled
+	} else if filled > 0 {
+		status = wire.StatusPartiallyFilled
+	}
+
+	return &wire.ExecutionReport{
+		EventID:           eventID,
+		OrderID:           req.OrderID,
+		UserID:            req.UserID,
+		BatchID:           batchID,
+		Side:              req.Side,
+		Price:             req.Price,
+		Quantity:          req.Quantity,
+		FilledQuantity:    filled,
+		RemainingQuantity: req.Quantity - filled,
+		Status:            status,
+		Timestamp:         time.Now().Unix(),
+		Outcome:           req.Outcome,
+	}
+}
+
+// placedReport reports what the book did with an order: refused, canceled
+// for lack of liquidity, or placed at its final price with its fill
+func placedReport(eventID *big.Int, batchID string, req orderbook.OrderRequest, placement orderbook.Placement) *wire.ExecutionReport {
+	if placement.Err != nil {
+		report := rejectedReport(eventID, batchID, req, placement.Err.Error())
+		if placement.Canceled {
+			report.Status = wire.StatusCanceled
+		}
+		return report
+	}
+	report := filledReport(eventID, batchID, req, placement.Filled)
+	if placement.Repriced {
+		report.Price = placement.Price
+		report.Repriced = true
+		report.RequestedPrice = req.Price
+	}
+	return report
+}
+
+// publishReport sends an execution report to the order's owner
+func (e *Exchange) publishReport(report *wire.ExecutionReport) {
+	e.Publish("execution_exchange", "execution.report", report)
+	for _, observer := range e.ReportObservers {
+		observer.OnReport(report)
+	}
+}
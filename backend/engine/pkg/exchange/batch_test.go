@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fills counts the executions a book reports to its trade observers
+type fills int
+
+func (f *fills) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) { *f++ }
+
+// batchExchange rests a sell of 3 at 600 from user 2 in event 7
+func batchExchange(t *testing.T) (*Exchange, *reports, *fills) {
+	t.Helper()
+	e := newTestExchange(t)
+	traded := new(fills)
+	e.Observers = append(e.Observers, traded)
+	if err := e.AddEvent(big.NewInt(7), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(7), orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(2), Price: 600, Quantity: 3})
+	got := &reports{}
+	e.ReportObservers = append(e.ReportObservers, got)
+	return e, got, traded
+}
+
+func TestAllOrNoneBatchRefusedByTheBook(t *testing.T) {
+	// The first leg takes the ask; the post-only one then finds the best ask
+	// at 600 gone and would only take if the first leg had not traded
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 600, OrderQuantity: 3},
+		{OrderID: "12", OrderUserID: "1", Type: "SELL", OrderPrice: 550, OrderQuantity: 1, PostOnly: true},
+		{OrderID: "13", OrderUserID: "1", Type: "BUY", OrderPrice: 560, OrderQuantity: 2, PostOnly: true},
+	}
+	e, got, traded := batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b1", batch, true)
+
+	if len(*got) != 3 {
+		t.Fatalf("%d reports for 3 orders", len(*got))
+	}
+	for _, r := range *got {
+		if r.Status != wire.StatusRejected {
+			t.Errorf("order %s %s, want rejected", r.OrderID, r.Status)
+		}
+	}
+	// Leg 13 crosses the post-only sell at 550 placed before it
+	if r := (*got)[2]; r.Reason != orderbook.ErrWouldTake.Error() {
+		t.Errorf("refused leg reason %q", r.Reason)
+	}
+	if r := (*got)[0]; !strings.Contains(r.Reason, "order 13 refused") {
+		t.Errorf("other leg reason %q", r.Reason)
+	}
+
+	// The dry run traded nothing for real
+	if *traded != 0 {
+		t.Errorf("%d fills observed from a refused batch", *traded)
+	}
+	ob := e.OrderBooks["7"]
+	if top := ob.GetTopSellOrder(); top == nil || top.Price != 600 || top.Quantity != 3 {
+		t.Errorf("best ask %v, want the untouched 3 at 600", top)
+	}
+	if usage := ob.Usage(big.NewInt(1)); usage != (orderbook.UserUsage{}) {
+		t.Errorf("usage %+v from a refused batch", usage)
+	}
+}
+
+func TestAllOrNoneBatchThatFitsGoesOnWhole(t *testing.T) {
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 600, OrderQuantity: 3},
+		{OrderID: "12", OrderUserID: "1", Type: "SELL", OrderPrice: 650, OrderQuantity: 1, PostOnly: true},
+		{OrderID: "13", OrderUserID: "1", Type: "SELL", OrderPrice: 590, OrderQuantity: 1, PostOnly: true, PostOnlyReprice: true},
+	}
+	e, got, traded := batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b1", batch, true)
+
+	want := map[int64]string{11: wire.StatusFilled, 12: wire.StatusNew, 13: wire.StatusNew}
+	for id, status := range want {
+		if s := got.statuses()[id]; s != status {
+			t.Errorf("order %d %s, want %s", id, s, status)
+		}
+	}
+	if *traded != 1 {
+		t.Errorf("%d fills, want the first leg's only", *traded)
+	}
+	// With the book empty below it the repriced leg keeps its price
+	if r := (*got)[len(*got)-1]; r.Repriced || r.Price != 590 {
+		t.Errorf("leg 13 %+v", r)
+	}
+}
+
+func TestBestEffortBatchPlacesAroundARefusal(t *testing.T) {
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 600, OrderQuantity: 1, PostOnly: true},
+		{OrderID: "12", OrderUserID: "1", Type: "BUY", OrderPrice: 590, OrderQuantity: 1, PostOnly: true},
+	}
+	e, got, _ := batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b1", batch, false)
+	if s := got.statuses(); s[11] != wire.StatusRejected || s[12] != wire.StatusNew {
+		t.Errorf("statuses %v", s)
+	}
+}
+
+func TestAllOrNoneBatchWithAShortMinimum(t *testing.T) {
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 400, OrderQuantity: 5},
+		{OrderID: "12", OrderUserID: "1", Type: "BUY", OrderPrice: 600, OrderQuantity: 5, MinQty: 4},
+	}
+	e, got, _ := batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b1", batch, true)
+	for _, r := range *got {
+		if r.Status != wire.StatusRejected {
+			t.Errorf("order %s %s, want the batch rejected", r.OrderID, r.Status)
+		}
+	}
+
+	// Alone, the leg is canceled rather than rejected as invalid
+	e, got, _ = batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b2", batch[1:], false)
+	if r := (*got)[0]; r.Status != wire.StatusCanceled || !strings.Contains(r.Reason, "3 available, 4 required") {
+		t.Errorf("report %+v", r)
+	}
+}
+
+func TestAllOrNoneBatchWithACrossingAllOrNoneLeg(t *testing.T) {
+	// 3 rest at 600: an all-or-none leg of 5 there would rest crossed
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 400, OrderQuantity: 5},
+		{OrderID: "12", OrderUserID: "1", Type: "BUY", OrderPrice: 600, OrderQuantity: 5, AllOrNone: true},
+	}
+	e, got, traded := batchExchange(t)
+	e.AddBatch(big.NewInt(7), 0, "b1", batch, true)
+	if s := got.statuses(); s[11] != wire.StatusRejected || s[12] != wire.StatusRejected {
+		t.Errorf("statuses %v, want the batch rejected", s)
+	}
+	if *traded != 0 || e.OrderBooks["7"].GetTopBuyOrder() != nil {
+		t.Error("a leg of the rejected batch reached the book")
+	}
+}
+
+func TestValidateConditionalOrders(t *testing.T) {
+	base := orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 100, Quantity: 5}
+	bad := []func(*orderbook.OrderRequest){
+		func(r *orderbook.OrderRequest) { r.MinQty = 6 },
+		func(r *orderbook.OrderRequest) { r.MinQty = -1 },
+		func(r *orderbook.OrderRequest) { r.AllOrNone, r.DisplayQuantity = true, 2 },
+	}
+	for i, change := range bad {
+		req := base
+		change(&req)
+		if err := ValidateOrder(req); err == nil {
+			t.Errorf("case %d: %+v accepted", i, req)
+		}
+	}
+	req := base
+	req.MinQty, req.AllOrNone = 5, true
+	if err := ValidateOrder(req); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,218 @@
+// dummyengine/pkg/exchange/cancel.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// CancelOrder cancels a resting order and sends its owner a report
+func (e *Exchange) CancelOrder(eventID, orderID, userID *big.Int) {
+	report := &wire.ExecutionReport{EventID: eventID, OrderID: orderID, UserID: userID, Timestamp: time.Now().Unix()}
+
+	orderBook, err := e.bookWith(eventID, orderID)
+	if err == nil {
+		err = e.checkUser(userID)
+	}
+	if err == nil {
+		report.Outcome = orderBook.Outcome
+		order, side, cancelErr := orderBook.CancelOrder(orderID, userID)
+		if err = cancelErr; err == nil {
+			report.Side = side
+			report.Price = order.Price
+			report.Quantity = order.Remaining()
+			report.Status = wire.StatusCanceled
+			logger.Info("🗑️ Canceled order", "order_id", orderID.String(), "event_key", eventID.String())
+		}
+	}
+	if err != nil {
+		logger.Warn("⚠️ Cancel rejected", "order_id", orderID.String(), "error", err)
+		report.Status = wire.StatusRejected
+		report.Reason = err.Error()
+	}
+	e.publishReport(report)
+}
+
+// MassCancelRequest selects the resting orders of a mass cancel. Each filter
+// left nil or empty matches anything, so an empty request clears every book
+// this partition owns.
+type MassCancelRequest struct {
+	EventID *big.Int
+	UserID  *big.Int
+	Side    string // "BUY" || "SELL" || ""
+}
+
+// MassCancel cancels every matching resting order in one pass over the books,
+// sends each owner a report and publishes a summary, which it also returns
+func (e *Exchange) MassCancel(req MassCancelRequest) *wire.MassCancelReport {
+	summary := &wire.MassCancelReport{EventID: req.EventID, UserID: req.UserID, Side: req.Side, Timestamp: time.Now().Unix()}
+
+	// Books in a fixed order, so a replica replays the same reports
+	var eventKeys []string
+	var err error
+	switch {
+	case req.Side != "" && req.Side != "BUY" && req.Side != "SELL":
+		err = fmt.Errorf("invalid side %q", req.Side)
+	case e.checkUser(req.UserID) != nil:
+		err = ErrMakerUser
+	case req.EventID != nil:
+		var books []*orderbook.OrderBook
+		if books, err = e.books(req.EventID); err == nil {
+			for _, ob := range books {
+				eventKeys = append(eventKeys, wire.MarketKey(ob.EventID, ob.Outcome))
+			}
+		}
+	default:
+		for eventKey := range e.OrderBooks {
+			eventKeys = append(eventKeys, eventKey)
+		}
+		sort.Strings(eventKeys)
+	}
+	if err != nil {
+		logger.Warn("⚠️ Mass cancel rejected", "error", err)
+		summary.Error = err.Error()
+		e.Publish("execution_exchange", "mass_cancel.report", summary)
+		return summary
+	}
+
+	for _, eventKey := range eventKeys {
+		orderBook := e.OrderBooks[eventKey]
+		canceled := orderBook.CancelAll(req.UserID, req.Side)
+		if len(canceled) == 0 {
+			continue
+		}
+		summary.Events++
+		for _, c := range canceled {
+			summary.Canceled++
+			summary.Quantity += c.Order.Remaining()
+		}
+		e.reportCanceled(orderBook, canceled, "mass cancel", summary.Timestamp)
+	}
+
+	logger.Info("🧹 Mass cancel", "event_id", req.EventID, "user_id", req.UserID, "side", req.Side, "canceled", summary.Canceled, "events", summary.Events)
+	e.Publish("execution_exchange", "mass_cancel.report", summary)
+	return summary
+}
+
+// reportCanceled sends the owner of each order canceled from a book a report
+func (e *Exchange) reportCanceled(ob *orderbook.OrderBook, canceled []orderbook.CanceledOrder, reason string, at int64) {
+	for _, c := range canceled {
+		e.publishReport(&wire.ExecutionReport{
+			EventID:   ob.EventID,
+			OrderID:   c.Order.ID,
+			UserID:    c.Order.UserID,
+			Side:      c.Side,
+			Price:     c.Order.Price,
+			Quantity:  c.Order.Remaining(),
+			Status:    wire.StatusCanceled,
+			Reason:    reason,
+			Timestamp: at,
+			Outcome:   ob.Outcome,
+		})
+	}
+}
+
+// ModifyOrder amends a resting order's price and/or quantity and sends its
+// owner a report. Growing it or moving its price is checked against limits.
+func (e *Exchange) ModifyOrder(eventID *big.Int, req orderbook.ModifyRequest) {
+	report := &wire.ExecutionReport{
+		EventID:   eventID,
+		OrderID:   req.OrderID,
+		UserID:    req.UserID,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		Timestamp: time.Now().Unix(),
+	}
+
+	orderBook, err := e.bookWith(eventID, req.OrderID)
+	if err == nil {
+		report.Outcome = orderBook.Outcome
+		err = e.checkUser(req.UserID)
+	}
+	if err == nil && (req.Price < 0 || req.Quantity <= 0) {
+		err = fmt.Errorf("invalid price %d or quantity %d", req.Price, req.Quantity)
+	}
+	if err == nil {
+		if order, _, ok := orderBook.FindOrder(req.OrderID); ok {
+			amended := orderbook.OrderRequest{Price: req.Price, Quantity: req.Quantity}
+			if amended.Price == 0 {
+				amended.Price = order.Price
+			}
+			err = orderBook.CheckOrder(amended, e.receivedAt())
+		}
+	}
+	if err == nil {
+		err = e.throttle(eventID, req.UserID, req.Tier, 1)
+	}
+	if err == nil {
+		var result orderbook.ModifyResult
+		result, err = orderBook.ModifyOrder(req, func(replacement orderbook.OrderRequest) error {
+			return e.checkLimits(orderBook, []orderbook.OrderRequest{replacement})
+		})
+		if err == nil {
+			report = filledReport(eventID, "", orderbook.OrderRequest{
+				Side:     result.Side,
+				OrderID:  req.OrderID,
+				Price:    result.Price,
+				Quantity: result.Quantity,
+				UserID:   req.UserID,
+				Outcome:  orderBook.Outcome,
+			}, result.Filled)
+			if !result.Replaced {
+				report.Status = wire.StatusModified
+			}
+			logger.Info("✏️ Modified order", "order_id", req.OrderID.String(), "price", result.Price, "quantity", result.Quantity, "replaced", result.Replaced)
+		}
+	}
+	if err != nil {
+		logger.Warn("⚠️ Modify rejected", "order_id", req.OrderID.String(), "error", err)
+		report.Status = wire.StatusRejected
+		report.Reason = err.Error()
+	}
+	e.publishReport(report)
+}
+
+// book returns the book of one of the event's outcomes if this partition owns it
+func (e *Exchange) book(eventID *big.Int, outcome int) (*orderbook.OrderBook, error) {
+	if err := e.checkOwner(eventID); err != nil {
+		return nil, err
+	}
+	orderBook, exists := e.OrderBooks[wire.MarketKey(eventID, outcome)]
+	if !exists {
+		return nil, fmt.Errorf("OrderBook not found")
+	}
+	return orderBook, nil
+}
+
+// books returns every book of the event in outcome order
+func (e *Exchange) books(eventID *big.Int) ([]*orderbook.OrderBook, error) {
+	first, err := e.book(eventID, 0)
+	if err != nil {
+		return nil, err
+	}
+	books := []*orderbook.OrderBook{first}
+	for outcome := 1; outcome < first.Params.Books(); outcome++ {
+		books = append(books, e.OrderBooks[wire.MarketKey(eventID, outcome)])
+	}
+	return books, nil
+}
+
+// bookWith returns the event's book holding the order, or its first book,
+// which then reports the order as not found
+func (e *Exchange) bookWith(eventID, orderID *big.Int) (*orderbook.OrderBook, error) {
+	books, err := e.books(eventID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ob := range books {
+		if _, _, ok := ob.FindOrder(orderID); ok {
+			return ob, nil
+		}
+	}
+	return books[0], nil
+}
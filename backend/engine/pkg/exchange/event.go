@@ -0,0 +1,85 @@
+// dummyengine/pkg/exchange/event.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+)
+
+// EventParams returns an event's current rules
+func (e *Exchange) EventParams(eventID *big.Int) (orderbook.Params, error) {
+	orderBook, err := e.book(eventID, 0)
+	if err != nil {
+		return orderbook.Params{}, err
+	}
+	return orderBook.Params, nil
+}
+
+// UpdateEvent replaces an event's rules in every outcome's book, or in none
+// if any book refuses them, see orderbook.OrderBook.Update
+func (e *Exchange) UpdateEvent(eventID *big.Int, params orderbook.Params) error {
+	books, err := e.books(eventID)
+	if err != nil {
+		return err
+	}
+	for _, orderBook := range books {
+		if err := orderBook.CheckUpdate(params); err != nil {
+			return fmt.Errorf("update event %s: %w", eventID, err)
+		}
+	}
+	for _, orderBook := range books {
+		if err := orderBook.Update(params); err != nil {
+			return fmt.Errorf("update event %s: %w", eventID, err)
+		}
+	}
+	e.record(books[0])
+	logger.Info("🛠️ Event updated", "event_key", eventID.String(), "params", books[0].Params)
+	return nil
+}
+
+// EventInfo answers an event query with its rules and phase
+func (e *Exchange) EventInfo(eventID *big.Int) (*wire.EventInfo, error) {
+	orderBook, err := e.book(eventID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := orderBook.Params
+	budget, loss, err := e.MakerLoss(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return &wire.EventInfo{
+		EventID:        eventID,
+		MatchingPolicy: orderBook.Policy.Name(),
+		MinAllocation:  p.MinAllocation,
+		Fees: &wire.FeeSchedule{
+			MakerBps: p.Fees.MakerBps,
+			TakerBps: p.Fees.TakerBps,
+			MinFee:   p.Fees.MinFee,
+		},
+		Limits: &wire.Limits{
+			MaxOpenOrders:   p.Limits.MaxOpenOrders,
+			MaxOpenNotional: p.Limits.MaxOpenNotional,
+			MaxPosition:     p.Limits.MaxPosition,
+		},
+		Payout:         p.Payout,
+		TickSize:       p.TickSize,
+		MinPrice:       p.MinPrice,
+		MaxPrice:       p.MaxPrice,
+		LotSize:        p.LotSize,
+		Outcomes:       p.Outcomes,
+		OpenTime:       p.OpenTime,
+		CloseTime:      p.CloseTime,
+		OpeningAuction: p.OpeningAuction,
+		AuctionEnd:     p.AuctionEnd,
+		Auction:        orderBook.Auction,
+		MakerLiquidity: p.MakerLiquidity,
+		MakerQuantity:  p.MakerQuantity,
+		MakerBudget:    budget,
+		MakerLoss:      loss,
+	}, nil
+}
@@ -2,65 +2,240 @@ package exchange
 
 import (
 	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/ratelimit"
+	"dummyengine/pkg/registry"
+	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
 	"github.com/streadway/amqp"
 	"dummyengine/pkg/logger"
+	"dummyengine/pkg/wire"
 )
 
 type Exchange struct {
-	OrderBooks     map[string]*orderbook.OrderBook
-	TradeQueue     string
-	PriceQueue     string
-	OrderBookQueue string
-	Ch             *amqp.Channel
+	OrderBooks      map[string]*orderbook.OrderBook // by wire.MarketKey
+	TradeQueue      string
+	PriceQueue      string
+	OrderBookQueue  string
+	Ch              atomic.Pointer[amqp.Channel] // shared by every book, see SetChannel
+	ContentType     string                       // wire format for published messages
+	GlobalLimits    orderbook.Limits
+	Observers       []orderbook.TradeObserver // attached to every new book
+	BookObservers   []orderbook.BookObserver
+	ReportObservers []ReportObserver
+
+	// With a partition map the exchange only serves the events of Partition.
+	// GlobalLimits then apply per instance.
+	Partitions *partition.Map
+	Partition  int
+
+	// Sequence is the last input applied from the journal
+	Sequence uint64
+
+	// RateLimiter throttles order entry per user and per user and event; nil
+	// disables it. Decisions are taken at ReceivedAt, the time the input being
+	// applied was first received, so a standby replaying the journal takes
+	// the same ones.
+	RateLimiter *ratelimit.Limiter
+	ReceivedAt  time.Time
+
+	// Registry keeps the owned events' rules and states on disk apart from
+	// their orders; nil keeps nothing
+	Registry *registry.Registry
+
+	// MakerID is the user events' LMSR market makers trade as; nil refuses
+	// events that ask for one
+	MakerID *big.Int
+
+	chMu   sync.RWMutex // guards silent for publishers outside the consumer goroutine
+	silent bool
+}
+
+// ReportObserver sees every execution report the exchange sends
+type ReportObserver interface {
+	OnReport(report *wire.ExecutionReport)
 }
 
 func NewExchange(ch *amqp.Channel, tradeQueue, priceQueue, orderBookQueue string) *Exchange {
-	return &Exchange{
+	e := &Exchange{
 		OrderBooks:     make(map[string]*orderbook.OrderBook),
 		TradeQueue:     tradeQueue,
 		PriceQueue:     priceQueue,
 		OrderBookQueue: orderBookQueue,
-		Ch:             ch,
+		ContentType:    wire.ContentTypeJSON,
+	}
+	e.Ch.Store(ch)
+	return e
+}
+
+// SetChannel points the exchange and all its order books at a new AMQP channel,
+// keeping the books themselves intact across reconnects. The books share the
+// exchange's pointer, so the swap is a single atomic store that publishers on
+// any goroutine see whole.
+func (e *Exchange) SetChannel(ch *amqp.Channel) {
+	e.Ch.Store(ch)
+}
+
+// SetSilent turns publishing off or back on for the exchange and all its
+// books. A silent exchange keeps its state exactly as a publishing one would.
+func (e *Exchange) SetSilent(silent bool) {
+	e.chMu.Lock()
+	e.silent = silent
+	e.chMu.Unlock()
+
+	for _, ob := range e.OrderBooks {
+		ob.Silent = silent
+	}
+}
+
+// Silent reports whether publishing is off
+func (e *Exchange) Silent() bool {
+	e.chMu.RLock()
+	defer e.chMu.RUnlock()
+	return e.silent
+}
+
+// Publish sends a message on the current channel; safe to call from any goroutine
+func (e *Exchange) Publish(exchange, routingKey string, message interface{}) {
+	e.chMu.RLock()
+	defer e.chMu.RUnlock()
+	if e.silent {
+		return
 	}
+	orderbook.Publish(e.Ch.Load(), e.ContentType, exchange, routingKey, message)
 }
 
-func (e *Exchange)AddEvent(eventID *big.Int) {
+// AddEvent opens the event's order books with the given rules: one for a
+// yes/no event, one per outcome otherwise. Invalid rules are returned as an
+// error; an existing event is left untouched.
+func (e *Exchange) AddEvent(eventID *big.Int, params orderbook.Params) error {
 	eventKey := eventID.String()
+	if err := e.checkOwner(eventID); err != nil {
+		return err
+	}
 	if _, exists := e.OrderBooks[eventKey]; exists{
 		logger.Warn("Event already exists", "event_key", eventKey)
-		return
+		return nil
 	}
 
-	// create orderbook
-	e.OrderBooks[eventKey] = orderbook.NewOrderBook(e.Ch, eventID, e.TradeQueue, e.PriceQueue, e.OrderBookQueue)
-	logger.Info("New OrderBook created", "event_key", eventKey)
+	// create orderbooks, the first one checking the rules for all
+	ob, err := e.newBook(eventID, 0, params)
+	if err != nil {
+		return err
+	}
+	e.OrderBooks[eventKey] = ob
+	for outcome := 1; outcome < ob.Params.Books(); outcome++ {
+		book, _ := e.newBook(eventID, outcome, params)
+		e.OrderBooks[wire.MarketKey(eventID, outcome)] = book
+	}
+	e.record(ob)
+	logger.Info("New OrderBook created", "event_key", eventKey, "policy", ob.Policy.Name(), "books", ob.Params.Books())
+	return nil
+}
+
+func (e *Exchange) newBook(eventID *big.Int, outcome int, params orderbook.Params) (*orderbook.OrderBook, error) {
+	ob, err := orderbook.NewOrderBook(&e.Ch, eventID, e.TradeQueue, e.PriceQueue, e.OrderBookQueue, params)
+	if err != nil {
+		return nil, err
+	}
+	ob.ContentType = e.ContentType
+	ob.Observers = e.Observers
+	ob.BookObservers = e.BookObservers
+	ob.Silent = e.Silent()
+	ob.Clock = e.receivedAt
+	ob.Outcome = outcome
+	if ob.Params.MakerLiquidity > 0 {
+		if e.MakerID == nil {
+			return nil, fmt.Errorf("no market maker user is configured")
+		}
+		ob.MakerID = e.MakerID
+	}
+	return ob, nil
 }
 
 func (e *Exchange)AddBuyOrder(eventID *big.Int, orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int){
-	eventKey := eventID.String()
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: orderID, Price: orderPrice, Quantity: orderQuantity, UserID: orderUserID})
+}
+
+func (e *Exchange)AddSellOrder(eventID *big.Int, orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int){
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: orderID, Price: orderPrice, Quantity: orderQuantity, UserID: orderUserID})
+}
+
+// AddOrder places a single order in the book of its outcome and sends its
+// owner an execution report
+func (e *Exchange) AddOrder(eventID *big.Int, req orderbook.OrderRequest) {
+	eventKey := wire.MarketKey(eventID, req.Outcome)
+	if err := e.checkOwner(eventID); err != nil {
+		logger.Warn("⛔ Order for an event owned elsewhere", "order_id", req.OrderID.String(), "error", err)
+		e.publishReport(rejectedReport(eventID, "", req, err.Error()))
+		return
+	}
+
 	orderBook, exists := e.OrderBooks[eventKey]
 	if !exists {
 		logger.Error("OrderBook not found", "event_key", eventKey)
+		e.publishReport(rejectedReport(eventID, "", req, "OrderBook not found"))
 		return
 	}
 
-	orderBook.AddBuyOrder(orderID, orderPrice, orderQuantity, orderUserID)
-	logger.Info("📦 Added buy order", "order_id", orderID.String(), "event_key", eventKey)
+	if err := e.checkUser(req.UserID); err != nil {
+		logger.Warn("⛔ Order in the market maker's name", "order_id", req.OrderID.String(), "error", err)
+		e.publishReport(rejectedReport(eventID, "", req, err.Error()))
+		return
+	}
 
-}
-func (e *Exchange)AddSellOrder(eventID *big.Int, orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int){
-	eventKey := eventID.String()
-	orderBook, exists := e.OrderBooks[eventKey]
-	if !exists {
-		logger.Warn("OrderBook not found", "event_key", eventKey)
+	if err := orderBook.CheckOrder(req, e.receivedAt()); err != nil {
+		logger.Warn("⚠️ Order breaks the event's rules", "order_id", req.OrderID.String(), "event_key", eventKey, "error", err)
+		e.publishReport(rejectedReport(eventID, "", req, err.Error()))
 		return
 	}
 
-	orderBook.AddSellOrder(orderID, orderPrice, orderQuantity, orderUserID)
-	logger.Info("📦 Added sell order", "order_id", orderID.String(), "event_key", eventKey)
+	if err := e.throttle(eventID, req.UserID, req.Tier, 1); err != nil {
+		logger.Warn("🚦 Order throttled", "order_id", req.OrderID.String(), "user_id", req.UserID.String(), "error", err)
+		e.publishReport(rejectedReport(eventID, "", req, err.Error()))
+		return
+	}
+
+	if err := e.checkLimits(orderBook, []orderbook.OrderRequest{req}); err != nil {
+		logger.Warn("⛔ Order rejected by limits", "order_id", req.OrderID.String(), "user_id", req.UserID.String(), "error", err)
+		e.publishReport(rejectedReport(eventID, "", req, err.Error()))
+		return
+	}
+
+	placement := orderBook.AddOrder(req)
+	e.publishReport(placedReport(eventID, "", req, placement))
+	if placement.Err != nil {
+		logger.Warn("⚠️ Order refused by the book", "order_id", req.OrderID.String(), "event_key", eventKey, "error", placement.Err)
+		return
+	}
+	logger.Info("📦 Added order", "side", req.Side, "order_id", req.OrderID.String(), "event_key", eventKey, "filled", placement.Filled, "price", placement.Price)
 }
 
-func (e *Exchange)Settlement(eventID *big.Int){
-	
+// ValidateOrder checks an order request before it reaches a book
+func ValidateOrder(req orderbook.OrderRequest) error {
+	if req.OrderID == nil || req.UserID == nil {
+		return fmt.Errorf("missing orderId or userId")
+	}
+	if req.Side != "BUY" && req.Side != "SELL" {
+		return fmt.Errorf("unknown order type: %q", req.Side)
+	}
+	if req.Price <= 0 || req.Quantity <= 0 {
+		return fmt.Errorf("invalid price %d or quantity %d", req.Price, req.Quantity)
+	}
+	if req.DisplayQuantity < 0 {
+		return fmt.Errorf("invalid display quantity %d", req.DisplayQuantity)
+	}
+	if req.MinQty < 0 || req.MinQty > req.Quantity {
+		return fmt.Errorf("invalid minimum quantity %d", req.MinQty)
+	}
+	if req.AllOrNone && req.DisplayQuantity > 0 {
+		return fmt.Errorf("all-or-none orders cannot be icebergs")
+	}
+	if req.Outcome < 0 {
+		return fmt.Errorf("invalid outcome %d", req.Outcome)
+	}
+	return nil
 }
@@ -0,0 +1,92 @@
+// dummyengine/pkg/exchange/limits.go
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+)
+
+// checkLimits checks orders for one book against the event's own limits and
+// the engine-wide ones, as if every order were accepted. GlobalLimits caps
+// open orders and notional summed over all events, and position per event.
+func (e *Exchange) checkLimits(ob *orderbook.OrderBook, requests []orderbook.OrderRequest) error {
+	if err := ob.CheckBatchLimits(requests, ob.Params.Limits); err != nil {
+		return fmt.Errorf("event limit: %w", err)
+	}
+	if err := ob.CheckBatchLimits(requests, orderbook.Limits{MaxPosition: e.GlobalLimits.MaxPosition}); err != nil {
+		return fmt.Errorf("global limit: %w", err)
+	}
+	if e.GlobalLimits.MaxOpenOrders == 0 && e.GlobalLimits.MaxOpenNotional == 0 {
+		return nil
+	}
+
+	totals := map[string]orderbook.UserUsage{}
+	for _, req := range requests {
+		key := req.UserID.String()
+		u, ok := totals[key]
+		if !ok {
+			u = e.TotalUsage(req.UserID)
+		}
+		u.OpenOrders++
+		u.OpenNotional += int64(req.Price) * int64(req.Quantity)
+		totals[key] = u
+
+		if e.GlobalLimits.MaxOpenOrders > 0 && u.OpenOrders > e.GlobalLimits.MaxOpenOrders {
+			return fmt.Errorf("global limit: order %s: open order limit %d reached", req.OrderID, e.GlobalLimits.MaxOpenOrders)
+		}
+		if e.GlobalLimits.MaxOpenNotional > 0 && u.OpenNotional > e.GlobalLimits.MaxOpenNotional {
+			return fmt.Errorf("global limit: order %s: open notional %d would exceed limit %d", req.OrderID, u.OpenNotional, e.GlobalLimits.MaxOpenNotional)
+		}
+	}
+	return nil
+}
+
+// TotalUsage sums a user's open orders and notional over every event. The
+// net position is per event and is left at zero.
+func (e *Exchange) TotalUsage(userID *big.Int) orderbook.UserUsage {
+	var total orderbook.UserUsage
+	for _, ob := range e.OrderBooks {
+		u := ob.Usage(userID)
+		total.OpenOrders += u.OpenOrders
+		total.OpenBuyQuantity += u.OpenBuyQuantity
+		total.OpenSellQuantity += u.OpenSellQuantity
+		total.OpenNotional += u.OpenNotional
+	}
+	return total
+}
+
+// UsageReport answers a usage query for one user in one outcome's book
+func (e *Exchange) UsageReport(eventID *big.Int, outcome int, userID *big.Int) (*wire.UsageReport, error) {
+	ob, exists := e.OrderBooks[wire.MarketKey(eventID, outcome)]
+	if !exists {
+		return nil, fmt.Errorf("OrderBook not found")
+	}
+
+	u := ob.Usage(userID)
+	total := e.TotalUsage(userID)
+	limits := ob.Params.Limits
+	return &wire.UsageReport{
+		EventID:           eventID,
+		Outcome:           outcome,
+		UserID:            userID,
+		OpenOrders:        u.OpenOrders,
+		OpenBuyQuantity:   u.OpenBuyQuantity,
+		OpenSellQuantity:  u.OpenSellQuantity,
+		OpenNotional:      u.OpenNotional,
+		NetPosition:       u.NetPosition,
+		TotalOpenOrders:   total.OpenOrders,
+		TotalOpenNotional: total.OpenNotional,
+		Limits: &wire.Limits{
+			MaxOpenOrders:   limits.MaxOpenOrders,
+			MaxOpenNotional: limits.MaxOpenNotional,
+			MaxPosition:     limits.MaxPosition,
+		},
+		GlobalLimits: &wire.Limits{
+			MaxOpenOrders:   e.GlobalLimits.MaxOpenOrders,
+			MaxOpenNotional: e.GlobalLimits.MaxOpenNotional,
+			MaxPosition:     e.GlobalLimits.MaxPosition,
+		},
+	}, nil
+}
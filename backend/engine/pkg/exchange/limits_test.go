@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"testing"
+)
+
+func TestOrderLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    orderbook.Limits
+		global   orderbook.Limits
+		wantOpen int // of the three orders user 1 sends to event 1
+	}{
+		{"no limits", orderbook.Limits{}, orderbook.Limits{}, 3},
+		{"event open orders", orderbook.Limits{MaxOpenOrders: 2}, orderbook.Limits{}, 2},
+		// user 1 already rests one order of 500 in event 2
+		{"global open orders over events", orderbook.Limits{}, orderbook.Limits{MaxOpenOrders: 2}, 1},
+		{"event notional", orderbook.Limits{MaxOpenNotional: 450}, orderbook.Limits{}, 2},
+		{"global notional over events", orderbook.Limits{}, orderbook.Limits{MaxOpenNotional: 950}, 2},
+		{"event position", orderbook.Limits{MaxPosition: 5}, orderbook.Limits{}, 2},
+		// the global position cap applies per event, so event 2 does not count
+		{"global position per event", orderbook.Limits{}, orderbook.Limits{MaxPosition: 9}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := limitsExchange(t, tt.event, tt.global)
+			for i := int64(0); i < 3; i++ {
+				e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(10 + i), UserID: big.NewInt(1), Price: 100 + int(i), Quantity: 1 + 2*int(i)})
+			}
+			if open := e.OrderBooks["1"].Usage(big.NewInt(1)).OpenOrders; open != tt.wantOpen {
+				t.Errorf("%d orders resting, want %d", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	// The same three orders as TestOrderLimits, in one batch
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "10", OrderUserID: "1", Type: "BUY", OrderPrice: 100, OrderQuantity: 1},
+		{OrderID: "11", OrderUserID: "1", Type: "BUY", OrderPrice: 101, OrderQuantity: 3},
+		{OrderID: "12", OrderUserID: "1", Type: "BUY", OrderPrice: 102, OrderQuantity: 5},
+	}
+	tests := []struct {
+		name      string
+		event     orderbook.Limits
+		global    orderbook.Limits
+		legs      []int // of batch, in the order sent
+		allOrNone bool
+		wantOpen  int
+	}{
+		{"within limits", orderbook.Limits{MaxOpenOrders: 3}, orderbook.Limits{}, []int{0, 1, 2}, true, 3},
+		{"event open orders, best effort", orderbook.Limits{MaxOpenOrders: 2}, orderbook.Limits{}, []int{0, 1, 2}, false, 2},
+		{"event open orders, all or none", orderbook.Limits{MaxOpenOrders: 2}, orderbook.Limits{}, []int{0, 1, 2}, true, 0},
+		{"global notional, best effort", orderbook.Limits{}, orderbook.Limits{MaxOpenNotional: 950}, []int{0, 1, 2}, false, 2},
+		{"global notional, all or none", orderbook.Limits{}, orderbook.Limits{MaxOpenNotional: 950}, []int{0, 1, 2}, true, 0},
+		// a leg past the cap is skipped and a later, smaller one still fits
+		{"event position skips a leg", orderbook.Limits{MaxPosition: 5}, orderbook.Limits{}, []int{0, 2, 1}, false, 2},
+		{"event position, all or none", orderbook.Limits{MaxPosition: 5}, orderbook.Limits{}, []int{0, 2, 1}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := limitsExchange(t, tt.event, tt.global)
+			var entries []wire.BatchOrderEntry
+			for _, i := range tt.legs {
+				entries = append(entries, batch[i])
+			}
+			e.AddBatch(big.NewInt(1), 0, "b1", entries, tt.allOrNone)
+			if open := e.OrderBooks["1"].Usage(big.NewInt(1)).OpenOrders; open != tt.wantOpen {
+				t.Errorf("%d orders resting, want %d", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+// limitsExchange opens events 1 and 2 and rests a buy of 5 at 100 for user 1
+// in event 2
+func limitsExchange(t *testing.T, event, global orderbook.Limits) *Exchange {
+	t.Helper()
+	e := NewExchange(nil, "trade", "price", "book")
+	e.GlobalLimits = global
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{Limits: event}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddEvent(big.NewInt(2), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(2), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 100, Quantity: 5})
+	return e
+}
@@ -0,0 +1,142 @@
+// dummyengine/pkg/exchange/maker.go
+package exchange
+
+import (
+	"dummyengine/pkg/lmsr"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// ErrMakerUser refuses requests made in the market maker's name
+var ErrMakerUser = errors.New("user ID is reserved for the market maker")
+
+// checkUser refuses the market maker's user ID on requests from outside
+func (e *Exchange) checkUser(userID *big.Int) error {
+	if e.MakerID != nil && userID != nil && userID.Cmp(e.MakerID) == 0 {
+		return ErrMakerUser
+	}
+	return nil
+}
+
+// Requote refreshes the quotes of every event that runs a market maker. It
+// runs around each input, judged at the input's receive time: before, so
+// quotes follow the trading hours, and after, so they follow the fills the
+// input gave the maker. A replica requotes at the same points as the leader.
+func (e *Exchange) Requote() {
+	var eventKeys []string
+	for eventKey, orderBook := range e.OrderBooks {
+		if orderBook.MakerID != nil && orderBook.Outcome == 0 {
+			eventKeys = append(eventKeys, eventKey)
+		}
+	}
+	sort.Strings(eventKeys)
+	for _, eventKey := range eventKeys {
+		e.quote(e.OrderBooks[eventKey].EventID)
+	}
+}
+
+// quote prices every book of an event from the maker's positions by the
+// LMSR and rests the quotes. Each quote is the average price of its whole
+// size, rounded to a tick in the maker's favour, and is left out if filling
+// it could take the maker's worst-case loss past its budget.
+func (e *Exchange) quote(eventID *big.Int) {
+	books, err := e.books(eventID)
+	if err != nil || books[0].MakerID == nil {
+		return
+	}
+	p := books[0].Params
+	at := e.receivedAt()
+
+	b := float64(p.MakerLiquidity)
+	size := p.MakerQuantity
+	cash, positions := makerAccount(books)
+	q := make([]float64, len(positions))
+	for i, position := range positions {
+		q[i] = -float64(position)
+	}
+	budget := makerBudget(p)
+	fits := func(outcome, shares, price int) bool {
+		moved := append([]int(nil), positions...)
+		moved[outcome] += shares
+		return worstLoss(p.Payout, cash-int64(shares)*int64(price), moved) <= budget
+	}
+
+	for _, ob := range books {
+		if !ob.Trading(at) {
+			continue
+		}
+		i := ob.Outcome
+		perShare := float64(p.Payout) / float64(size)
+
+		var bid, ask orderbook.MakerQuote
+		askPrice := max(ceilTick(lmsr.Buy(b, q, i, float64(size))*perShare, p.TickSize), p.MinPrice)
+		if askPrice < p.Payout && (p.MaxPrice == 0 || askPrice <= p.MaxPrice) && fits(i, -size, askPrice) {
+			ask = orderbook.MakerQuote{Price: askPrice, Quantity: size}
+		}
+		bidPrice := floorTick(lmsr.Sell(b, q, i, float64(size))*perShare, p.TickSize)
+		if p.MaxPrice > 0 {
+			bidPrice = min(bidPrice, p.MaxPrice)
+		}
+		if bidPrice > 0 && bidPrice >= p.MinPrice && fits(i, size, bidPrice) {
+			bid = orderbook.MakerQuote{Price: bidPrice, Quantity: size}
+		}
+
+		if ob.Requote(bid, ask) {
+			logger.Debug("🤖 Maker requoted", "event_key", eventID.String(), "outcome", i, "bid", bid.Price, "ask", ask.Price, "size", size)
+		}
+	}
+}
+
+// MakerLoss returns an event maker's loss budget and its worst-case loss
+// as things stand, both in price units
+func (e *Exchange) MakerLoss(eventID *big.Int) (budget, loss int64, err error) {
+	books, err := e.books(eventID)
+	if err != nil {
+		return 0, 0, err
+	}
+	cash, positions := makerAccount(books)
+	return makerBudget(books[0].Params), worstLoss(books[0].Params.Payout, cash, positions), nil
+}
+
+// makerAccount returns the maker's cash over an event's books and its
+// position in every outcome. In a yes/no event's single book, a long
+// position is in outcome 0; outcome 1 is its complement.
+func makerAccount(books []*orderbook.OrderBook) (int64, []int) {
+	var cash int64
+	positions := make([]int, books[0].Params.Outcomes)
+	for _, ob := range books {
+		cash += ob.MakerCash
+		if ob.MakerID != nil {
+			positions[ob.Outcome] = ob.Usage(ob.MakerID).NetPosition
+		}
+	}
+	return cash, positions
+}
+
+// makerBudget is the most an LMSR maker with the event's liquidity can lose,
+// rounded up to a whole price unit
+func makerBudget(p orderbook.Params) int64 {
+	return int64(math.Ceil(lmsr.MaxLoss(float64(p.MakerLiquidity), p.Outcomes) * float64(p.Payout)))
+}
+
+// worstLoss is what the maker loses if the outcome worst for it wins: its
+// cash plus what its position in the winner pays out, before fees
+func worstLoss(payout int, cash int64, positions []int) int64 {
+	var loss int64
+	for _, position := range positions {
+		loss = max(loss, -(cash + int64(position)*int64(payout)))
+	}
+	return loss
+}
+
+func ceilTick(price float64, tick int) int {
+	return int(math.Ceil(price/float64(tick))) * tick
+}
+
+func floorTick(price float64, tick int) int {
+	return int(math.Floor(price/float64(tick))) * tick
+}
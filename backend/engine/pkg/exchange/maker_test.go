@@ -0,0 +1,113 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"testing"
+)
+
+var makerID = big.NewInt(99)
+
+// makerExchange creates event 7 with an LMSR maker of the given liquidity
+func makerExchange(t *testing.T, params orderbook.Params) *Exchange {
+	t.Helper()
+	e := newTestExchange(t)
+	e.MakerID = makerID
+	if err := e.AddEvent(big.NewInt(7), params); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestMakerQuotesAroundItsPrice(t *testing.T) {
+	e := makerExchange(t, orderbook.Params{MakerLiquidity: 100, Outcomes: 3})
+	e.Requote()
+
+	// Nothing traded yet: every outcome is priced near a third of the payout
+	for outcome := 0; outcome < 3; outcome++ {
+		ob := e.OrderBooks[wire.MarketKey(big.NewInt(7), outcome)]
+		bid, ask := ob.GetTopBuyOrder(), ob.GetTopSellOrder()
+		if bid == nil || ask == nil {
+			t.Fatalf("outcome %d not quoted on both sides", outcome)
+		}
+		if bid.Price > 333 || ask.Price < 334 || ask.Price-bid.Price > 10 || bid.Quantity != 1 {
+			t.Errorf("outcome %d quoted %d x %d / %d", outcome, bid.Quantity, bid.Price, ask.Price)
+		}
+	}
+}
+
+func TestMakerQuotesFollowItsFills(t *testing.T) {
+	e := makerExchange(t, orderbook.Params{MakerLiquidity: 10})
+	e.Requote()
+	ob := e.OrderBooks["7"]
+	before := ob.GetTopSellOrder().Price
+
+	e.AddOrder(big.NewInt(7), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: before, Quantity: 1})
+	e.Requote()
+	if after := ob.GetTopSellOrder().Price; after <= before {
+		t.Errorf("ask %d after the maker sold at %d, want it higher", after, before)
+	}
+	if ob.MakerCash != int64(before) || ob.Usage(makerID).NetPosition != -1 {
+		t.Errorf("maker cash %d position %d", ob.MakerCash, ob.Usage(makerID).NetPosition)
+	}
+}
+
+func TestMakerStaysWithinItsBudget(t *testing.T) {
+	e := makerExchange(t, orderbook.Params{MakerLiquidity: 2})
+	ob := e.OrderBooks["7"]
+	for i := int64(1); i <= 50; i++ {
+		e.Requote()
+		ask := ob.GetTopSellOrder()
+		if ask == nil {
+			break
+		}
+		e.AddOrder(big.NewInt(7), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(i), UserID: big.NewInt(1), Price: ask.Price, Quantity: ask.Quantity})
+		budget, loss, err := e.MakerLoss(big.NewInt(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loss > budget {
+			t.Fatalf("after %d buys the maker could lose %d of a budget of %d", i, loss, budget)
+		}
+	}
+	if ask := ob.GetTopSellOrder(); ask != nil {
+		t.Errorf("still offering at %d after 50 buys", ask.Price)
+	}
+	if ob.GetTopBuyOrder() == nil {
+		t.Error("the maker stopped bidding too")
+	}
+}
+
+func TestMakerQuotesOnlyWhileTrading(t *testing.T) {
+	e := makerExchange(t, orderbook.Params{MakerLiquidity: 10, OpeningAuction: true})
+	e.Requote()
+	if ob := e.OrderBooks["7"]; ob.GetTopBuyOrder() != nil || ob.GetTopSellOrder() != nil {
+		t.Error("maker quoted during the opening auction")
+	}
+	if err := e.Uncross(big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	e.Requote()
+	if e.OrderBooks["7"].GetTopBuyOrder() == nil {
+		t.Error("maker not quoting after the uncross")
+	}
+}
+
+func TestMakerUserIsReserved(t *testing.T) {
+	e := makerExchange(t, orderbook.Params{MakerLiquidity: 10})
+	got := &reports{}
+	e.ReportObservers = append(e.ReportObservers, got)
+	e.AddOrder(big.NewInt(7), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: makerID, Price: 400, Quantity: 1})
+	if status := got.statuses()[1]; status != wire.StatusRejected {
+		t.Errorf("order in the maker's name %s", status)
+	}
+	if summary := e.MassCancel(MassCancelRequest{UserID: makerID}); summary.Error == "" {
+		t.Error("mass cancel of the maker's quotes accepted")
+	}
+
+	// Without a maker user no event may ask for a maker
+	if err := newTestExchange(t).AddEvent(big.NewInt(8), orderbook.Params{MakerLiquidity: 10}); err == nil {
+		t.Error("event with a maker created without a maker user")
+	}
+}
@@ -0,0 +1,152 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// massCancelExchange has user 1 and user 2 quoting in events 1 and 2:
+// user 1's iceberg buy of 6 showing 2 in event 1 is partly filled by 1
+func massCancelExchange(t *testing.T) (*Exchange, *reports) {
+	t.Helper()
+	e := newTestExchange(t)
+	for _, id := range []int64{1, 2} {
+		if err := e.AddEvent(big.NewInt(id), orderbook.Params{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	place := func(event, id, user int64, side string, price, quantity, display int) {
+		e.AddOrder(big.NewInt(event), orderbook.OrderRequest{Side: side, OrderID: big.NewInt(id), UserID: big.NewInt(user), Price: price, Quantity: quantity, DisplayQuantity: display})
+	}
+	place(1, 10, 1, "BUY", 400, 6, 2)
+	place(1, 11, 2, "BUY", 400, 3, 0)
+	place(1, 12, 1, "BUY", 410, 1, 0)
+	place(1, 13, 1, "SELL", 500, 2, 0)
+	place(1, 14, 2, "SELL", 410, 1, 0) // takes order 12
+	place(1, 15, 2, "SELL", 400, 1, 0) // takes 1 of the iceberg's slice
+	place(2, 20, 1, "SELL", 600, 4, 0)
+	place(2, 21, 2, "SELL", 600, 4, 0)
+
+	got := &reports{}
+	e.ReportObservers = append(e.ReportObservers, got)
+	return e, got
+}
+
+func TestMassCancelByUserAcrossEvents(t *testing.T) {
+	e, got := massCancelExchange(t)
+	summary := e.MassCancel(MassCancelRequest{UserID: big.NewInt(1)})
+
+	// One report per order, events in order, buys before sells
+	type canceled struct {
+		event, order int64
+		side         string
+		price, qty   int
+	}
+	var reported []canceled
+	for _, r := range *got {
+		if r.Status != wire.StatusCanceled || r.Reason != "mass cancel" || r.UserID.Int64() != 1 {
+			t.Errorf("report %+v", r)
+		}
+		reported = append(reported, canceled{r.EventID.Int64(), r.OrderID.Int64(), r.Side, r.Price, r.Quantity})
+	}
+	want := []canceled{
+		{1, 10, "BUY", 400, 5}, // the iceberg's whole remainder, hidden included
+		{1, 13, "SELL", 500, 2},
+		{2, 20, "SELL", 600, 4},
+	}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported %v, want %v", reported, want)
+	}
+	if summary.Canceled != 3 || summary.Quantity != 11 || summary.Events != 2 || summary.Error != "" {
+		t.Errorf("summary %+v", summary)
+	}
+
+	// User 2 keeps its orders, at the front of the queue, and user 1 its fills
+	for _, key := range []string{"1", "2"} {
+		ob := e.OrderBooks[key]
+		if u := ob.Usage(big.NewInt(1)); u.OpenOrders != 0 || u.OpenNotional != 0 {
+			t.Errorf("event %s: user 1 usage %+v", key, u)
+		}
+	}
+	if top := e.OrderBooks["1"].GetTopBuyOrder(); top.Price != 400 || top.Quantity != 3 || top.Orders[0].ID.Int64() != 11 {
+		t.Errorf("event 1 best bid %d x %d", top.Quantity, top.Price)
+	}
+	if top := e.OrderBooks["1"].GetTopSellOrder(); top != nil {
+		t.Errorf("event 1 asks left at %d", top.Price)
+	}
+	if top := e.OrderBooks["2"].GetTopSellOrder(); top.Quantity != 4 || top.Orders[0].ID.Int64() != 21 {
+		t.Errorf("event 2 best ask %d x %d", top.Quantity, top.Price)
+	}
+}
+
+func TestMassCancelBySide(t *testing.T) {
+	e, got := massCancelExchange(t)
+	summary := e.MassCancel(MassCancelRequest{EventID: big.NewInt(1), Side: "SELL"})
+	if summary.Canceled != 1 || summary.Events != 1 || (*got)[0].OrderID.Int64() != 13 {
+		t.Errorf("summary %+v, reports %d", summary, len(*got))
+	}
+	if e.OrderBooks["1"].GetTopBuyOrder() == nil || e.OrderBooks["2"].GetTopSellOrder() == nil {
+		t.Error("orders outside the filter canceled")
+	}
+
+	// Nothing left to match is not an error
+	if summary := e.MassCancel(MassCancelRequest{EventID: big.NewInt(1), Side: "SELL"}); summary.Canceled != 0 || summary.Events != 0 || summary.Error != "" {
+		t.Errorf("second pass %+v", summary)
+	}
+}
+
+func TestMassCancelAcrossOutcomeBooks(t *testing.T) {
+	e, got := massCancelExchange(t)
+	eventID := big.NewInt(3)
+	if err := e.AddEvent(eventID, orderbook.Params{Outcomes: 3}); err != nil {
+		t.Fatal(err)
+	}
+	place := func(outcome int, id, user int64) {
+		e.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(id), UserID: big.NewInt(user), Price: 300, Quantity: 2, Outcome: outcome})
+	}
+	place(0, 30, 1)
+	place(1, 31, 2)
+	place(2, 32, 1)
+	*got = nil
+
+	summary := e.MassCancel(MassCancelRequest{EventID: eventID, UserID: big.NewInt(1)})
+	if summary.Canceled != 2 || summary.Quantity != 4 || summary.Events != 2 {
+		t.Errorf("summary %+v, want 2 orders from 2 books", summary)
+	}
+	// Each report names the book the order rested in
+	var reported [][2]int64
+	for _, r := range *got {
+		if r.Status != wire.StatusCanceled || r.EventID.Int64() != 3 {
+			t.Errorf("report %+v", r)
+		}
+		reported = append(reported, [2]int64{r.OrderID.Int64(), int64(r.Outcome)})
+	}
+	if want := [][2]int64{{30, 0}, {32, 2}}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported order:outcome %v, want %v", reported, want)
+	}
+	if e.OrderBooks[wire.MarketKey(eventID, 1)].GetTopBuyOrder() == nil {
+		t.Error("user 2's order in outcome 1 canceled")
+	}
+	if open := e.OrderBooks["1"].Usage(big.NewInt(1)).OpenOrders; open != 2 {
+		t.Errorf("user 1 has %d orders left in event 1, want 2", open)
+	}
+}
+
+func TestMassCancelRefused(t *testing.T) {
+	for name, req := range map[string]MassCancelRequest{
+		"invalid side":  {Side: "HOLD"},
+		"unknown event": {EventID: big.NewInt(9)},
+	} {
+		e, got := massCancelExchange(t)
+		summary := e.MassCancel(req)
+		if summary.Error == "" || summary.Canceled != 0 || len(*got) != 0 {
+			t.Errorf("%s: summary %+v, %d reports", name, summary, len(*got))
+		}
+		if e.OrderBooks["1"].GetTopBuyOrder() == nil {
+			t.Errorf("%s: orders canceled", name)
+		}
+	}
+}
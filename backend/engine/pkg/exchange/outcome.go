@@ -0,0 +1,138 @@
+// dummyengine/pkg/exchange/outcome.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/registry"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Complete set actions
+const (
+	ActionMint  = "MINT"
+	ActionMerge = "MERGE"
+)
+
+// CompleteSetRequest mints or merges complete sets of a categorical event
+type CompleteSetRequest struct {
+	EventID  *big.Int
+	UserID   *big.Int
+	Action   string // ActionMint || ActionMerge
+	Quantity int    // sets
+	Tier     string
+}
+
+// CompleteSet turns the payout into one share of every outcome, or back.
+// Minting adds Quantity to the user's position in each outcome's book, within
+// position limits; merging takes it away and needs that many shares in every
+// book not already offered for sale. The report, also returned, tells the
+// settlement side what to charge or pay.
+func (e *Exchange) CompleteSet(req CompleteSetRequest) *wire.CompleteSetReport {
+	report := &wire.CompleteSetReport{
+		EventID:   req.EventID,
+		UserID:    req.UserID,
+		Action:    req.Action,
+		Quantity:  req.Quantity,
+		Timestamp: time.Now().Unix(),
+	}
+
+	books, err := e.books(req.EventID)
+	if err == nil {
+		err = e.checkUser(req.UserID)
+	}
+	if err == nil {
+		err = e.checkCompleteSet(books, req)
+	}
+	if err == nil {
+		err = e.throttle(req.EventID, req.UserID, req.Tier, 1)
+	}
+	if err != nil {
+		logger.Warn("⚠️ Complete set rejected", "event_key", req.EventID.String(), "user_id", req.UserID.String(), "action", req.Action, "error", err)
+		report.Error = err.Error()
+		e.Publish("execution_exchange", "complete_set.report", report)
+		return report
+	}
+
+	for _, ob := range books {
+		if req.Action == ActionMint {
+			ob.Mint(req.UserID, req.Quantity)
+		} else {
+			ob.Merge(req.UserID, req.Quantity)
+		}
+	}
+	report.Amount = int64(req.Quantity) * int64(books[0].Params.Payout)
+
+	logger.Info("🧩 Complete sets", "event_key", req.EventID.String(), "user_id", req.UserID.String(), "action", req.Action, "quantity", req.Quantity, "amount", report.Amount)
+	e.Publish("execution_exchange", "complete_set.report", report)
+	return report
+}
+
+// checkCompleteSet checks a mint or merge against every book of the event
+// and, for a mint, against the event's and the engine-wide position limits
+func (e *Exchange) checkCompleteSet(books []*orderbook.OrderBook, req CompleteSetRequest) error {
+	params := books[0].Params
+	switch {
+	case len(books) < 2:
+		return fmt.Errorf("complete sets need an event with more than two outcomes")
+	case req.Action != ActionMint && req.Action != ActionMerge:
+		return fmt.Errorf("invalid action %q", req.Action)
+	case req.Quantity <= 0 || req.Quantity%params.LotSize != 0:
+		return fmt.Errorf("invalid quantity %d for lot size %d", req.Quantity, params.LotSize)
+	case books[0].Settled:
+		return orderbook.ErrSettled
+	}
+
+	for _, ob := range books {
+		if req.Action == ActionMerge {
+			if err := ob.CheckMerge(req.UserID, req.Quantity); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ob.CheckMint(req.UserID, req.Quantity, ob.Params.Limits); err != nil {
+			return fmt.Errorf("event limit: outcome %d: %w", ob.Outcome, err)
+		}
+		if err := ob.CheckMint(req.UserID, req.Quantity, e.GlobalLimits); err != nil {
+			return fmt.Errorf("global limit: outcome %d: %w", ob.Outcome, err)
+		}
+	}
+	return nil
+}
+
+// Settlement settles an event on its winning outcome: every resting order is
+// canceled with a report to its owner, the books stop taking orders, and the
+// positions they hold are paid out in a SettlementReport
+func (e *Exchange) Settlement(eventID *big.Int, winner int) error {
+	books, err := e.books(eventID)
+	if err != nil {
+		return err
+	}
+	if winner < 0 || winner >= books[0].Params.Outcomes {
+		return fmt.Errorf("invalid winning outcome %d of %d", winner, books[0].Params.Outcomes)
+	}
+	if books[0].Settled {
+		return fmt.Errorf("event %s is already settled", eventID)
+	}
+
+	report := &wire.SettlementReport{
+		EventID:        eventID,
+		WinningOutcome: winner,
+		Payout:         books[0].Params.Payout,
+		Timestamp:      time.Now().Unix(),
+	}
+	for _, ob := range books {
+		canceled := ob.CancelAll(nil, "")
+		report.Canceled += len(canceled)
+		e.reportCanceled(ob, canceled, "event settled", report.Timestamp)
+		report.Payouts = append(report.Payouts, ob.Settle(winner)...)
+	}
+	e.recordState(eventID, registry.StateSettled)
+
+	logger.Info("💰 Event settled", "event_key", eventID.String(), "winning_outcome", winner, "canceled", report.Canceled, "payouts", len(report.Payouts))
+	e.Publish("execution_exchange", "settlement.report", report)
+	return nil
+}
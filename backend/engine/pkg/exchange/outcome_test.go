@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"math/big"
+	"testing"
+)
+
+func TestSettlement(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes int
+		winner   int
+		wantErr  bool
+	}{
+		{"yes", 2, 0, false},
+		{"no", 2, 1, false},
+		{"categorical", 3, 2, false},
+		{"out of range", 3, 3, true},
+		{"negative", 2, -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExchange(t)
+			eventID := big.NewInt(7)
+			if err := e.AddEvent(eventID, orderbook.Params{Outcomes: tt.outcomes}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := e.Settlement(eventID, tt.winner); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettlementPaysWinnersAndCancelsOrders(t *testing.T) {
+	e := newTestExchange(t)
+	eventID := big.NewInt(7)
+	if err := e.AddEvent(eventID, orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	buyer, seller := big.NewInt(1), big.NewInt(2)
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(10), UserID: seller, Price: 600, Quantity: 5})
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(11), UserID: buyer, Price: 600, Quantity: 3})
+
+	if err := e.Settlement(eventID, 1); err != nil {
+		t.Fatal(err)
+	}
+	ob := e.OrderBooks[eventID.String()]
+	if !ob.Settled || ob.GetTopSellOrder() != nil {
+		t.Fatalf("book settled = %v with resting sells %v", ob.Settled, ob.GetTopSellOrder())
+	}
+
+	// "No" won: the short seller is paid, the long buyer is not
+	payouts := ob.Settle(1)
+	want := map[string]int64{buyer.String(): 0, seller.String(): 3 * orderbook.DefaultPayout}
+	for _, p := range payouts {
+		if p.Amount != want[p.UserID.String()] {
+			t.Errorf("user %s paid %d, want %d", p.UserID, p.Amount, want[p.UserID.String()])
+		}
+	}
+	if err := e.Settlement(eventID, 1); err == nil {
+		t.Fatal("settled twice")
+	}
+}
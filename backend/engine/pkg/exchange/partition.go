@@ -0,0 +1,112 @@
+// dummyengine/pkg/exchange/partition.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"math/big"
+)
+
+// NotOwnerError is returned for an event owned by another partition
+type NotOwnerError struct {
+	EventID *big.Int
+	Owner   int
+}
+
+func (e *NotOwnerError) Error() string {
+	return fmt.Sprintf("event %s is owned by partition %d", e.EventID, e.Owner)
+}
+
+// Owner returns the partition owning the event and whether it is this one.
+// Without a partition map the exchange owns every event.
+func (e *Exchange) Owner(eventID *big.Int) (int, bool) {
+	if e.Partitions == nil {
+		return e.Partition, true
+	}
+	owner := e.Partitions.Owner(eventID)
+	return owner, owner == e.Partition
+}
+
+// checkOwner returns a NotOwnerError unless this partition owns the event
+func (e *Exchange) checkOwner(eventID *big.Int) error {
+	if owner, ok := e.Owner(eventID); !ok {
+		return &NotOwnerError{EventID: eventID, Owner: owner}
+	}
+	return nil
+}
+
+// HandOff moves an event to the target partition. send must deliver the
+// snapshots of the event's books to the target durably; only when it succeeds
+// is the event reassigned and dropped here, so a failed handoff leaves the
+// books in place.
+func (e *Exchange) HandOff(eventID *big.Int, target int, send func([]orderbook.BookSnapshot) error) error {
+	if e.Partitions == nil {
+		return fmt.Errorf("partitioning is not enabled")
+	}
+	if err := e.checkOwner(eventID); err != nil {
+		return err
+	}
+	if target == e.Partition {
+		return fmt.Errorf("event %s is already on partition %d", eventID, target)
+	}
+
+	eventKey := eventID.String()
+	books, err := e.books(eventID)
+	if err != nil {
+		return err
+	}
+
+	snapshots := make([]orderbook.BookSnapshot, len(books))
+	for i, ob := range books {
+		snapshots[i] = ob.Snapshot()
+	}
+	if err := send(snapshots); err != nil {
+		return fmt.Errorf("send snapshot of event %s: %w", eventKey, err)
+	}
+	if err := e.Partitions.Assign(eventID, target); err != nil {
+		return fmt.Errorf("assign event %s: %w", eventKey, err)
+	}
+	for _, ob := range books {
+		delete(e.OrderBooks, wire.MarketKey(eventID, ob.Outcome))
+	}
+	e.forget(eventID)
+
+	logger.Info("📤 Event handed off", "event_key", eventKey, "from", e.Partition, "to", target)
+	return nil
+}
+
+// Adopt takes over an event handed off by another partition, given the
+// snapshots of all its books. If they cannot be restored the event goes back
+// to its previous owner and no book of it is left here.
+func (e *Exchange) Adopt(eventID *big.Int, snapshots []orderbook.BookSnapshot) error {
+	if e.Partitions == nil {
+		return fmt.Errorf("partitioning is not enabled")
+	}
+	if _, exists := e.OrderBooks[wire.MarketKey(eventID, 0)]; exists {
+		logger.Warn("Event already adopted", "event_key", eventID.String())
+		return nil
+	}
+
+	// The books are only accepted once the event is assigned here
+	previous := e.Partitions.Owner(eventID)
+	if err := e.Partitions.Assign(eventID, e.Partition); err != nil {
+		return fmt.Errorf("assign event %s: %w", eventID, err)
+	}
+	if err := e.Restore(Snapshot{Books: snapshots}); err != nil {
+		for key, ob := range e.OrderBooks {
+			if ob.EventID.Cmp(eventID) == 0 {
+				delete(e.OrderBooks, key)
+			}
+		}
+		e.forget(eventID)
+		if undo := e.Partitions.Assign(eventID, previous); undo != nil {
+			logger.Error("❌ Failed to give a refused event back", "event_key", eventID.String(), "partition", previous, "error", undo)
+		}
+		return err
+	}
+
+	logger.Info("📥 Event adopted", "event_key", eventID.String(), "partition", e.Partition, "books", len(snapshots))
+	return nil
+}
@@ -0,0 +1,113 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/partition"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// partitioned returns the exchanges serving partitions 0 and 1 of one map,
+// with event 7 created on its owner
+func partitioned(t *testing.T) (owner, other *Exchange, eventID *big.Int) {
+	t.Helper()
+	m, err := partition.NewMap(2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventID = big.NewInt(7)
+	p := m.Owner(eventID)
+	owner, other = newTestExchange(t), newTestExchange(t)
+	owner.Partitions, owner.Partition = m, p
+	other.Partitions, other.Partition = m, 1-p
+	if err := owner.AddEvent(eventID, orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	owner.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 400, Quantity: 2})
+	return owner, other, eventID
+}
+
+func TestNotOwnerIsRefused(t *testing.T) {
+	_, other, eventID := partitioned(t)
+	var notOwner *NotOwnerError
+	if err := other.AddEvent(eventID, orderbook.Params{}); !errors.As(err, &notOwner) || notOwner.Owner != 1-other.Partition {
+		t.Errorf("create on the wrong partition: %v", err)
+	}
+}
+
+func TestHandOff(t *testing.T) {
+	owner, other, eventID := partitioned(t)
+
+	// A handoff the target never confirmed leaves the book where it was
+	if err := owner.HandOff(eventID, other.Partition, func([]orderbook.BookSnapshot) error { return errors.New("nack") }); err == nil {
+		t.Fatal("failed send reported as a handoff")
+	}
+	if _, ok := owner.Owner(eventID); !ok || owner.OrderBooks["7"] == nil {
+		t.Fatal("book left the owner after a failed send")
+	}
+
+	var sent []orderbook.BookSnapshot
+	if err := owner.HandOff(eventID, other.Partition, func(s []orderbook.BookSnapshot) error { sent = s; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if owner.OrderBooks["7"] != nil {
+		t.Error("book kept after the handoff")
+	}
+	if err := other.Adopt(eventID, sent); err != nil {
+		t.Fatal(err)
+	}
+	top := other.OrderBooks["7"].GetTopBuyOrder()
+	if top == nil || top.Price != 400 || top.Quantity != 2 {
+		t.Errorf("adopted book top %v, want 2 at 400", top)
+	}
+	if _, ok := other.Owner(eventID); !ok {
+		t.Error("adopting partition does not own the event")
+	}
+}
+
+func TestAdoptRefusedLeavesNoTrace(t *testing.T) {
+	owner, other, eventID := partitioned(t)
+	var sent []orderbook.BookSnapshot
+	if err := owner.HandOff(eventID, other.Partition, func(s []orderbook.BookSnapshot) error { sent = s; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	// The adopting instance keeps a map of its own, still naming the sender
+	m, err := partition.NewMap(2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Partitions = m
+
+	// A yes/no event has no outcome 5: its first book is restored before the
+	// second is refused
+	bogus := sent[0]
+	bogus.Outcome = 5
+	if err := other.Adopt(eventID, append([]orderbook.BookSnapshot{sent[0]}, bogus)); err == nil {
+		t.Fatal("adopted a book for an unknown outcome")
+	}
+	if len(other.OrderBooks) != 0 {
+		t.Errorf("books %v left behind", other.OrderBooks)
+	}
+	if _, ok := other.Owner(eventID); ok {
+		t.Error("refused event still assigned to the adopting partition")
+	}
+
+	// The intact handoff still goes through
+	if err := other.Adopt(eventID, sent); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Adopt(eventID, sent); err != nil {
+		t.Errorf("redelivered adoption: %v", err)
+	}
+	if top := other.OrderBooks["7"].GetTopBuyOrder(); top == nil || top.Quantity != 2 {
+		t.Errorf("adopted book top %v, want the 2 handed over, once", top)
+	}
+}
+
+func TestHandOffToItself(t *testing.T) {
+	owner, _, eventID := partitioned(t)
+	if err := owner.HandOff(eventID, owner.Partition, func([]orderbook.BookSnapshot) error { return nil }); err == nil {
+		t.Error("handed an event off to its own partition")
+	}
+}
@@ -0,0 +1,98 @@
+// dummyengine/pkg/exchange/registry.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/registry"
+	"math/big"
+	"time"
+)
+
+// LoadRegistry opens a book for every registered event this partition owns
+// that the snapshot did not restore, in the state it was left in, then
+// registers any restored book the registry lacks
+func (e *Exchange) LoadRegistry() {
+	if e.Registry == nil {
+		return
+	}
+
+	opened := 0
+	for _, entry := range e.Registry.Entries() {
+		if _, ok := e.Owner(entry.EventID); !ok {
+			continue
+		}
+		eventKey := entry.EventID.String()
+		if _, exists := e.OrderBooks[eventKey]; exists {
+			continue
+		}
+		if err := e.AddEvent(entry.EventID, entry.Params); err != nil {
+			logger.Error("❌ Registered event has invalid parameters", "event_key", eventKey, "error", err)
+			continue
+		}
+		books, _ := e.books(entry.EventID)
+		for _, ob := range books {
+			ob.Auction = entry.State == registry.StateAuction
+			ob.Settled = entry.State == registry.StateSettled
+		}
+		e.record(books[0])
+		opened++
+	}
+
+	for _, ob := range e.OrderBooks {
+		if _, ok := e.Registry.Get(ob.EventID); !ok {
+			e.record(ob)
+		}
+	}
+	logger.Info("🗂️ Events reopened from the registry", "opened", opened, "books", len(e.OrderBooks))
+}
+
+// record saves a book's rules and phase to the registry; a settled event stays settled
+func (e *Exchange) record(ob *orderbook.OrderBook) {
+	state := registry.StateTrading
+	if ob.Auction {
+		state = registry.StateAuction
+	}
+	if entry, ok := e.registered(ob.EventID); ob.Settled || ok && entry.State == registry.StateSettled {
+		state = registry.StateSettled
+	}
+	e.put(registry.Entry{EventID: ob.EventID, Params: ob.Params, State: state})
+}
+
+// recordState changes a registered event's state
+func (e *Exchange) recordState(eventID *big.Int, state string) {
+	entry, ok := e.registered(eventID)
+	if !ok {
+		return
+	}
+	entry.State = state
+	e.put(entry)
+}
+
+// forget drops an event that is no longer served here
+func (e *Exchange) forget(eventID *big.Int) {
+	if e.Registry == nil {
+		return
+	}
+	if err := e.Registry.Remove(eventID); err != nil {
+		logger.Error("❌ Failed to save the event registry", "event_key", eventID.String(), "error", err)
+	}
+}
+
+func (e *Exchange) registered(eventID *big.Int) (registry.Entry, bool) {
+	if e.Registry == nil {
+		return registry.Entry{}, false
+	}
+	return e.Registry.Get(eventID)
+}
+
+// put saves an entry, logging rather than failing: the books stay authoritative
+func (e *Exchange) put(entry registry.Entry) {
+	if e.Registry == nil {
+		return
+	}
+	entry.UpdatedAt = time.Now().Unix()
+	if err := e.Registry.Put(entry); err != nil {
+		logger.Error("❌ Failed to save the event registry", "event_key", entry.EventID.String(), "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/registry"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+// registeredExchange returns an exchange keeping its events in a registry at path
+func registeredExchange(t *testing.T, path string) *Exchange {
+	t.Helper()
+	r, err := registry.NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newTestExchange(t)
+	e.Registry = r
+	return e
+}
+
+func TestLoadRegistryReopensEventsInTheirPhase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	e := registeredExchange(t, path)
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{OpeningAuction: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddEvent(big.NewInt(2), orderbook.Params{OpeningAuction: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Uncross(big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddEvent(big.NewInt(3), orderbook.Params{MatchingPolicy: "PRO_RATA"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart with no snapshot reopens every book empty
+	restarted := registeredExchange(t, path)
+	restarted.LoadRegistry()
+	if len(restarted.OrderBooks) != 3 {
+		t.Fatalf("%d books reopened, want 3", len(restarted.OrderBooks))
+	}
+	if !restarted.OrderBooks["1"].Auction || restarted.OrderBooks["2"].Auction {
+		t.Error("reopened books lost their auction phase")
+	}
+	if name := restarted.OrderBooks["3"].Policy.Name(); name != "PRO_RATA" {
+		t.Errorf("policy %s, want PRO_RATA", name)
+	}
+}
+
+func TestLoadRegistryKeepsRestoredBooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	e := registeredExchange(t, path)
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 400, Quantity: 2})
+	s := e.Snapshot()
+
+	// An event created after the snapshot is known only to the registry
+	if err := e.AddEvent(big.NewInt(2), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := registeredExchange(t, path)
+	if err := restarted.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	restarted.LoadRegistry()
+	if top := restarted.OrderBooks["1"].GetTopBuyOrder(); top == nil || top.Quantity != 2 {
+		t.Errorf("restored book replaced: top %v", top)
+	}
+	if restarted.OrderBooks["2"] == nil {
+		t.Error("event 2 not reopened")
+	}
+}
+
+func TestLoadRegistrySkipsOtherPartitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	e := registeredExchange(t, path)
+	for id := int64(1); id <= 8; id++ {
+		if err := e.AddEvent(big.NewInt(id), orderbook.Params{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := partition.NewMap(2, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted := registeredExchange(t, path)
+	restarted.Partitions, restarted.Partition = m, 0
+	restarted.LoadRegistry()
+	for eventKey, ob := range restarted.OrderBooks {
+		if m.Owner(ob.EventID) != 0 {
+			t.Errorf("event %s of partition 1 reopened", eventKey)
+		}
+	}
+	if len(restarted.OrderBooks) == 0 || len(restarted.OrderBooks) == 8 {
+		t.Errorf("%d of 8 events reopened, want partition 0's share", len(restarted.OrderBooks))
+	}
+}
+
+func TestSettledStaysSettled(t *testing.T) {
+	e := registeredExchange(t, "")
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settlement(big.NewInt(1), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.UpdateEvent(big.NewInt(1), orderbook.Params{MatchingPolicy: "PRO_RATA"}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := e.Registry.Get(big.NewInt(1)); entry.State != registry.StateSettled || entry.Params.MatchingPolicy != "PRO_RATA" {
+		t.Errorf("entry %+v, want settled with the new params", entry)
+	}
+}
+
+func TestHandOffForgetsTheEvent(t *testing.T) {
+	owner, _, eventID := partitioned(t)
+	r, err := registry.NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner.Registry = r
+	owner.LoadRegistry()
+	if _, ok := r.Get(eventID); !ok {
+		t.Fatal("the owner's book was not registered")
+	}
+	if err := owner.HandOff(eventID, 1-owner.Partition, func([]orderbook.BookSnapshot) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(eventID); ok {
+		t.Error("handed-off event still registered")
+	}
+}
@@ -0,0 +1,103 @@
+// dummyengine/pkg/exchange/snapshot.go
+package exchange
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot is the state of every book in the exchange at a point in time
+type Snapshot struct {
+	TakenAt  int64  // unix seconds
+	Sequence uint64 // last journaled input reflected in the books
+	Books    []orderbook.BookSnapshot
+}
+
+// Snapshot copies the state of every book. Callers make sure no order is
+// being processed concurrently.
+func (e *Exchange) Snapshot() Snapshot {
+	s := Snapshot{TakenAt: time.Now().Unix(), Sequence: e.Sequence}
+	for _, ob := range e.OrderBooks {
+		s.Books = append(s.Books, ob.Snapshot())
+	}
+	return s
+}
+
+// Restore recreates the books of a snapshot, every outcome of an event from
+// its own entry; events that already exist are skipped
+func (e *Exchange) Restore(s Snapshot) error {
+	created := map[string]bool{}
+	for _, book := range s.Books {
+		eventKey := book.EventID.String()
+		if !created[eventKey] {
+			if _, exists := e.OrderBooks[eventKey]; exists {
+				logger.Warn("Event already exists, skipping snapshot", "event_key", eventKey)
+				continue
+			}
+			if err := e.AddEvent(book.EventID, book.Params); err != nil {
+				return fmt.Errorf("restore event %s: %w", eventKey, err)
+			}
+			created[eventKey] = true
+		}
+		ob, exists := e.OrderBooks[wire.MarketKey(book.EventID, book.Outcome)]
+		if !exists {
+			return fmt.Errorf("restore event %s: no outcome %d", eventKey, book.Outcome)
+		}
+		ob.Restore(book)
+		e.record(ob)
+	}
+	if s.Sequence > e.Sequence {
+		e.Sequence = s.Sequence
+	}
+	return nil
+}
+
+// WriteSnapshot writes the exchange state to path atomically through a temp file
+func (e *Exchange) WriteSnapshot(path string) error {
+	data, err := json.Marshal(e.Snapshot())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSnapshot restores the state written by WriteSnapshot. The file is then
+// moved aside so that a later crash cannot resurrect stale orders from it.
+// A missing file is not an error.
+func (e *Exchange) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("parse snapshot %s: %w", path, err)
+	}
+	if err := e.Restore(s); err != nil {
+		return err
+	}
+	if err := os.Rename(path, path+".restored"); err != nil {
+		return err
+	}
+
+	logger.Info("📸 State restored from snapshot", "path", path, "events", len(s.Books), "taken_at", time.Unix(s.TakenAt, 0).Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,151 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestExchange returns an exchange with no broker to publish to
+func newTestExchange(t *testing.T) *Exchange {
+	t.Helper()
+	return NewExchange(nil, "trade", "price", "book")
+}
+
+func restoredCopy(t *testing.T, e *Exchange) *Exchange {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state", "snapshot.json")
+	if err := e.WriteSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	restored := newTestExchange(t)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	return restored
+}
+
+func TestSnapshotKeepsPriority(t *testing.T) {
+	e := newTestExchange(t)
+	eventID := big.NewInt(7)
+	if err := e.AddEvent(eventID, orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	// Three sells at 500 in time priority, the first an iceberg, and a better one at 490
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 500, Quantity: 6, DisplayQuantity: 2})
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(2), UserID: big.NewInt(2), Price: 500, Quantity: 3})
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(3), UserID: big.NewInt(3), Price: 500, Quantity: 3})
+	e.AddOrder(eventID, orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(4), UserID: big.NewInt(1), Price: 490, Quantity: 1})
+
+	restored := restoredCopy(t, e)
+	ob := restored.OrderBooks["7"]
+	if top := ob.GetTopSellOrder(); top == nil || top.Price != 490 {
+		t.Fatalf("best ask %v, want 490", top)
+	}
+
+	// 1 at 490, then the iceberg's slice of 2 and order 2; the iceberg's next
+	// slice goes behind order 3
+	ob.AddOrder(orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(10), UserID: big.NewInt(9), Price: 500, Quantity: 6})
+	level := ob.GetTopSellOrder()
+	var got []int64
+	for _, o := range level.Orders {
+		got = append(got, o.ID.Int64())
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 1 {
+		t.Errorf("queue at 500 %v, want [3 1]", got)
+	}
+	if iceberg := level.Orders[len(level.Orders)-1]; iceberg.Quantity != 2 || iceberg.Hidden != 2 {
+		t.Errorf("iceberg shows %d and hides %d, want 2 and 2", iceberg.Quantity, iceberg.Hidden)
+	}
+}
+
+func TestSnapshotKeepsRulesAndUsage(t *testing.T) {
+	e := newTestExchange(t)
+	eventID := big.NewInt(7)
+	params := orderbook.Params{MatchingPolicy: "PRO_RATA", Limits: orderbook.Limits{MaxOpenOrders: 2}}
+	if err := e.AddEvent(eventID, params); err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(1); i <= 2; i++ {
+		e.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(i), UserID: big.NewInt(1), Price: 400, Quantity: 1})
+	}
+
+	restored := restoredCopy(t, e)
+	ob := restored.OrderBooks["7"]
+	if ob.Policy.Name() != "PRO_RATA" || ob.Params.Limits != params.Limits {
+		t.Errorf("policy %s limits %+v", ob.Policy.Name(), ob.Params.Limits)
+	}
+	if got := ob.Usage(big.NewInt(1)); got.OpenOrders != 2 || got.OpenNotional != 800 {
+		t.Errorf("usage %+v", got)
+	}
+
+	// The restored usage still counts against the limit
+	restored.AddOrder(eventID, orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(3), UserID: big.NewInt(1), Price: 400, Quantity: 1})
+	if got := ob.Usage(big.NewInt(1)).OpenOrders; got != 2 {
+		t.Errorf("%d open orders, want the third refused", got)
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing file means a cold start
+	e := newTestExchange(t)
+	if err := e.LoadSnapshot(filepath.Join(dir, "none.json")); err != nil || len(e.OrderBooks) != 0 {
+		t.Fatalf("missing snapshot: %v, %d books", err, len(e.OrderBooks))
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadSnapshot(bad); err == nil {
+		t.Error("corrupt snapshot loaded")
+	}
+	if _, err := os.Stat(bad); err != nil {
+		t.Errorf("corrupt snapshot moved aside: %v", err)
+	}
+
+	// A loaded snapshot is moved aside so a later start does not load it again
+	path := filepath.Join(dir, "snapshot.json")
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+	if err := newTestExchange(t).LoadSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("snapshot still in place: %v", err)
+	}
+	if _, err := os.Stat(path + ".restored"); err != nil {
+		t.Errorf("snapshot not kept aside: %v", err)
+	}
+}
+
+func TestRestoreSkipsExistingEvents(t *testing.T) {
+	e := newTestExchange(t)
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 400, Quantity: 1})
+	s := e.Snapshot()
+
+	live := newTestExchange(t)
+	if err := live.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := live.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if top := live.OrderBooks["1"].GetTopBuyOrder(); top != nil {
+		t.Errorf("snapshot orders added to a live book: %v", top)
+	}
+}
@@ -0,0 +1,66 @@
+// dummyengine/pkg/exchange/throttle.go
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// throttle charges n orders to the user's rate limits in the event
+func (e *Exchange) throttle(eventID, userID *big.Int, tier string, n int) error {
+	if e.RateLimiter == nil {
+		return nil
+	}
+	return e.RateLimiter.Take(userID.String(), eventID.String(), tier, n, e.receivedAt())
+}
+
+// throttleBatch charges an all-or-none batch as a whole: either every owner
+// has room for all of their orders and they are charged, or nothing is
+func (e *Exchange) throttleBatch(eventID *big.Int, requests []orderbook.OrderRequest) error {
+	if e.RateLimiter == nil {
+		return nil
+	}
+	at := e.receivedAt()
+
+	// Owners in order of first appearance, with their order count and tier
+	type owner struct {
+		userID string
+		tier   string
+		orders int
+	}
+	var owners []*owner
+	byUser := map[string]*owner{}
+	for _, req := range requests {
+		key := req.UserID.String()
+		o, ok := byUser[key]
+		if !ok {
+			o = &owner{userID: key, tier: req.Tier}
+			byUser[key] = o
+			owners = append(owners, o)
+		}
+		o.orders++
+	}
+
+	for _, o := range owners {
+		if err := e.RateLimiter.Check(o.userID, eventID.String(), o.tier, o.orders, at); err != nil {
+			return fmt.Errorf("user %s: %w", o.userID, err)
+		}
+	}
+	for _, o := range owners {
+		if err := e.RateLimiter.Take(o.userID, eventID.String(), o.tier, o.orders, at); err != nil {
+			return fmt.Errorf("user %s: %w", o.userID, err)
+		}
+	}
+	return nil
+}
+
+// receivedAt is the time rate limits are judged and trades stamped at: when
+// the current input was received, or now outside the consumer
+func (e *Exchange) receivedAt() time.Time {
+	if e.ReceivedAt.IsZero() {
+		return time.Now()
+	}
+	return e.ReceivedAt
+}
@@ -0,0 +1,100 @@
+package exchange
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/ratelimit"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reports collects the execution reports an exchange publishes
+type reports []*wire.ExecutionReport
+
+func (r *reports) OnReport(report *wire.ExecutionReport) {
+	*r = append(*r, report)
+}
+
+// statuses lists the last status reported for each order ID
+func (r reports) statuses() map[int64]string {
+	got := map[int64]string{}
+	for _, report := range r {
+		got[report.OrderID.Int64()] = report.Status
+	}
+	return got
+}
+
+func throttledExchange(t *testing.T, tier ratelimit.Tier) (*Exchange, *reports) {
+	t.Helper()
+	e := newTestExchange(t)
+	limiter, err := ratelimit.NewLimiter(map[string]ratelimit.Tier{ratelimit.DefaultTier: tier})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.RateLimiter = limiter
+	e.ReceivedAt = time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	got := &reports{}
+	e.ReportObservers = append(e.ReportObservers, got)
+	if err := e.AddEvent(big.NewInt(1), orderbook.Params{}); err != nil {
+		t.Fatal(err)
+	}
+	return e, got
+}
+
+func TestOrdersThrottledAtReceivedTime(t *testing.T) {
+	e, got := throttledExchange(t, ratelimit.Tier{EventRate: 1, EventBurst: 2})
+	for i := int64(1); i <= 3; i++ {
+		e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(i), UserID: big.NewInt(1), Price: 100, Quantity: 1})
+	}
+	if status := got.statuses()[3]; status != wire.StatusRejected {
+		t.Fatalf("third order %s, want rejected", status)
+	}
+	if open := e.OrderBooks["1"].Usage(big.NewInt(1)).OpenOrders; open != 2 {
+		t.Errorf("%d orders resting, want 2", open)
+	}
+
+	// However long processing takes, only the receive time refills the bucket
+	e.ReceivedAt = e.ReceivedAt.Add(time.Second)
+	e.AddOrder(big.NewInt(1), orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(4), UserID: big.NewInt(1), Price: 100, Quantity: 1})
+	if status := got.statuses()[4]; status == wire.StatusRejected {
+		t.Error("order a second later still throttled")
+	}
+}
+
+func TestBatchThrottle(t *testing.T) {
+	batch := []wire.BatchOrderEntry{
+		{OrderID: "1", OrderUserID: "1", Type: "BUY", OrderPrice: 100, OrderQuantity: 1},
+		{OrderID: "2", OrderUserID: "2", Type: "BUY", OrderPrice: 100, OrderQuantity: 1},
+		{OrderID: "3", OrderUserID: "1", Type: "BUY", OrderPrice: 100, OrderQuantity: 1},
+		{OrderID: "4", OrderUserID: "1", Type: "BUY", OrderPrice: 100, OrderQuantity: 1},
+	}
+	tier := ratelimit.Tier{UserRate: 1, UserBurst: 2}
+
+	// Best effort: user 1's third order is the only one refused
+	e, got := throttledExchange(t, tier)
+	e.AddBatch(big.NewInt(1), 0, "b1", batch, false)
+	want := map[int64]string{1: wire.StatusNew, 2: wire.StatusNew, 3: wire.StatusNew, 4: wire.StatusRejected}
+	for id, status := range want {
+		if got.statuses()[id] != status {
+			t.Errorf("best effort order %d %s, want %s", id, got.statuses()[id], status)
+		}
+	}
+
+	// All or none: user 1 has no room for 3, so nobody is charged, user 2 included
+	e, got = throttledExchange(t, tier)
+	e.AddBatch(big.NewInt(1), 0, "b1", batch, true)
+	for _, r := range *got {
+		if r.Status != wire.StatusRejected || !strings.Contains(r.Reason, "user 1") {
+			t.Errorf("all or none order %s %s: %s", r.OrderID, r.Status, r.Reason)
+		}
+	}
+	if stats := e.RateLimiter.Stats().Tiers[ratelimit.DefaultTier]; stats.Allowed != 0 {
+		t.Errorf("%d orders charged for a refused batch", stats.Allowed)
+	}
+	e.AddBatch(big.NewInt(1), 0, "b2", batch[:3], true)
+	if open := e.OrderBooks["1"].Usage(big.NewInt(1)).OpenOrders; open != 2 {
+		t.Errorf("user 1 rests %d orders after a batch that fits, want 2", open)
+	}
+}
@@ -0,0 +1,47 @@
+// dummyengine/pkg/fees/fees.go
+package fees
+
+import "fmt"
+
+// Liquidity flags carried on each trade leg
+const (
+	Maker   = "MAKER"
+	Taker   = "TAKER"
+	Auction = "AUCTION" // both legs of an auction uncross, charged the maker fee
+)
+
+// Schedule is a per-event fee schedule in basis points of notional
+// (price * quantity) with a floor per fill
+type Schedule struct {
+	MakerBps int
+	TakerBps int
+	MinFee   int
+}
+
+func (s Schedule) Validate() error {
+	if s.MakerBps < 0 || s.TakerBps < 0 || s.MinFee < 0 {
+		return fmt.Errorf("invalid fee schedule: maker %d bps, taker %d bps, min %d", s.MakerBps, s.TakerBps, s.MinFee)
+	}
+	return nil
+}
+
+// Maker returns the fee charged to the resting side of a fill, and to both
+// sides of an auction uncross
+func (s Schedule) Maker(price, quantity int) int {
+	return s.fee(s.MakerBps, price, quantity)
+}
+
+// Taker returns the fee charged to the incoming side of a fill
+func (s Schedule) Taker(price, quantity int) int {
+	return s.fee(s.TakerBps, price, quantity)
+}
+
+// fee is computed in integers only, rounding half up, so that every service
+// applying the same schedule to the same fill arrives at the same number:
+//
+//	fee = max(MinFee, (price * quantity * bps + 5000) / 10000)
+func (s Schedule) fee(bps, price, quantity int) int {
+	notional := int64(price) * int64(quantity)
+	fee := int((notional*int64(bps) + 5000) / 10000)
+	return max(fee, s.MinFee)
+}
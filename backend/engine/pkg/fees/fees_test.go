@@ -0,0 +1,54 @@
+package fees
+
+import "testing"
+
+func TestFeeRoundsHalfUp(t *testing.T) {
+	// 25 bps of a notional of 1000 is 2.5
+	s := Schedule{MakerBps: 25, TakerBps: 25}
+	if got := s.Taker(100, 10); got != 3 {
+		t.Errorf("2.5 rounded to %d, want 3", got)
+	}
+	// 24 bps of the same is 2.4
+	s.MakerBps = 24
+	if got := s.Maker(100, 10); got != 2 {
+		t.Errorf("2.4 rounded to %d, want 2", got)
+	}
+	// and below half of the smallest unit is free
+	if got := (Schedule{TakerBps: 4}).Taker(100, 10); got != 0 {
+		t.Errorf("0.4 rounded to %d, want 0", got)
+	}
+}
+
+func TestMinFee(t *testing.T) {
+	s := Schedule{MakerBps: 0, TakerBps: 10, MinFee: 3}
+
+	// The floor applies per fill, even to a side with no rate
+	if got := s.Maker(500, 1); got != 3 {
+		t.Errorf("maker fee %d, want the floor of 3", got)
+	}
+	if got := s.Taker(500, 1); got != 3 {
+		t.Errorf("taker fee %d on 0.5, want the floor of 3", got)
+	}
+	if got := s.Taker(1000, 100); got != 100 {
+		t.Errorf("taker fee %d, want 100 above the floor", got)
+	}
+}
+
+func TestFeeOnLargeNotional(t *testing.T) {
+	// price * quantity * bps is past 32 bits well before the fee is
+	s := Schedule{TakerBps: 10000}
+	if got := s.Taker(1<<20, 1<<20); got != 1<<40 {
+		t.Errorf("fee %d, want %d", got, 1<<40)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Schedule{}).Validate(); err != nil {
+		t.Errorf("free schedule refused: %v", err)
+	}
+	for _, s := range []Schedule{{MakerBps: -1}, {TakerBps: -1}, {MinFee: -1}} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%+v accepted", s)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// dummyengine/pkg/grpcapi/codec.go
+package grpcapi
+
+import (
+	"dummyengine/pkg/wire"
+	"fmt"
+)
+
+// codec encodes gRPC messages with the engine's hand-written protobuf
+// encoders, so standard protobuf clients built from engine.proto interoperate
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(wire.Message)
+	if !ok {
+		return nil, fmt.Errorf("grpcapi: %T is not a wire message", v)
+	}
+	return m.MarshalProto()
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(wire.Message)
+	if !ok {
+		return fmt.Errorf("grpcapi: %T is not a wire message", v)
+	}
+	return m.UnmarshalProto(data)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,258 @@
+// dummyengine/pkg/grpcapi/server.go
+package grpcapi
+
+import (
+	"context"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/rabbitmqQueue"
+	"dummyengine/pkg/wire"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Submitter runs a task through the engine's sequenced input, as
+// rabbitmqQueue.RabbitMQQueue.Submit does
+type Submitter interface {
+	Submit(ctx context.Context, msg *wire.EventMessage) ([]*wire.ExecutionReport, error)
+}
+
+// Server implements the Engine gRPC service. Orders go through Engine so
+// they share sequencing and journaling with order_queue; book subscriptions
+// are fed as a trade and book observer of the exchange.
+type Server struct {
+	Engine       Submitter
+	Timeout      time.Duration // per order request
+	StreamBuffer int           // events a subscriber may fall behind before it is dropped
+
+	grpc *grpc.Server
+
+	mu     sync.Mutex
+	books  map[string]*wire.OrderBookUpdate // latest depth per book, by wire.MarketKey
+	subs   map[string]map[*subscriber]struct{}
+	closed bool
+}
+
+type subscriber struct {
+	events  chan *wire.BookEvent
+	evicted chan struct{} // closed when dropped for falling behind or on shutdown
+}
+
+func NewServer(engine Submitter, timeout time.Duration, streamBuffer int) *Server {
+	s := &Server{
+		Engine:       engine,
+		Timeout:      timeout,
+		StreamBuffer: streamBuffer,
+		books:        make(map[string]*wire.OrderBookUpdate),
+		subs:         make(map[string]map[*subscriber]struct{}),
+	}
+	s.grpc = grpc.NewServer(grpc.ForceServerCodec(codec{}))
+	s.grpc.RegisterService(&ServiceDesc, s)
+	return s
+}
+
+// Serve accepts connections on addr until Stop
+func (s *Server) Serve(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("🛰️ gRPC API listening", "addr", addr)
+	return s.grpc.Serve(lis)
+}
+
+// Stop ends every book stream, then lets in-flight order requests finish
+func (s *Server) Stop() {
+	s.mu.Lock()
+	s.closed = true
+	for _, subs := range s.subs {
+		for sub := range subs {
+			close(sub.evicted)
+		}
+	}
+	s.subs = make(map[string]map[*subscriber]struct{})
+	s.mu.Unlock()
+
+	s.grpc.GracefulStop()
+}
+
+func (s *Server) SubmitOrder(ctx context.Context, req *wire.SubmitOrderRequest) (*wire.ExecutionReport, error) {
+	if req.EventID == nil || req.OrderID == nil || req.UserID == nil {
+		return nil, status.Error(codes.InvalidArgument, "event_id, order_id and user_id are required")
+	}
+	return s.submit(ctx, req.OrderID, &wire.EventMessage{
+		Task:            "Order",
+		ID:              req.EventID.String(),
+		OrderID:         req.OrderID.String(),
+		OrderUserID:     req.UserID.String(),
+		OrderPrice:      req.Price,
+		OrderQuantity:   req.Quantity,
+		Type:            req.Side,
+		DisplayQuantity: req.DisplayQuantity,
+		Tier:            req.Tier,
+		PostOnly:        req.PostOnly,
+		PostOnlyReprice: req.PostOnlyReprice,
+		MinQty:          req.MinQty,
+		AllOrNone:       req.AllOrNone,
+		Outcome:         req.Outcome,
+	})
+}
+
+func (s *Server) CancelOrder(ctx context.Context, req *wire.CancelOrderRequest) (*wire.ExecutionReport, error) {
+	if req.EventID == nil || req.OrderID == nil || req.UserID == nil {
+		return nil, status.Error(codes.InvalidArgument, "event_id, order_id and user_id are required")
+	}
+	return s.submit(ctx, req.OrderID, &wire.EventMessage{
+		Task:        "CancelOrder",
+		ID:          req.EventID.String(),
+		OrderID:     req.OrderID.String(),
+		OrderUserID: req.UserID.String(),
+	})
+}
+
+func (s *Server) ModifyOrder(ctx context.Context, req *wire.ModifyOrderRequest) (*wire.ExecutionReport, error) {
+	if req.EventID == nil || req.OrderID == nil || req.UserID == nil {
+		return nil, status.Error(codes.InvalidArgument, "event_id, order_id and user_id are required")
+	}
+	return s.submit(ctx, req.OrderID, &wire.EventMessage{
+		Task:          "ModifyOrder",
+		ID:            req.EventID.String(),
+		OrderID:       req.OrderID.String(),
+		OrderUserID:   req.UserID.String(),
+		OrderPrice:    req.Price,
+		OrderQuantity: req.Quantity,
+		Tier:          req.Tier,
+	})
+}
+
+// submit runs msg through the engine and returns the order's report
+func (s *Server) submit(ctx context.Context, orderID *big.Int, msg *wire.EventMessage) (*wire.ExecutionReport, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	reports, err := s.Engine.Submit(ctx, msg)
+	if err != nil {
+		var rejected *rabbitmqQueue.RejectedError
+		switch {
+		case errors.As(err, &rejected):
+			return nil, status.Error(codes.InvalidArgument, rejected.Reason)
+		case errors.Is(err, rabbitmqQueue.ErrUnavailable):
+			return nil, status.Error(codes.Unavailable, err.Error())
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+			return nil, status.FromContextError(err).Err()
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	for i := len(reports) - 1; i >= 0; i-- {
+		if reports[i].OrderID != nil && reports[i].OrderID.Cmp(orderID) == 0 {
+			return reports[i], nil
+		}
+	}
+	return nil, status.Error(codes.Internal, "no execution report for order")
+}
+
+// SubscribeBook streams the latest depth of the event's book, or of one
+// outcome's book, then every depth update and trade. A subscriber that falls
+// StreamBuffer events behind is dropped.
+func (s *Server) SubscribeBook(req *wire.SubscribeBookRequest, stream grpc.ServerStream) error {
+	if req.EventID == nil || req.Outcome < 0 {
+		return status.Error(codes.InvalidArgument, "event_id is required and outcome must not be negative")
+	}
+	eventKey := wire.MarketKey(req.EventID, req.Outcome)
+
+	sub := &subscriber{
+		events:  make(chan *wire.BookEvent, max(s.StreamBuffer, 1)),
+		evicted: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	snapshot := s.books[eventKey]
+	if s.subs[eventKey] == nil {
+		s.subs[eventKey] = make(map[*subscriber]struct{})
+	}
+	s.subs[eventKey][sub] = struct{}{}
+	s.mu.Unlock()
+	defer s.unsubscribe(eventKey, sub)
+
+	if snapshot != nil {
+		if err := stream.SendMsg(&wire.BookEvent{Book: snapshot}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event := <-sub.events:
+			if err := stream.SendMsg(event); err != nil {
+				return err
+			}
+		case <-sub.evicted:
+			return status.Error(codes.ResourceExhausted, "subscriber fell behind or server stopped")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) unsubscribe(eventKey string, sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if subs, ok := s.subs[eventKey]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(s.subs, eventKey)
+		}
+	}
+}
+
+// OnBookUpdate records the book's latest depth and streams it
+func (s *Server) OnBookUpdate(ob *orderbook.OrderBook) {
+	depth := ob.Depth()
+	key := wire.MarketKey(ob.EventID, ob.Outcome)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books[key] = depth
+	s.broadcast(key, &wire.BookEvent{Book: depth})
+}
+
+// OnTrade streams an execution
+func (s *Server) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcast(wire.MarketKey(eventID, outcome), &wire.BookEvent{Trade: &wire.PublicTrade{
+		EventID:   eventID,
+		Outcome:   outcome,
+		Price:     price,
+		Quantity:  quantity,
+		Timestamp: at.Unix(),
+	}})
+}
+
+// broadcast never blocks the matching loop: full subscribers are dropped; callers hold mu
+func (s *Server) broadcast(eventKey string, event *wire.BookEvent) {
+	for sub := range s.subs[eventKey] {
+		select {
+		case sub.events <- event:
+		default:
+			logger.Warn("🐢 Dropping slow book subscriber", "event_key", eventKey)
+			close(sub.evicted)
+			delete(s.subs[eventKey], sub)
+		}
+	}
+}
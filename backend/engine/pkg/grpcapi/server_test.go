@@ -0,0 +1,280 @@
+package grpcapi
+
+import (
+	"context"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/rabbitmqQueue"
+	"dummyengine/pkg/wire"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// engine answers submissions with canned reports or an error
+type engine struct {
+	got     *wire.EventMessage
+	reports []*wire.ExecutionReport
+	err     error
+	block   bool // wait for the caller to give up
+}
+
+func (e *engine) Submit(ctx context.Context, msg *wire.EventMessage) ([]*wire.ExecutionReport, error) {
+	e.got = msg
+	if e.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return e.reports, e.err
+}
+
+// serve starts s on an in-memory listener and returns a client connection to it
+func serve(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go s.grpc.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func submitOrder(conn *grpc.ClientConn, req *wire.SubmitOrderRequest) (*wire.ExecutionReport, error) {
+	report := &wire.ExecutionReport{}
+	err := conn.Invoke(context.Background(), "/"+ServiceName+"/SubmitOrder", req, report)
+	return report, err
+}
+
+func TestCodecRefusesForeignMessages(t *testing.T) {
+	if _, err := (codec{}).Marshal("order"); err == nil {
+		t.Error("marshalled a string")
+	}
+	if err := (codec{}).Unmarshal(nil, new(int)); err == nil {
+		t.Error("unmarshalled into an int")
+	}
+	// Clients built from engine.proto ask for the default "proto" codec
+	if (codec{}).Name() != "proto" {
+		t.Errorf("codec name %q", codec{}.Name())
+	}
+}
+
+func TestSubmitOrder(t *testing.T) {
+	orderID := new(big.Int).Lsh(big.NewInt(1), 80) // wider than int64
+	e := &engine{reports: []*wire.ExecutionReport{
+		{OrderID: orderID, Status: "NEW"},
+		{OrderID: big.NewInt(5), Status: "FILLED"}, // the maker it traded with
+		{OrderID: orderID, Status: "PARTIALLY_FILLED", FilledQuantity: 2},
+	}}
+	conn := serve(t, NewServer(e, time.Second, 8))
+
+	report, err := submitOrder(conn, &wire.SubmitOrderRequest{
+		EventID: big.NewInt(7), OrderID: orderID, UserID: big.NewInt(3),
+		Side: "BUY", Price: 400, Quantity: 5, DisplayQuantity: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The order's latest report, not the maker's
+	if report.Status != "PARTIALLY_FILLED" || report.OrderID.Cmp(orderID) != 0 {
+		t.Errorf("report %+v", report)
+	}
+	want := wire.EventMessage{Task: "Order", ID: "7", OrderID: orderID.String(), OrderUserID: "3", Type: "BUY", OrderPrice: 400, OrderQuantity: 5, DisplayQuantity: 1}
+	if got := *e.got; got.Task != want.Task || got.ID != want.ID || got.OrderID != want.OrderID || got.OrderUserID != want.OrderUserID ||
+		got.Type != want.Type || got.OrderPrice != want.OrderPrice || got.OrderQuantity != want.OrderQuantity || got.DisplayQuantity != want.DisplayQuantity {
+		t.Errorf("engine got %+v, want %+v", got, want)
+	}
+}
+
+func TestSubmitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *engine
+		req  *wire.SubmitOrderRequest
+		want codes.Code
+	}{
+		{"missing user", &engine{}, &wire.SubmitOrderRequest{EventID: big.NewInt(7), OrderID: big.NewInt(1)}, codes.InvalidArgument},
+		{"rejected", &engine{err: &rabbitmqQueue.RejectedError{Reason: "invalid side"}}, nil, codes.InvalidArgument},
+		{"standing by", &engine{err: rabbitmqQueue.ErrUnavailable}, nil, codes.Unavailable},
+		{"timed out", &engine{block: true}, nil, codes.DeadlineExceeded},
+		{"no report", &engine{reports: []*wire.ExecutionReport{{OrderID: big.NewInt(2)}}}, nil, codes.Internal},
+		{"engine failure", &engine{err: errors.New("boom")}, nil, codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := serve(t, NewServer(tt.e, 50*time.Millisecond, 8))
+			req := tt.req
+			if req == nil {
+				req = &wire.SubmitOrderRequest{EventID: big.NewInt(7), OrderID: big.NewInt(1), UserID: big.NewInt(1), Side: "BUY", Price: 1, Quantity: 1}
+			}
+			_, err := submitOrder(conn, req)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("code %v (%v), want %v", got, err, tt.want)
+			}
+			if tt.name == "missing user" && tt.e.got != nil {
+				t.Error("incomplete request reached the engine")
+			}
+			if tt.name == "rejected" && status.Convert(err).Message() != "invalid side" {
+				t.Errorf("message %q, want the rejection reason", status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestCancelAndModifyMapToTasks(t *testing.T) {
+	e := &engine{reports: []*wire.ExecutionReport{{OrderID: big.NewInt(1), Status: "CANCELED"}}}
+	conn := serve(t, NewServer(e, time.Second, 8))
+	ctx := context.Background()
+
+	cancel := &wire.CancelOrderRequest{EventID: big.NewInt(7), OrderID: big.NewInt(1), UserID: big.NewInt(3)}
+	if err := conn.Invoke(ctx, "/"+ServiceName+"/CancelOrder", cancel, &wire.ExecutionReport{}); err != nil {
+		t.Fatal(err)
+	}
+	if e.got.Task != "CancelOrder" || e.got.OrderUserID != "3" {
+		t.Errorf("cancel sent %+v", e.got)
+	}
+
+	modify := &wire.ModifyOrderRequest{EventID: big.NewInt(7), OrderID: big.NewInt(1), UserID: big.NewInt(3), Price: 410, Quantity: 2}
+	if err := conn.Invoke(ctx, "/"+ServiceName+"/ModifyOrder", modify, &wire.ExecutionReport{}); err != nil {
+		t.Fatal(err)
+	}
+	if e.got.Task != "ModifyOrder" || e.got.OrderPrice != 410 || e.got.OrderQuantity != 2 {
+		t.Errorf("modify sent %+v", e.got)
+	}
+}
+
+func subscribe(t *testing.T, conn *grpc.ClientConn, eventID int64) grpc.ClientStream {
+	t.Helper()
+	stream, err := conn.NewStream(context.Background(), &ServiceDesc.Streams[0], "/"+ServiceName+"/SubscribeBook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(&wire.SubscribeBookRequest{EventID: big.NewInt(eventID)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// waitSubscribed waits until the server has registered n subscribers for the event
+func waitSubscribed(t *testing.T, s *Server, eventKey string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		got := len(s.subs[eventKey])
+		s.mu.Unlock()
+		if got == n {
+			return
+		}
+	}
+	t.Fatalf("no %d subscribers for event %s", n, eventKey)
+}
+
+func recv(t *testing.T, stream grpc.ClientStream) *wire.BookEvent {
+	t.Helper()
+	event := &wire.BookEvent{}
+	if err := stream.RecvMsg(event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestSubscribeBook(t *testing.T) {
+	s := NewServer(&engine{}, time.Second, 8)
+	conn := serve(t, s)
+
+	ob, err := orderbook.NewOrderBook(nil, big.NewInt(7), "trade", "price", "book", orderbook.Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob.AddOrder(orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 400, Quantity: 3})
+	s.OnBookUpdate(ob)
+
+	// A late subscriber starts from the latest depth
+	stream := subscribe(t, conn, 7)
+	first := recv(t, stream)
+	if first.Book == nil || len(first.Book.BuyLevels) != 1 || first.Book.BuyLevels[0] != (wire.Level{Price: 400, Quantity: 3}) {
+		t.Fatalf("first event %+v, want the depth snapshot", first)
+	}
+
+	waitSubscribed(t, s, "7", 1)
+	at := time.Unix(1700000000, 0)
+	s.OnTrade(big.NewInt(8), 0, 450, 1, at) // another event's trade is not streamed
+	s.OnTrade(big.NewInt(7), 0, 400, 2, at)
+	if trade := recv(t, stream).Trade; trade == nil || trade.Price != 400 || trade.Quantity != 2 || trade.Timestamp != at.Unix() {
+		t.Errorf("trade %+v", trade)
+	}
+
+	// Stopping the server ends the stream
+	s.Stop()
+	if err := stream.RecvMsg(&wire.BookEvent{}); err == nil {
+		t.Error("stream still open after Stop")
+	}
+}
+
+func TestSubscribeBookWithoutSnapshot(t *testing.T) {
+	s := NewServer(&engine{}, time.Second, 8)
+	conn := serve(t, s)
+
+	stream := subscribe(t, conn, 9)
+	waitSubscribed(t, s, "9", 1)
+	ob, err := orderbook.NewOrderBook(nil, big.NewInt(9), "trade", "price", "book", orderbook.Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.OnBookUpdate(ob)
+	if event := recv(t, stream); event.Book == nil || event.Trade != nil {
+		t.Errorf("first event %+v, want the first depth update", event)
+	}
+}
+
+func TestSubscribeBookRequiresEvent(t *testing.T) {
+	conn := serve(t, NewServer(&engine{}, time.Second, 8))
+	stream, err := conn.NewStream(context.Background(), &ServiceDesc.Streams[0], "/"+ServiceName+"/SubscribeBook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.SendMsg(&wire.SubscribeBookRequest{})
+	stream.CloseSend()
+	if err := stream.RecvMsg(&wire.BookEvent{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error %v, want InvalidArgument", err)
+	}
+}
+
+func TestBroadcastDropsSlowSubscribers(t *testing.T) {
+	s := NewServer(&engine{}, time.Second, 1)
+	slow := &subscriber{events: make(chan *wire.BookEvent, 1), evicted: make(chan struct{})}
+	fast := &subscriber{events: make(chan *wire.BookEvent, 4), evicted: make(chan struct{})}
+	s.subs["7"] = map[*subscriber]struct{}{slow: {}, fast: {}}
+
+	s.OnTrade(big.NewInt(7), 0, 400, 1, time.Now())
+	s.OnTrade(big.NewInt(7), 0, 400, 1, time.Now())
+
+	select {
+	case <-slow.evicted:
+	default:
+		t.Fatal("slow subscriber kept")
+	}
+	if _, kept := s.subs["7"][slow]; kept {
+		t.Error("slow subscriber still registered")
+	}
+	if len(fast.events) != 2 {
+		t.Errorf("fast subscriber got %d events, want 2", len(fast.events))
+	}
+}
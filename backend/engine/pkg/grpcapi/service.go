@@ -0,0 +1,70 @@
+// dummyengine/pkg/grpcapi/service.go
+package grpcapi
+
+import (
+	"context"
+	"dummyengine/pkg/wire"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the fully qualified name of the Engine service in engine.proto
+const ServiceName = "opinex.engine.v1.Engine"
+
+// EngineServer is the Engine service as declared in engine.proto
+type EngineServer interface {
+	SubmitOrder(ctx context.Context, req *wire.SubmitOrderRequest) (*wire.ExecutionReport, error)
+	CancelOrder(ctx context.Context, req *wire.CancelOrderRequest) (*wire.ExecutionReport, error)
+	ModifyOrder(ctx context.Context, req *wire.ModifyOrderRequest) (*wire.ExecutionReport, error)
+	SubscribeBook(req *wire.SubscribeBookRequest, stream grpc.ServerStream) error
+}
+
+// ServiceDesc describes the Engine service to grpc, as protoc-gen-go-grpc would
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*EngineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SubmitOrder", Handler: unary("SubmitOrder", func(s EngineServer, ctx context.Context, req *wire.SubmitOrderRequest) (*wire.ExecutionReport, error) {
+			return s.SubmitOrder(ctx, req)
+		})},
+		{MethodName: "CancelOrder", Handler: unary("CancelOrder", func(s EngineServer, ctx context.Context, req *wire.CancelOrderRequest) (*wire.ExecutionReport, error) {
+			return s.CancelOrder(ctx, req)
+		})},
+		{MethodName: "ModifyOrder", Handler: unary("ModifyOrder", func(s EngineServer, ctx context.Context, req *wire.ModifyOrderRequest) (*wire.ExecutionReport, error) {
+			return s.ModifyOrder(ctx, req)
+		})},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeBook",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				req := &wire.SubscribeBookRequest{}
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(EngineServer).SubscribeBook(req, stream)
+			},
+		},
+	},
+	Metadata: "engine.proto",
+}
+
+// unary adapts a typed method to grpc's method handler, interceptors included
+func unary[Req any, PReq interface {
+	*Req
+	wire.Message
+}](method string, call func(EngineServer, context.Context, PReq) (*wire.ExecutionReport, error)) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := PReq(new(Req))
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(EngineServer), ctx, req)
+		}
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + method}, func(ctx context.Context, req any) (any, error) {
+			return call(srv.(EngineServer), ctx, req.(PReq))
+		})
+	}
+}
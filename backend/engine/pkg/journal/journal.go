@@ -0,0 +1,123 @@
+// dummyengine/pkg/journal/journal.go
+package journal
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+// Headers a journal record carries on top of the original message's own
+const (
+	SequenceHeader  = "x-journal-sequence"
+	ConfirmedHeader = "x-journal-confirmed"
+	HeartbeatHeader = "x-journal-heartbeat"
+)
+
+// Entry is one record of the input journal. Every consumed message is
+// written with the next Sequence before it is processed; Confirmed is the
+// highest sequence whose outputs the broker had confirmed by then. Heartbeats
+// carry only Confirmed, so an idle primary still moves it forward.
+type Entry struct {
+	Sequence  uint64
+	Confirmed uint64
+	Heartbeat bool
+	Delivery  amqp.Delivery // the journal delivery, carrying the original message
+}
+
+// Record wraps a consumed message for the journal
+func Record(sequence, confirmed uint64, msg amqp.Delivery) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[SequenceHeader] = int64(sequence)
+	headers[ConfirmedHeader] = int64(confirmed)
+
+	return amqp.Publishing{
+		ContentType:   msg.ContentType,
+		MessageId:     msg.MessageId,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		Headers:       headers,
+		Body:          msg.Body,
+		DeliveryMode:  amqp.Persistent,
+	}
+}
+
+// Heartbeat builds a record that only advances Confirmed
+func Heartbeat(confirmed uint64) amqp.Publishing {
+	return amqp.Publishing{
+		Headers: amqp.Table{
+			HeartbeatHeader: true,
+			ConfirmedHeader: int64(confirmed),
+		},
+		DeliveryMode: amqp.Persistent,
+	}
+}
+
+// Parse reads a journal delivery
+func Parse(d amqp.Delivery) (Entry, error) {
+	entry := Entry{Delivery: d}
+	entry.Heartbeat, _ = d.Headers[HeartbeatHeader].(bool)
+
+	confirmed, ok := header(d.Headers, ConfirmedHeader)
+	if !ok {
+		return entry, fmt.Errorf("missing %s", ConfirmedHeader)
+	}
+	entry.Confirmed = confirmed
+	if entry.Heartbeat {
+		return entry, nil
+	}
+
+	sequence, ok := header(d.Headers, SequenceHeader)
+	if !ok || sequence == 0 {
+		return entry, fmt.Errorf("missing %s", SequenceHeader)
+	}
+	entry.Sequence = sequence
+	return entry, nil
+}
+
+func header(headers amqp.Table, key string) (uint64, bool) {
+	switch v := headers[key].(type) {
+	case int64:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+// Recent remembers the digests of the last Limit journaled bodies, so that a
+// message the broker redelivers after a crash or reconnect is not applied twice
+type Recent struct {
+	Limit int
+
+	order [][sha256.Size]byte
+	seen  map[[sha256.Size]byte]int
+}
+
+func NewRecent(limit int) *Recent {
+	return &Recent{Limit: limit, seen: make(map[[sha256.Size]byte]int)}
+}
+
+// Add records a journaled body, forgetting the oldest beyond Limit
+func (r *Recent) Add(body []byte) {
+	digest := sha256.Sum256(body)
+	r.order = append(r.order, digest)
+	r.seen[digest]++
+
+	if len(r.order) > r.Limit {
+		oldest := r.order[0]
+		r.order = r.order[1:]
+		if r.seen[oldest]--; r.seen[oldest] == 0 {
+			delete(r.seen, oldest)
+		}
+	}
+}
+
+// Contains reports whether body was journaled recently
+func (r *Recent) Contains(body []byte) bool {
+	return r.seen[sha256.Sum256(body)] > 0
+}
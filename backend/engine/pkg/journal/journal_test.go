@@ -0,0 +1,78 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	msg := amqp.Delivery{
+		ContentType: "application/json",
+		MessageId:   "m-1",
+		ReplyTo:     "replies",
+		Headers:     amqp.Table{"x-trace": "abc"},
+		Body:        []byte(`{"task":"Order"}`),
+	}
+	record := Record(5, 3, msg)
+	if _, leaked := msg.Headers[SequenceHeader]; leaked {
+		t.Error("journal headers written into the consumed message")
+	}
+
+	entry, err := Parse(amqp.Delivery{Headers: record.Headers, Body: record.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Sequence != 5 || entry.Confirmed != 3 || entry.Heartbeat {
+		t.Errorf("entry %+v", entry)
+	}
+	if record.Headers["x-trace"] != "abc" || record.ReplyTo != "replies" || string(record.Body) != `{"task":"Order"}` {
+		t.Errorf("record lost the original message: %+v", record)
+	}
+}
+
+func TestParse(t *testing.T) {
+	// The broker may hand small integers back as int32
+	entry, err := Parse(amqp.Delivery{Headers: amqp.Table{SequenceHeader: int32(2), ConfirmedHeader: int32(1)}})
+	if err != nil || entry.Sequence != 2 || entry.Confirmed != 1 {
+		t.Errorf("int32 headers: %+v, %v", entry, err)
+	}
+
+	// Heartbeats have no sequence of their own
+	entry, err = Parse(amqp.Delivery{Headers: Heartbeat(9).Headers})
+	if err != nil || !entry.Heartbeat || entry.Confirmed != 9 || entry.Sequence != 0 {
+		t.Errorf("heartbeat: %+v, %v", entry, err)
+	}
+
+	for _, headers := range []amqp.Table{
+		{SequenceHeader: int64(1)},
+		{ConfirmedHeader: int64(0)},
+		{SequenceHeader: int64(0), ConfirmedHeader: int64(0)},
+		{SequenceHeader: int64(-1), ConfirmedHeader: int64(0)},
+		{SequenceHeader: "1", ConfirmedHeader: int64(0)},
+	} {
+		if _, err := Parse(amqp.Delivery{Headers: headers}); err == nil {
+			t.Errorf("parsed %v", headers)
+		}
+	}
+}
+
+func TestRecentForgetsTheOldest(t *testing.T) {
+	r := NewRecent(2)
+	r.Add([]byte("a"))
+	r.Add([]byte("b"))
+	r.Add([]byte("a")) // the same body again, as a repeated order would be
+	if !r.Contains([]byte("a")) || !r.Contains([]byte("b")) {
+		t.Fatal("forgot a body within the limit")
+	}
+
+	// Dropping the first "a" keeps the second one remembered
+	r.Add([]byte("c"))
+	if !r.Contains([]byte("a")) {
+		t.Error("forgot a body still within the limit")
+	}
+	r.Add([]byte("d"))
+	if r.Contains([]byte("a")) || r.Contains([]byte("b")) {
+		t.Error("remembered bodies past the limit")
+	}
+}
@@ -0,0 +1,118 @@
+// dummyengine/pkg/leader/lease.go
+package leader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Lease elects one engine out of several sharing a filesystem. The holder
+// and its expiry live in a JSON file at Path; every read-modify-write happens
+// under an exclusive flock on Path + ".lock". The holder renews well inside
+// TTL, and anyone may take the lease once it has expired.
+type Lease struct {
+	Path   string
+	Holder string
+	TTL    time.Duration
+}
+
+// record is the on-disk form of the lease
+type record struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+func NewLease(path, holder string, ttl time.Duration) *Lease {
+	return &Lease{Path: path, Holder: holder, TTL: ttl}
+}
+
+// Acquire takes the lease, or renews it if already held. It reports false
+// while another holder's lease is still live.
+func (l *Lease) Acquire() (bool, error) {
+	acquired := false
+	err := l.locked(func() error {
+		current, err := l.read()
+		if err != nil {
+			return err
+		}
+		if current.Holder != "" && current.Holder != l.Holder && time.Now().Before(current.Expires) {
+			return nil
+		}
+
+		acquired = true
+		return l.write(record{Holder: l.Holder, Expires: time.Now().Add(l.TTL)})
+	})
+	return acquired && err == nil, err
+}
+
+// Release gives the lease up early so a standby can take over at once.
+// A lease held by someone else is left alone.
+func (l *Lease) Release() error {
+	return l.locked(func() error {
+		current, err := l.read()
+		if err != nil || current.Holder != l.Holder {
+			return err
+		}
+		return os.Remove(l.Path)
+	})
+}
+
+// Current returns the holder and expiry on file; an empty holder means nobody
+func (l *Lease) Current() (string, time.Time, error) {
+	var current record
+	err := l.locked(func() error {
+		var err error
+		current, err = l.read()
+		return err
+	})
+	return current.Holder, current.Expires, err
+}
+
+// locked runs fn while holding the lock file
+func (l *Lease) locked(fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
+
+func (l *Lease) read() (record, error) {
+	var current record
+	data, err := os.ReadFile(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return current, nil
+		}
+		return current, err
+	}
+	// A torn or foreign file counts as no lease
+	_ = json.Unmarshal(data, &current)
+	return current, nil
+}
+
+// write replaces the lease file atomically through a temp file
+func (l *Lease) write(r record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	tmp := l.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.Path)
+}
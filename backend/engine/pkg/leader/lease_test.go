@@ -0,0 +1,78 @@
+package leader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaseIsExclusiveUntilItExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader", "lease.json")
+	primary := NewLease(path, "primary", 50*time.Millisecond)
+	standby := NewLease(path, "standby", 50*time.Millisecond)
+
+	if ok, err := primary.Acquire(); !ok || err != nil {
+		t.Fatalf("primary: %v, %v", ok, err)
+	}
+	if ok, err := standby.Acquire(); ok || err != nil {
+		t.Fatalf("standby took a live lease: %v, %v", ok, err)
+	}
+
+	// Renewing pushes the expiry out
+	_, first, _ := primary.Current()
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := primary.Acquire(); !ok {
+		t.Fatal("holder could not renew")
+	}
+	_, renewed, _ := primary.Current()
+	if !renewed.After(first) {
+		t.Errorf("renewal kept expiry %v", first)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := standby.Acquire(); ok {
+		t.Fatal("standby took a renewed lease")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := standby.Acquire(); !ok {
+		t.Fatal("standby could not take an expired lease")
+	}
+	if holder, _, _ := primary.Current(); holder != "standby" {
+		t.Errorf("holder %q, want standby", holder)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	primary := NewLease(path, "primary", time.Hour)
+	standby := NewLease(path, "standby", time.Hour)
+	if ok, _ := primary.Acquire(); !ok {
+		t.Fatal("primary could not acquire")
+	}
+
+	// Only the holder can give the lease up
+	if err := standby.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if holder, _, _ := standby.Current(); holder != "primary" {
+		t.Fatalf("holder %q after a release by someone else", holder)
+	}
+
+	if err := primary.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := standby.Acquire(); !ok {
+		t.Error("standby could not take a released lease at once")
+	}
+}
+
+func TestTornLeaseFileCountsAsFree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	if err := os.WriteFile(path, []byte(`{"holder":"primary","exp`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := NewLease(path, "standby", time.Hour).Acquire(); !ok || err != nil {
+		t.Errorf("acquire over a torn file: %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,55 @@
+// dummyengine/pkg/lmsr/lmsr.go
+package lmsr
+
+import "math"
+
+// The logarithmic market scoring rule prices an event's outcomes from q, the
+// shares of each outcome the market maker has sold, through the cost function
+//
+//	C(q) = b * ln(sum_i exp(q_i / b))
+//
+// Buying shares moves q and costs the difference in C. Amounts are in
+// payouts: one winning share is worth 1. The liquidity b, in shares, sets how
+// far a trade moves prices and bounds the maker's loss at b * ln(outcomes).
+
+// Cost returns C(q)
+func Cost(b float64, q []float64) float64 {
+	// log-sum-exp around the largest term, so large q/b do not overflow
+	top := math.Inf(-1)
+	for _, qi := range q {
+		top = math.Max(top, qi/b)
+	}
+	sum := 0.0
+	for _, qi := range q {
+		sum += math.Exp(qi/b - top)
+	}
+	return b * (top + math.Log(sum))
+}
+
+// Price returns the marginal price of outcome i, its implied probability
+func Price(b float64, q []float64, i int) float64 {
+	return math.Exp((q[i] - Cost(b, q)) / b)
+}
+
+// Buy returns what buying qty shares of outcome i from the maker costs
+func Buy(b float64, q []float64, i int, qty float64) float64 {
+	return Cost(b, shift(q, i, qty)) - Cost(b, q)
+}
+
+// Sell returns what selling qty shares of outcome i to the maker pays
+func Sell(b float64, q []float64, i int, qty float64) float64 {
+	return Cost(b, q) - Cost(b, shift(q, i, -qty))
+}
+
+// MaxLoss returns the most a maker with liquidity b can lose over an event
+// with the given number of outcomes
+func MaxLoss(b float64, outcomes int) float64 {
+	return b * math.Log(float64(outcomes))
+}
+
+// shift returns a copy of q with qty added to outcome i
+func shift(q []float64, i int, qty float64) []float64 {
+	moved := append([]float64(nil), q...)
+	moved[i] += qty
+	return moved
+}
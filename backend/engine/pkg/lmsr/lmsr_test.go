@@ -0,0 +1,65 @@
+package lmsr
+
+import (
+	"math"
+	"testing"
+)
+
+const eps = 1e-9
+
+func TestPricesAreProbabilities(t *testing.T) {
+	for _, q := range [][]float64{{0, 0, 0}, {5, -3, 20}, {-40, 0}} {
+		sum := 0.0
+		for i := range q {
+			p := Price(10, q, i)
+			if p <= 0 || p >= 1 {
+				t.Errorf("price of %d at %v is %v", i, q, p)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > eps {
+			t.Errorf("prices at %v sum to %v", q, sum)
+		}
+	}
+	if p := Price(10, []float64{0, 0, 0, 0}, 2); math.Abs(p-0.25) > eps {
+		t.Errorf("an untouched market prices each of 4 outcomes at %v", p)
+	}
+}
+
+func TestSellingBackWhatWasBoughtIsFree(t *testing.T) {
+	q := []float64{3, -7}
+	bought := Buy(20, q, 0, 5)
+	if back := Sell(20, shift(q, 0, 5), 0, 5); math.Abs(bought-back) > eps {
+		t.Errorf("bought for %v, sold back for %v", bought, back)
+	}
+
+	// Each extra share costs more than the last, and less than a payout
+	if first, second := Buy(20, q, 0, 1), Buy(20, shift(q, 0, 1), 0, 1); !(first < second && second < 1) {
+		t.Errorf("consecutive shares cost %v then %v", first, second)
+	}
+}
+
+func TestLossIsBounded(t *testing.T) {
+	// Sell ever more of one outcome; if it wins the maker pays out every share
+	b := 10.0
+	q := []float64{0, 0, 0}
+	for _, shares := range []float64{1, 10, 100, 1000} {
+		loss := shares - Buy(b, q, 1, shares)
+		if loss > MaxLoss(b, 3)+eps {
+			t.Errorf("selling %v shares loses %v, over the bound %v", shares, loss, MaxLoss(b, 3))
+		}
+	}
+	if loss := 1000 - Buy(b, q, 1, 1000); math.Abs(loss-MaxLoss(b, 3)) > 1e-6 {
+		t.Errorf("a runaway outcome loses %v, want close to %v", loss, MaxLoss(b, 3))
+	}
+}
+
+func TestCostDoesNotOverflow(t *testing.T) {
+	c := Cost(1, []float64{1e6, 0})
+	if math.IsInf(c, 0) || math.IsNaN(c) || math.Abs(c-1e6) > eps {
+		t.Errorf("cost %v, want about 1e6", c)
+	}
+	if p := Price(1, []float64{1e6, 0}, 1); math.IsNaN(p) || p > eps {
+		t.Errorf("price %v", p)
+	}
+}
@@ -0,0 +1,99 @@
+// dummyengine/pkg/matching/matching.go
+package matching
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"fmt"
+	"strings"
+)
+
+// Policy names accepted at CreateEvent
+const (
+	FIFO    = "FIFO"
+	ProRata = "PRO_RATA"
+)
+
+// MatchingPolicy shares an incoming quantity among the resting orders of one
+// price level. Allocate returns one entry per order; each entry is at most the
+// order's visible quantity and the entries sum to at most quantity.
+type MatchingPolicy interface {
+	Name() string
+	Allocate(orders []*pricelevel.Order, quantity int) []int
+}
+
+// NewPolicy builds a policy by name; an empty name means FIFO
+func NewPolicy(name string, minAllocation int) (MatchingPolicy, error) {
+	switch strings.ToUpper(name) {
+	case "", FIFO:
+		return FIFOPolicy{}, nil
+	case ProRata:
+		if minAllocation < 0 {
+			return nil, fmt.Errorf("invalid minimum allocation %d", minAllocation)
+		}
+		return ProRataPolicy{MinAllocation: minAllocation}, nil
+	}
+	return nil, fmt.Errorf("unknown matching policy %q", name)
+}
+
+// FIFOPolicy is price-time priority: the oldest order at the level fills first
+type FIFOPolicy struct{}
+
+func (FIFOPolicy) Name() string { return FIFO }
+
+func (FIFOPolicy) Allocate(orders []*pricelevel.Order, quantity int) []int {
+	allocations := make([]int, len(orders))
+	fillInOrder(orders, allocations, quantity)
+	return allocations
+}
+
+// ProRataPolicy shares the incoming quantity in proportion to visible size.
+// Shares below MinAllocation are dropped, and the rounding remainder goes out
+// in time priority so the whole quantity is always allocated when possible.
+type ProRataPolicy struct {
+	MinAllocation int
+}
+
+func (ProRataPolicy) Name() string { return ProRata }
+
+func (p ProRataPolicy) Allocate(orders []*pricelevel.Order, quantity int) []int {
+	allocations := make([]int, len(orders))
+
+	total := 0
+	for _, o := range orders {
+		total += o.Quantity
+	}
+	if total == 0 {
+		return allocations
+	}
+	if quantity >= total {
+		for i, o := range orders {
+			allocations[i] = o.Quantity
+		}
+		return allocations
+	}
+
+	allocated := 0
+	for i, o := range orders {
+		share := quantity * o.Quantity / total
+		if share < p.MinAllocation {
+			continue
+		}
+		allocations[i] = share
+		allocated += share
+	}
+
+	fillInOrder(orders, allocations, quantity-allocated)
+	return allocations
+}
+
+// fillInOrder hands out quantity on top of existing allocations, oldest order first
+func fillInOrder(orders []*pricelevel.Order, allocations []int, quantity int) {
+	for i, o := range orders {
+		if quantity == 0 {
+			return
+		}
+		take := min(o.Quantity-allocations[i], quantity)
+		allocations[i] += take
+		quantity -= take
+	}
+}
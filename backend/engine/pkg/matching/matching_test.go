@@ -0,0 +1,85 @@
+package matching
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"reflect"
+	"testing"
+)
+
+// level builds a level's orders from their visible quantities, oldest first
+func level(quantities ...int) []*pricelevel.Order {
+	orders := make([]*pricelevel.Order, len(quantities))
+	for i, qty := range quantities {
+		orders[i] = &pricelevel.Order{Quantity: qty}
+	}
+	return orders
+}
+
+func TestNewPolicy(t *testing.T) {
+	for name, want := range map[string]string{"": FIFO, "fifo": FIFO, "FIFO": FIFO, "pro_rata": ProRata} {
+		policy, err := NewPolicy(name, 0)
+		if err != nil || policy.Name() != want {
+			t.Errorf("NewPolicy(%q) = %v, %v, want %s", name, policy, err, want)
+		}
+	}
+
+	if policy, _ := NewPolicy(ProRata, 5); policy != (ProRataPolicy{MinAllocation: 5}) {
+		t.Errorf("minimum allocation not carried: %#v", policy)
+	}
+	// FIFO has no use for a minimum allocation and ignores a bad one
+	if _, err := NewPolicy(FIFO, -1); err != nil {
+		t.Errorf("FIFO refused a minimum allocation: %v", err)
+	}
+	if _, err := NewPolicy(ProRata, -1); err == nil {
+		t.Error("negative minimum allocation accepted")
+	}
+	if _, err := NewPolicy("LIFO", 0); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
+
+func TestFIFO(t *testing.T) {
+	if got := (FIFOPolicy{}).Allocate(level(5, 5, 5), 7); !reflect.DeepEqual(got, []int{5, 2, 0}) {
+		t.Errorf("allocations %v, want the oldest filled first", got)
+	}
+	if got := (FIFOPolicy{}).Allocate(level(2, 3), 10); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("allocations %v, want every order capped at its size", got)
+	}
+}
+
+func TestProRata(t *testing.T) {
+	tests := []struct {
+		name     string
+		min      int
+		orders   []int
+		quantity int
+		want     []int
+	}{
+		{"in proportion to size", 0, []int{10, 30}, 20, []int{5, 15}},
+		// 10/3 each leaves 1 over, which the oldest order gets
+		{"rounding remainder by time", 0, []int{10, 10, 10}, 10, []int{4, 3, 3}},
+		// The small share is dropped, yet the remainder still reaches it
+		// first since it is the older order
+		{"dropped share back through the remainder", 3, []int{10, 90}, 20, []int{2, 18}},
+		{"dropped share goes to the older order", 3, []int{90, 10}, 20, []int{20, 0}},
+		{"more than the level", 0, []int{2, 3}, 10, []int{2, 3}},
+		{"nothing showing", 0, []int{0, 0}, 5, []int{0, 0}},
+	}
+	for _, tt := range tests {
+		got := ProRataPolicy{MinAllocation: tt.min}.Allocate(level(tt.orders...), tt.quantity)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: allocations %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllocateIgnoresHiddenQuantity(t *testing.T) {
+	orders := level(3, 3)
+	orders[0].Hidden = 97
+
+	for _, policy := range []MatchingPolicy{FIFOPolicy{}, ProRataPolicy{}} {
+		if got := policy.Allocate(orders, 4); got[0] > 3 || got[0]+got[1] != 4 {
+			t.Errorf("%s allocated %v past what is shown", policy.Name(), got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// dummyengine/pkg/orderbook/allornone.go
+package orderbook
+
+import (
+	"container/heap"
+	"dummyengine/pkg/pricelevel"
+	"errors"
+)
+
+// ErrMinQuantity cancels an incoming order whose minimum quantity cannot be
+// filled straight away, or a crossing all-or-none order that cannot fill whole
+var ErrMinQuantity = errors.New("not enough crossing depth for the minimum quantity")
+
+// crossing returns the opposite side's levels that price crosses, best first
+func (ob *OrderBook) crossing(price int, side string) []*pricelevel.PriceLevel {
+	var levels []*pricelevel.PriceLevel
+	ob.opposite(side, func(level *pricelevel.PriceLevel) bool {
+		if (side == "BUY" && level.Price > price) || (side == "SELL" && level.Price < price) {
+			return false
+		}
+		levels = append(levels, level)
+		return true
+	})
+	return levels
+}
+
+// crosses reports whether an order at price on side would meet any level of
+// the opposite side
+func (ob *OrderBook) crosses(price int, side string) bool {
+	crossed := false
+	ob.opposite(side, func(level *pricelevel.PriceLevel) bool {
+		crossed = (side == "BUY" && level.Price <= price) || (side == "SELL" && level.Price >= price)
+		return false
+	})
+	return crossed
+}
+
+// opposite visits the levels an incoming order on side would meet, best
+// first, until visit returns false. It walks the heap from the top, always
+// taking the best level whose parent was visited, so it touches only the
+// levels it visits and their children and leaves the heap as it is.
+func (ob *OrderBook) opposite(side string, visit func(*pricelevel.PriceLevel) bool) {
+	levels, better := ob.SellOrders.CommonHeap, func(a, b int) bool { return a < b }
+	if side == "SELL" {
+		levels, better = ob.BuyOrders.CommonHeap, func(a, b int) bool { return a > b }
+	}
+	if len(levels) == 0 {
+		return
+	}
+
+	next := &frontier{levels: levels, better: better, index: []int{0}}
+	for next.Len() > 0 {
+		i := heap.Pop(next).(int)
+		if !visit(levels[i]) {
+			return
+		}
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(levels) {
+				heap.Push(next, child)
+			}
+		}
+	}
+}
+
+// frontier is a heap of indexes into a side's levels, best price on top
+type frontier struct {
+	levels []*pricelevel.PriceLevel
+	better func(a, b int) bool
+	index  []int
+}
+
+func (f *frontier) Len() int { return len(f.index) }
+func (f *frontier) Less(i, j int) bool {
+	return f.better(f.levels[f.index[i]].Price, f.levels[f.index[j]].Price)
+}
+func (f *frontier) Swap(i, j int)      { f.index[i], f.index[j] = f.index[j], f.index[i] }
+func (f *frontier) Push(x interface{}) { f.index = append(f.index, x.(int)) }
+func (f *frontier) Pop() interface{} {
+	n := len(f.index)
+	i := f.index[n-1]
+	f.index = f.index[:n-1]
+	return i
+}
+
+// allocate shares quantity among a level's orders by the book's policy.
+// All-or-none orders only take part while they can be filled completely; one
+// that would get a partial share is left out and the rest shared again.
+func (ob *OrderBook) allocate(orders []*pricelevel.Order, quantity int) []int {
+	aon := false
+	for _, o := range orders {
+		aon = aon || o.AllOrNone
+	}
+	if !aon {
+		return ob.Policy.Allocate(orders, quantity)
+	}
+
+	excluded := make([]bool, len(orders))
+	for {
+		var eligible []*pricelevel.Order
+		var index []int
+		for i, o := range orders {
+			if o.AllOrNone && (excluded[i] || o.Quantity > quantity) {
+				continue
+			}
+			eligible = append(eligible, o)
+			index = append(index, i)
+		}
+
+		allocations := make([]int, len(orders))
+		partial := false
+		for j, qty := range ob.Policy.Allocate(eligible, quantity) {
+			i := index[j]
+			if orders[i].AllOrNone && qty > 0 && qty < orders[i].Quantity {
+				excluded[i] = true
+				partial = true
+			}
+			allocations[i] = qty
+		}
+		if !partial {
+			return allocations
+		}
+	}
+}
+
+// fillable returns how much of an incoming order would fill right now, by
+// matching it against a copy of the crossing levels
+func (ob *OrderBook) fillable(incoming *pricelevel.Order, side string) int {
+	probe := *incoming
+	for _, level := range ob.crossing(incoming.Price, side) {
+		if probe.Quantity == 0 {
+			break
+		}
+
+		copied := &pricelevel.PriceLevel{Price: level.Price, Quantity: level.Quantity}
+		for _, order := range level.Orders {
+			o := *order
+			copied.Orders = append(copied.Orders, &o)
+		}
+		ob.matchLevel(&probe, copied, func(resting *pricelevel.Order, qty int) {
+			probe.Quantity -= qty
+			resting.Quantity -= qty
+			copied.Quantity -= qty
+		})
+	}
+	return incoming.Quantity - probe.Quantity
+}
@@ -0,0 +1,207 @@
+package orderbook
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func aonSell(id int64, price, quantity int) OrderRequest {
+	req := sell(id, price, quantity, 0)
+	req.AllOrNone = true
+	return req
+}
+
+func TestRestingAllOrNoneIsPassedOver(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(aonSell(1, 500, 5))
+	ob.AddOrder(sell(2, 500, 3, 0))
+	ob.AddOrder(sell(3, 510, 2, 0))
+
+	// Too small for the all-or-none order, the buy fills behind it and then
+	// at the next level, leaving it first in line
+	if p := ob.AddOrder(buy(10, 510, 4, 0)); p.Filled != 4 {
+		t.Fatalf("filled %d, want 4", p.Filled)
+	}
+	if got, want := queue(ob.GetTopSellOrder()), [][2]int{{1, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue at 500 %v, want %v", got, want)
+	}
+	if top := ob.GetTopSellOrder(); top.Price != 500 {
+		t.Errorf("best ask %d, want 500", top.Price)
+	}
+
+	// A buy large enough takes it whole
+	if p := ob.AddOrder(buy(11, 500, 5, 0)); p.Filled != 5 {
+		t.Errorf("filled %d, want the whole 5", p.Filled)
+	}
+}
+
+func TestProRataResharesAroundAllOrNone(t *testing.T) {
+	ob := newTestBook(t, Params{MatchingPolicy: "PRO_RATA"})
+	ob.AddOrder(aonSell(1, 500, 6))
+	ob.AddOrder(sell(2, 500, 6, 0))
+
+	// A 3:3 share would fill the all-or-none order partly, so the plain
+	// order takes the 6 alone
+	if p := ob.AddOrder(buy(10, 500, 6, 0)); p.Filled != 6 {
+		t.Fatalf("filled %d, want 6", p.Filled)
+	}
+	if got, want := queue(ob.GetTopSellOrder()), [][2]int{{1, 6}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue %v, want %v", got, want)
+	}
+}
+
+func TestMinQuantity(t *testing.T) {
+	withMin := func(id int64, price, quantity, min int) OrderRequest {
+		req := buy(id, price, quantity, 0)
+		req.MinQty = min
+		return req
+	}
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 2, 0))
+	ob.AddOrder(aonSell(2, 505, 4)) // counts only for a buy with 4 left after the 2
+	ob.AddOrder(sell(3, 520, 5, 0)) // beyond the limit
+
+	p := ob.AddOrder(withMin(10, 510, 5, 3))
+	if !errors.Is(p.Err, ErrMinQuantity) || !p.Canceled {
+		t.Fatalf("placement %+v, want canceled for the minimum", p)
+	}
+	if top := ob.GetTopSellOrder(); top.Price != 500 || top.Quantity != 2 {
+		t.Errorf("canceled order touched the book: best ask %d x %d", top.Quantity, top.Price)
+	}
+	if ob.GetTopBuyOrder() != nil {
+		t.Error("canceled order rested")
+	}
+
+	// Met on entry, all-or-none depth included, the rest then rests as a plain order
+	p = ob.AddOrder(withMin(11, 510, 8, 6))
+	if p.Err != nil || p.Filled != 6 {
+		t.Fatalf("placement %+v, want 6 filled", p)
+	}
+	if top := ob.GetTopBuyOrder(); top == nil || top.Quantity != 2 || top.Orders[0].AllOrNone {
+		t.Errorf("resting remainder %v, want a plain 2", top)
+	}
+}
+
+func TestIncomingAllOrNoneFillsWhole(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 2, 0))
+	ob.AddOrder(sell(2, 505, 3, 0))
+
+	req := buy(10, 505, 5, 0)
+	req.AllOrNone = true
+	if p := ob.AddOrder(req); p.Err != nil || p.Filled != 5 {
+		t.Errorf("placement %+v, want 5 filled across both levels", p)
+	}
+	if ob.GetTopSellOrder() != nil || ob.GetTopBuyOrder() != nil {
+		t.Error("book not empty")
+	}
+}
+
+func TestIncomingAllOrNoneNeverRestsCrossed(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 2, 0))
+	ob.AddOrder(sell(2, 510, 2, 0))
+
+	aonBuy := func(id int64, price, quantity int) OrderRequest {
+		req := buy(id, price, quantity, 0)
+		req.AllOrNone = true
+		return req
+	}
+
+	// 4 cross at 510, not 5: resting at 510 would sit above the asks
+	p := ob.AddOrder(aonBuy(10, 510, 5))
+	if !errors.Is(p.Err, ErrMinQuantity) || !p.Canceled {
+		t.Fatalf("placement %+v, want canceled", p)
+	}
+	if ob.GetTopBuyOrder() != nil {
+		t.Fatal("crossing all-or-none order rested")
+	}
+	if top := ob.GetTopSellOrder(); top.Price != 500 || top.Quantity != 2 {
+		t.Errorf("best ask %d x %d, want the asks untouched", top.Quantity, top.Price)
+	}
+
+	// Below the asks it rests whole, and the book stays uncrossed
+	if p := ob.AddOrder(aonBuy(11, 490, 5)); p.Err != nil || p.Filled != 0 {
+		t.Fatalf("placement %+v, want it resting", p)
+	}
+	if bid, ask := ob.GetTopBuyOrder(), ob.GetTopSellOrder(); bid.Price >= ask.Price {
+		t.Errorf("book crossed: bid %d, ask %d", bid.Price, ask.Price)
+	}
+}
+
+func TestModifyKeepsAllOrNone(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(aonSell(1, 500, 5))
+	if _, err := ob.ModifyOrder(ModifyRequest{OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 490, Quantity: 5}, func(OrderRequest) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if o := ob.GetTopSellOrder().Orders[0]; o.Price != 490 || !o.AllOrNone {
+		t.Errorf("replacement %+v, want all-or-none at 490", o)
+	}
+}
+
+func TestCrossing(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	for i, price := range []int{700, 500, 900, 600, 800, 550, 650} {
+		ob.AddOrder(OrderRequest{Side: "SELL", OrderID: big.NewInt(int64(i + 1)), UserID: big.NewInt(1), Price: price, Quantity: 1})
+	}
+	tests := []struct {
+		name  string
+		price int
+		want  []int
+	}{
+		{"nothing crosses", 450, nil},
+		{"top only", 500, []int{500}},
+		{"part of the book", 650, []int{500, 550, 600, 650}},
+		{"whole book", 950, []int{500, 550, 600, 650, 700, 800, 900}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, level := range ob.crossing(tt.price, "BUY") {
+				got = append(got, level.Price)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("crossing levels %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("crossing levels %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPostOnlyPastAllOrNone(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  int
+		reprice   bool
+		wantErr   error
+		wantPrice int
+	}{
+		{"too small for the all-or-none ask", 5, false, nil, 600},
+		{"large enough to fill it", 10, false, ErrWouldTake, 0},
+		{"reprices behind the next ask", 5, true, nil, 600},
+		{"reprices behind the all-or-none ask", 10, true, nil, 550 - DefaultTickSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := newTestBook(t, Params{})
+			ob.AddOrder(OrderRequest{Side: "SELL", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 550, Quantity: 10, AllOrNone: true})
+			ob.AddOrder(OrderRequest{Side: "SELL", OrderID: big.NewInt(2), UserID: big.NewInt(1), Price: 700, Quantity: 10})
+
+			placement := ob.AddOrder(OrderRequest{Side: "BUY", OrderID: big.NewInt(3), UserID: big.NewInt(2), Price: 600, Quantity: tt.quantity, PostOnly: true, PostOnlyReprice: tt.reprice})
+
+			if !errors.Is(placement.Err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", placement.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (placement.Price != tt.wantPrice || placement.Filled != 0) {
+				t.Errorf("rests at %d with %d filled, want %d unfilled", placement.Price, placement.Filled, tt.wantPrice)
+			}
+		})
+	}
+}
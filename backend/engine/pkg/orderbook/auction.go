@@ -0,0 +1,225 @@
+// dummyengine/pkg/orderbook/auction.go
+package orderbook
+
+import (
+	"container/heap"
+	"dummyengine/pkg/fees"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/pricelevel"
+	"dummyengine/pkg/wire"
+	"errors"
+)
+
+// ErrAuctionOrder refuses order types that only make sense in continuous trading
+var ErrAuctionOrder = errors.New("post-only, minimum quantity and all-or-none orders are not accepted during the opening auction")
+
+// Indicative is what an uncross would do with the book as it stands
+type Indicative struct {
+	Price   int // 0 while nothing crosses
+	Volume  int
+	Surplus int // unmatched at Price, positive on the buy side
+}
+
+// Indicative finds the single clearing price that executes the most volume.
+// Ties go to the smallest surplus, then to the side under pressure: the
+// highest price while buyers are left over, the lowest while sellers are,
+// otherwise the middle of the tied range, rounded down to a tick.
+func (ob *OrderBook) Indicative() Indicative {
+	var candidates []Indicative
+	best := Indicative{}
+	for _, price := range ob.auctionPrices() {
+		demand, supply := ob.interest(price)
+		c := Indicative{Price: price, Volume: min(demand, supply), Surplus: demand - supply}
+		if c.Volume == 0 {
+			continue
+		}
+		switch {
+		case len(candidates) == 0, c.Volume > best.Volume, c.Volume == best.Volume && abs(c.Surplus) < abs(best.Surplus):
+			best = c
+			candidates = []Indicative{c}
+		case c.Volume == best.Volume && abs(c.Surplus) == abs(best.Surplus):
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) <= 1 {
+		return best
+	}
+
+	low, high := candidates[0], candidates[0]
+	buyers, sellers := true, true
+	for _, c := range candidates {
+		if c.Price < low.Price {
+			low = c
+		}
+		if c.Price > high.Price {
+			high = c
+		}
+		buyers = buyers && c.Surplus > 0
+		sellers = sellers && c.Surplus < 0
+	}
+	switch {
+	case buyers:
+		return high
+	case sellers:
+		return low
+	}
+	tick := ob.Params.TickSize
+	price := (low.Price + high.Price) / 2 / tick * tick
+	demand, supply := ob.interest(price)
+	return Indicative{Price: price, Volume: min(demand, supply), Surplus: demand - supply}
+}
+
+// Uncross ends the opening auction: every crossing order executes at the
+// clearing price, in price-time priority and by the book's policy at the
+// marginal level, and continuous trading starts with what is left
+func (ob *OrderBook) Uncross() Indicative {
+	result := ob.Indicative()
+	if result.Volume > 0 {
+		buys := ob.auctionFills(ob.crossing(result.Price, "SELL"), result.Volume)
+		sells := ob.auctionFills(ob.crossing(result.Price, "BUY"), result.Volume)
+
+		for i, j := 0, 0; i < len(buys) && j < len(sells); {
+			qty := min(buys[i].qty, sells[j].qty)
+			ob.cross(buys[i], sells[j], result.Price, qty)
+			buys[i].qty -= qty
+			sells[j].qty -= qty
+			if buys[i].qty == 0 {
+				i++
+			}
+			if sells[j].qty == 0 {
+				j++
+			}
+		}
+
+		ob.tidy(ob.crossing(result.Price, "SELL"), ob.BuyOrders)
+		ob.tidy(ob.crossing(result.Price, "BUY"), ob.SellOrders)
+	}
+
+	ob.Auction = false
+	logger.Info("🔔 Opening auction uncrossed", "event_id", ob.EventID, "outcome", ob.Outcome, "price", result.Price, "volume", result.Volume, "surplus", result.Surplus)
+	ob.publishAuction(result, true)
+	if result.Volume > 0 {
+		ob.publishPriceUpdate(result.Price)
+	}
+	ob.publishOrderBook()
+	return result
+}
+
+// auctionPrices lists every price resting on either side
+func (ob *OrderBook) auctionPrices() []int {
+	var prices []int
+	for _, level := range ob.BuyOrders.CommonHeap {
+		prices = append(prices, level.Price)
+	}
+	for _, level := range ob.SellOrders.CommonHeap {
+		prices = append(prices, level.Price)
+	}
+	return prices
+}
+
+// interest sums the quantity willing to buy and to sell at price, hidden included
+func (ob *OrderBook) interest(price int) (demand, supply int) {
+	for _, level := range ob.crossing(price, "SELL") {
+		for _, order := range level.Orders {
+			demand += order.Remaining()
+		}
+	}
+	for _, level := range ob.crossing(price, "BUY") {
+		for _, order := range level.Orders {
+			supply += order.Remaining()
+		}
+	}
+	return demand, supply
+}
+
+// auctionFill is what one resting order executes at the uncross
+type auctionFill struct {
+	order *pricelevel.Order
+	level *pricelevel.PriceLevel
+	qty   int
+}
+
+// auctionFills shares volume among one side's crossing levels, best first.
+// Levels ahead of the marginal one execute in full; the marginal level is
+// shared by the book's policy.
+func (ob *OrderBook) auctionFills(levels []*pricelevel.PriceLevel, volume int) []auctionFill {
+	var fills []auctionFill
+	for _, level := range levels {
+		if volume == 0 {
+			break
+		}
+
+		// Shares are judged on whole orders, reserve included
+		whole := make([]*pricelevel.Order, len(level.Orders))
+		total := 0
+		for i, order := range level.Orders {
+			o := *order
+			o.Quantity, o.Hidden = order.Remaining(), 0
+			whole[i] = &o
+			total += o.Quantity
+		}
+
+		allocations := make([]int, len(whole))
+		if total <= volume {
+			for i, o := range whole {
+				allocations[i] = o.Quantity
+			}
+		} else {
+			allocations = ob.allocate(whole, volume)
+		}
+		for i, qty := range allocations {
+			if qty > 0 {
+				fills = append(fills, auctionFill{order: level.Orders[i], level: level, qty: qty})
+			}
+		}
+		if total > volume {
+			break
+		}
+		volume -= total
+	}
+	return fills
+}
+
+// cross executes qty between two resting orders at the clearing price.
+// Neither side took liquidity, so both pay the maker fee; this is intended,
+// as no order in the call phase could trade on arrival.
+func (ob *OrderBook) cross(buy, sell auctionFill, price, qty int) {
+	fee := ob.Params.Fees.Maker(price, qty)
+	ob.trade(buy.order, sell.order, price, qty, fee, fee, fees.Auction, fees.Auction)
+
+	for _, f := range []struct {
+		auctionFill
+		side string
+	}{{buy, "BUY"}, {sell, "SELL"}} {
+		fromVisible := min(qty, f.order.Quantity)
+		f.order.Quantity -= fromVisible
+		f.order.Hidden -= qty - fromVisible
+		f.level.Quantity -= fromVisible
+		ob.trackFill(f.order, f.side, qty, true)
+	}
+}
+
+// tidy sweeps the levels touched by the uncross and drops the emptied ones
+func (ob *OrderBook) tidy(levels []*pricelevel.PriceLevel, side heap.Interface) {
+	for _, level := range levels {
+		sweep(level)
+		if len(level.Orders) == 0 {
+			heap.Remove(side, level.Index)
+		} else {
+			heap.Fix(side, level.Index)
+		}
+	}
+}
+
+// publishAuction publishes the indicative or final uncross
+func (ob *OrderBook) publishAuction(result Indicative, uncrossed bool) {
+	ob.PublishMessage("price_exchange", "auction.update", &wire.AuctionUpdate{
+		EventID:   ob.EventID,
+		Price:     result.Price,
+		Volume:    result.Volume,
+		Surplus:   result.Surplus,
+		Uncrossed: uncrossed,
+		Timestamp: ob.now().Unix(),
+		Outcome:   ob.Outcome,
+	})
+}
@@ -0,0 +1,181 @@
+package orderbook
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// trades records each execution as price:quantity
+type trades [][2]int
+
+func (tr *trades) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) {
+	*tr = append(*tr, [2]int{price, quantity})
+}
+
+func auctionBook(t *testing.T) (*OrderBook, *trades) {
+	t.Helper()
+	ob := newTestBook(t, Params{OpeningAuction: true})
+	got := &trades{}
+	ob.Observers = append(ob.Observers, got)
+	return ob, got
+}
+
+func TestNothingMatchesDuringTheCall(t *testing.T) {
+	ob, got := auctionBook(t)
+	ob.AddOrder(sell(1, 490, 5, 0))
+	if p := ob.AddOrder(buy(2, 510, 10, 0)); p.Err != nil || p.Filled != 0 {
+		t.Fatalf("crossing buy %+v, want it resting unfilled", p)
+	}
+	if len(*got) != 0 {
+		t.Errorf("traded %v during the call", *got)
+	}
+	if ob.GetTopBuyOrder().Price != 510 || ob.GetTopSellOrder().Price != 490 {
+		t.Error("the call's crossed orders should both rest")
+	}
+}
+
+func TestIndicativePrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		orders []OrderRequest
+		want   Indicative
+	}{
+		{"nothing crosses", []OrderRequest{buy(1, 480, 5, 0), sell(2, 490, 5, 0)}, Indicative{}},
+		// 8 trade at 500, more than the 4 at 490 or 5 at 510
+		{"most volume", []OrderRequest{buy(1, 510, 5, 0), buy(2, 500, 5, 0), sell(3, 490, 4, 0), sell(4, 500, 4, 0)}, Indicative{Price: 500, Volume: 8, Surplus: 2}},
+		// 490 and 510 both trade 5 with nothing left over: the middle
+		{"balanced tie", []OrderRequest{buy(1, 510, 5, 0), sell(2, 490, 5, 0)}, Indicative{Price: 500, Volume: 5}},
+		{"buyers left over", []OrderRequest{buy(1, 510, 6, 0), sell(2, 490, 5, 0)}, Indicative{Price: 510, Volume: 5, Surplus: 1}},
+		{"sellers left over", []OrderRequest{buy(1, 510, 5, 0), sell(2, 490, 6, 0)}, Indicative{Price: 490, Volume: 5, Surplus: -1}},
+		// The reserve behind the slice counts
+		{"iceberg reserve", []OrderRequest{sell(1, 490, 6, 2), buy(2, 500, 5, 0)}, Indicative{Price: 490, Volume: 5, Surplus: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, _ := auctionBook(t)
+			for _, req := range tt.orders {
+				ob.AddOrder(req)
+			}
+			if got := ob.Indicative(); got != tt.want {
+				t.Errorf("indicative %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUncross(t *testing.T) {
+	ob, got := auctionBook(t)
+	ob.AddOrder(buy(1, 510, 5, 0))
+	ob.AddOrder(buy(2, 500, 5, 0))
+	ob.AddOrder(sell(3, 490, 4, 0))
+	ob.AddOrder(sell(4, 500, 4, 0))
+	ob.AddOrder(sell(5, 520, 1, 0))
+
+	result := ob.Uncross()
+	if result.Price != 500 || result.Volume != 8 {
+		t.Fatalf("uncrossed %+v, want 8 at 500", result)
+	}
+	volume := 0
+	for _, trade := range *got {
+		if trade[0] != 500 {
+			t.Errorf("traded at %d, want every trade at the clearing price", trade[0])
+		}
+		volume += trade[1]
+	}
+	if volume != 8 {
+		t.Errorf("traded %d, want 8", volume)
+	}
+
+	// The buy at 510 fills whole; the marginal buy at 500 keeps what is left,
+	// and the sell above the price is untouched
+	if ob.Auction {
+		t.Error("still in the auction")
+	}
+	if got, want := queue(ob.GetTopBuyOrder()), [][2]int{{2, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bids %v, want %v", got, want)
+	}
+	if got, want := queue(ob.GetTopSellOrder()), [][2]int{{5, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("asks %v, want %v", got, want)
+	}
+	if u := ob.Usage(big.NewInt(2)); u.OpenOrders != 1 || u.NetPosition != 8 {
+		t.Errorf("buyer usage %+v, want 1 open order and a position of 8", u)
+	}
+
+	// Continuous trading matches on arrival
+	if p := ob.AddOrder(sell(6, 500, 2, 0)); p.Filled != 2 {
+		t.Errorf("sell after the uncross filled %d, want 2", p.Filled)
+	}
+}
+
+func TestUncrossWithNothingCrossing(t *testing.T) {
+	ob, got := auctionBook(t)
+	ob.AddOrder(buy(1, 480, 5, 0))
+	ob.AddOrder(sell(2, 490, 5, 0))
+	if result := ob.Uncross(); result != (Indicative{}) || len(*got) != 0 {
+		t.Errorf("uncrossed %+v with trades %v, want nothing", result, *got)
+	}
+	if ob.Auction || ob.GetTopBuyOrder() == nil || ob.GetTopSellOrder() == nil {
+		t.Error("the book should open with both orders resting")
+	}
+}
+
+func TestUncrossSharesTheMarginalLevelByPolicy(t *testing.T) {
+	ob := newTestBook(t, Params{OpeningAuction: true, MatchingPolicy: "PRO_RATA"})
+	ob.AddOrder(sell(1, 500, 2, 0))
+	ob.AddOrder(sell(2, 500, 6, 0))
+	ob.AddOrder(buy(3, 500, 4, 0))
+
+	ob.Uncross()
+	// 4 of 8 at the level: pro rata gives each seller half its size
+	if got, want := queue(ob.GetTopSellOrder()), [][2]int{{1, 1}, {2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("asks %v, want %v", got, want)
+	}
+}
+
+func TestAuctionRefusesContinuousOrderTypes(t *testing.T) {
+	ob, _ := auctionBook(t)
+	aon := buy(2, 500, 5, 0)
+	aon.AllOrNone = true
+	minQty := buy(3, 500, 5, 0)
+	minQty.MinQty = 2
+	for _, req := range []OrderRequest{postOnly("BUY", 1, 500, false), aon, minQty} {
+		if p := ob.AddOrder(req); !errors.Is(p.Err, ErrAuctionOrder) {
+			t.Errorf("order %s: %v, want ErrAuctionOrder", req.OrderID, p.Err)
+		}
+	}
+	if ob.GetTopBuyOrder() != nil {
+		t.Error("a refused order rests")
+	}
+
+	ob.Uncross()
+	if p := ob.AddOrder(postOnly("BUY", 4, 500, false)); p.Err != nil {
+		t.Errorf("post-only after the uncross: %v", p.Err)
+	}
+}
+
+func TestSnapshotKeepsTheAuction(t *testing.T) {
+	ob, _ := auctionBook(t)
+	ob.AddOrder(buy(1, 510, 5, 0))
+	ob.AddOrder(sell(2, 490, 5, 0))
+
+	restored := newTestBook(t, ob.Params)
+	restored.Auction = false
+	restored.Restore(ob.Snapshot())
+	if !restored.Auction {
+		t.Fatal("restored book left the auction")
+	}
+	if p := restored.AddOrder(sell(3, 500, 1, 0)); p.Filled != 0 {
+		t.Errorf("restored book matched %d during the call", p.Filled)
+	}
+}
+
+func TestAuctionEndNeedsAnAuction(t *testing.T) {
+	for _, params := range []Params{{AuctionEnd: 100}, {OpeningAuction: true, AuctionEnd: -1}} {
+		if _, err := NewOrderBook(nil, big.NewInt(1), "", "", "", params); err == nil {
+			t.Errorf("%+v accepted", params)
+		}
+	}
+}
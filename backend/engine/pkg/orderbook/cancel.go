@@ -0,0 +1,239 @@
+// dummyengine/pkg/orderbook/cancel.go
+package orderbook
+
+import (
+	"container/heap"
+	"dummyengine/pkg/pricelevel"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ErrOrderNotFound is returned when no resting order has the given ID
+var ErrOrderNotFound = errors.New("order not found")
+
+// ModifyRequest amends a resting order. Quantity is the new total open
+// quantity; a zero Price keeps the current one.
+type ModifyRequest struct {
+	OrderID  *big.Int
+	UserID   *big.Int
+	Price    int
+	Quantity int
+	Tier     string // owner's rate limit tier, used by the exchange only
+}
+
+// ModifyResult describes what a modify did
+type ModifyResult struct {
+	Side     string
+	Price    int
+	Quantity int
+	Filled   int  // filled on re-entry at a new price or larger size
+	Replaced bool // false when reduced in place, keeping time priority
+}
+
+// FindOrder returns a resting order and its side
+func (ob *OrderBook) FindOrder(orderID *big.Int) (*pricelevel.Order, string, bool) {
+	level, i, side := ob.locate(orderID)
+	if level == nil {
+		return nil, "", false
+	}
+	return level.Orders[i], side, true
+}
+
+// CancelOrder removes a resting order of userID, publishes the new depth and
+// returns the removed order with its side
+func (ob *OrderBook) CancelOrder(orderID, userID *big.Int) (*pricelevel.Order, string, error) {
+	level, i, side := ob.locate(orderID)
+	if level == nil {
+		return nil, "", ErrOrderNotFound
+	}
+	order := level.Orders[i]
+	if order.UserID.Cmp(userID) != 0 {
+		return nil, "", fmt.Errorf("order %s belongs to another user", orderID)
+	}
+
+	ob.unrest(level, i, side)
+	ob.trackCancel(order, side)
+	ob.publishOrderBook()
+	return order, side, nil
+}
+
+// CanceledOrder is a resting order removed by CancelAll, with its side
+type CanceledOrder struct {
+	Order *pricelevel.Order
+	Side  string
+}
+
+// CancelAll removes every resting order of userID on side in one pass; a nil
+// userID or empty side matches any. It publishes the new depth once and
+// returns the removed orders, buys before sells and best price first.
+func (ob *OrderBook) CancelAll(userID *big.Int, side string) []CanceledOrder {
+	canceled := ob.cancelAll(userID, side)
+	if len(canceled) > 0 {
+		ob.publishOrderBook()
+	}
+	return canceled
+}
+
+// cancelAll is CancelAll without publishing
+func (ob *OrderBook) cancelAll(userID *big.Int, side string) []CanceledOrder {
+	var canceled []CanceledOrder
+	for _, s := range []string{"BUY", "SELL"} {
+		if side != "" && side != s {
+			continue
+		}
+
+		var levels heap.Interface = ob.SellOrders
+		sorted := append([]*pricelevel.PriceLevel(nil), ob.SellOrders.CommonHeap...)
+		if s == "BUY" {
+			levels = ob.BuyOrders
+			sorted = append([]*pricelevel.PriceLevel(nil), ob.BuyOrders.CommonHeap...)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if s == "BUY" {
+				return sorted[i].Price > sorted[j].Price
+			}
+			return sorted[i].Price < sorted[j].Price
+		})
+
+		for _, level := range sorted {
+			kept := level.Orders[:0]
+			for _, order := range level.Orders {
+				if userID != nil && order.UserID.Cmp(userID) != 0 {
+					kept = append(kept, order)
+					continue
+				}
+				level.Quantity -= order.Quantity
+				ob.trackCancel(order, s)
+				canceled = append(canceled, CanceledOrder{Order: order, Side: s})
+			}
+			level.Orders = kept
+			if len(kept) == 0 {
+				heap.Remove(levels, level.Index)
+			}
+		}
+	}
+	return canceled
+}
+
+// ModifyOrder amends a resting order of userID. Reducing the quantity at the
+// same price happens in place and keeps time priority; any other change
+// cancels and re-enters the order with all its conditions, which may then
+// match. check vets the re-entered order with the old one's usage already
+// released. A re-entry the book refuses, such as a post-only order moved
+// across the spread, leaves the original resting as it was.
+func (ob *OrderBook) ModifyOrder(req ModifyRequest, check func(OrderRequest) error) (ModifyResult, error) {
+	level, i, side := ob.locate(req.OrderID)
+	if level == nil {
+		return ModifyResult{}, ErrOrderNotFound
+	}
+	order := level.Orders[i]
+	if order.UserID.Cmp(req.UserID) != 0 {
+		return ModifyResult{}, fmt.Errorf("order %s belongs to another user", req.OrderID)
+	}
+
+	price := req.Price
+	if price == 0 {
+		price = order.Price
+	}
+	result := ModifyResult{Side: side, Price: price, Quantity: req.Quantity}
+
+	if price == order.Price && req.Quantity <= order.Remaining() {
+		ob.reduce(level, order, side, order.Remaining()-req.Quantity)
+		ob.publishOrderBook()
+		return result, nil
+	}
+
+	replacement := OrderRequest{
+		Side:            side,
+		OrderID:         order.ID,
+		Price:           price,
+		Quantity:        req.Quantity,
+		UserID:          order.UserID,
+		DisplayQuantity: order.Peak,
+		PostOnly:        order.PostOnly,
+		PostOnlyReprice: order.PostOnlyReprice,
+		MinQty:          min(order.MinQty, req.Quantity),
+		AllOrNone:       order.AllOrNone,
+	}
+
+	ob.trackCancel(order, side)
+	if check != nil {
+		if err := check(replacement); err != nil {
+			ob.trackRest(order, side)
+			return ModifyResult{}, err
+		}
+	}
+
+	// A refused re-entry leaves no trace, so the original goes back where it was
+	ob.unrest(level, i, side)
+	placement := ob.AddOrder(replacement)
+	if placement.Err != nil {
+		ob.reinstate(level, i, order, side)
+		ob.trackRest(order, side)
+		return ModifyResult{}, placement.Err
+	}
+	result.Price = placement.Price
+	result.Filled = placement.Filled
+	result.Replaced = true
+	return result, nil
+}
+
+// locate finds a resting order's level, its index in the level and its side
+func (ob *OrderBook) locate(orderID *big.Int) (*pricelevel.PriceLevel, int, string) {
+	for _, level := range ob.BuyOrders.CommonHeap {
+		for i, order := range level.Orders {
+			if order.ID.Cmp(orderID) == 0 {
+				return level, i, "BUY"
+			}
+		}
+	}
+	for _, level := range ob.SellOrders.CommonHeap {
+		for i, order := range level.Orders {
+			if order.ID.Cmp(orderID) == 0 {
+				return level, i, "SELL"
+			}
+		}
+	}
+	return nil, 0, ""
+}
+
+// unrest takes the i-th order out of its level, dropping the level if it empties
+func (ob *OrderBook) unrest(level *pricelevel.PriceLevel, i int, side string) {
+	order := level.Orders[i]
+	level.Orders = append(level.Orders[:i], level.Orders[i+1:]...)
+	level.Quantity -= order.Quantity
+
+	var levels heap.Interface = ob.SellOrders
+	if side == "BUY" {
+		levels = ob.BuyOrders
+	}
+	if len(level.Orders) == 0 {
+		heap.Remove(levels, level.Index)
+	}
+}
+
+// reinstate puts an order taken out by unrest back at index i of its level
+func (ob *OrderBook) reinstate(level *pricelevel.PriceLevel, i int, order *pricelevel.Order, side string) {
+	level.Orders = append(level.Orders[:i], append([]*pricelevel.Order{order}, level.Orders[i:]...)...)
+	level.Quantity += order.Quantity
+
+	if len(level.Orders) == 1 {
+		var levels heap.Interface = ob.SellOrders
+		if side == "BUY" {
+			levels = ob.BuyOrders
+		}
+		heap.Push(levels, level)
+	}
+}
+
+// reduce takes qty off a resting order, from an iceberg's reserve first
+func (ob *OrderBook) reduce(level *pricelevel.PriceLevel, order *pricelevel.Order, side string, qty int) {
+	fromHidden := min(qty, order.Hidden)
+	order.Hidden -= fromHidden
+	order.Quantity -= qty - fromHidden
+	level.Quantity -= qty - fromHidden
+
+	ob.trackReduce(order, side, qty)
+}
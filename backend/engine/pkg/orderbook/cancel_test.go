@@ -0,0 +1,96 @@
+package orderbook
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestModifyOrder(t *testing.T) {
+	user, other := big.NewInt(1), big.NewInt(2)
+	tests := []struct {
+		name      string
+		resting   OrderRequest
+		modify    ModifyRequest
+		wantErr   error
+		wantPrice int // resting price of the order afterwards
+		wantQty   int
+		wantFill  int
+	}{
+		{
+			name:      "reduce in place",
+			resting:   OrderRequest{Side: "BUY", Price: 400, Quantity: 10},
+			modify:    ModifyRequest{Quantity: 4},
+			wantPrice: 400,
+			wantQty:   4,
+		},
+		{
+			name:      "reprice and match",
+			resting:   OrderRequest{Side: "BUY", Price: 400, Quantity: 10},
+			modify:    ModifyRequest{Price: 600, Quantity: 10},
+			wantPrice: 600,
+			wantQty:   5,
+			wantFill:  5,
+		},
+		{
+			name:      "post-only across the spread keeps resting",
+			resting:   OrderRequest{Side: "BUY", Price: 400, Quantity: 10, PostOnly: true},
+			modify:    ModifyRequest{Price: 600, Quantity: 10},
+			wantErr:   ErrWouldTake,
+			wantPrice: 400,
+			wantQty:   10,
+		},
+		{
+			name:      "post-only reprice moves behind the top",
+			resting:   OrderRequest{Side: "BUY", Price: 400, Quantity: 10, PostOnly: true, PostOnlyReprice: true},
+			modify:    ModifyRequest{Price: 600, Quantity: 10},
+			wantPrice: 600 - DefaultTickSize,
+			wantQty:   10,
+		},
+		{
+			name:      "min quantity short keeps resting",
+			resting:   OrderRequest{Side: "BUY", Price: 600, Quantity: 10, MinQty: 3},
+			modify:    ModifyRequest{Price: 700, Quantity: 5},
+			wantErr:   ErrMinQuantity,
+			wantPrice: 600,
+			wantQty:   5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := newTestBook(t, Params{})
+			ob.AddOrder(OrderRequest{Side: "SELL", OrderID: big.NewInt(100), UserID: other, Price: 600, Quantity: 5})
+			ob.AddOrder(OrderRequest{Side: "SELL", OrderID: big.NewInt(101), UserID: other, Price: 700, Quantity: 2})
+
+			req := tt.resting
+			req.OrderID, req.UserID = big.NewInt(7), user
+			if p := ob.AddOrder(req); p.Err != nil {
+				t.Fatal(p.Err)
+			}
+
+			mod := tt.modify
+			mod.OrderID, mod.UserID = req.OrderID, user
+			result, err := ob.ModifyOrder(mod, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if result.Filled != tt.wantFill {
+				t.Errorf("filled %d, want %d", result.Filled, tt.wantFill)
+			}
+
+			order, _, ok := ob.FindOrder(req.OrderID)
+			if !ok {
+				t.Fatal("order no longer rests")
+			}
+			if order.Price != tt.wantPrice || order.Remaining() != tt.wantQty {
+				t.Errorf("rests %d @ %d, want %d @ %d", order.Remaining(), order.Price, tt.wantQty, tt.wantPrice)
+			}
+			if order.PostOnly != req.PostOnly || order.PostOnlyReprice != req.PostOnlyReprice || order.MinQty != req.MinQty {
+				t.Errorf("conditions lost: %+v", order)
+			}
+			if got := ob.Usage(user).OpenBuyQuantity; got != tt.wantQty {
+				t.Errorf("open buy usage %d, want %d", got, tt.wantQty)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// dummyengine/pkg/orderbook/limits.go
+package orderbook
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"fmt"
+	"math/big"
+)
+
+// Limits caps what a single user may have in a book. Zero means unlimited.
+type Limits struct {
+	MaxOpenOrders   int
+	MaxOpenNotional int64 // sum of price * open quantity over resting orders
+	MaxPosition     int   // absolute net position, counting open orders as if filled
+}
+
+func (l Limits) Validate() error {
+	if l.MaxOpenOrders < 0 || l.MaxOpenNotional < 0 || l.MaxPosition < 0 {
+		return fmt.Errorf("invalid limits: %+v", l)
+	}
+	return nil
+}
+
+// UserUsage is a user's current footprint in one book
+type UserUsage struct {
+	OpenOrders       int
+	OpenBuyQuantity  int
+	OpenSellQuantity int
+	OpenNotional     int64
+	NetPosition      int // filled buys minus filled sells
+}
+
+// Usage returns the user's current usage in this book
+func (ob *OrderBook) Usage(userID *big.Int) UserUsage {
+	if u, ok := ob.Users[userID.String()]; ok {
+		return *u
+	}
+	return UserUsage{}
+}
+
+// CheckLimits reports whether req would take its owner past limits, assuming
+// the whole order rests and later fills
+func (ob *OrderBook) CheckLimits(req OrderRequest, limits Limits) error {
+	return checkLimits(ob.Usage(req.UserID), req, limits)
+}
+
+// CheckBatchLimits checks a batch cumulatively, as if every order were
+// accepted, so an all-or-none batch is judged as a whole
+func (ob *OrderBook) CheckBatchLimits(requests []OrderRequest, limits Limits) error {
+	usage := map[string]UserUsage{}
+	for _, req := range requests {
+		key := req.UserID.String()
+		u, ok := usage[key]
+		if !ok {
+			u = ob.Usage(req.UserID)
+		}
+		if err := checkLimits(u, req, limits); err != nil {
+			return fmt.Errorf("order %s: %w", req.OrderID, err)
+		}
+		u.addOpen(req.Side, req.Price, req.Quantity)
+		usage[key] = u
+	}
+	return nil
+}
+
+func checkLimits(u UserUsage, req OrderRequest, limits Limits) error {
+	if limits.MaxOpenOrders > 0 && u.OpenOrders+1 > limits.MaxOpenOrders {
+		return fmt.Errorf("open order limit %d reached", limits.MaxOpenOrders)
+	}
+
+	notional := u.OpenNotional + int64(req.Price)*int64(req.Quantity)
+	if limits.MaxOpenNotional > 0 && notional > limits.MaxOpenNotional {
+		return fmt.Errorf("open notional %d would exceed limit %d", notional, limits.MaxOpenNotional)
+	}
+
+	if limits.MaxPosition > 0 {
+		position := u.NetPosition + u.OpenBuyQuantity + req.Quantity
+		if req.Side == "SELL" {
+			position = u.NetPosition - u.OpenSellQuantity - req.Quantity
+		}
+		if abs(position) > limits.MaxPosition {
+			return fmt.Errorf("position %d would exceed limit %d", position, limits.MaxPosition)
+		}
+	}
+	return nil
+}
+
+func (u *UserUsage) addOpen(side string, price, quantity int) {
+	u.OpenOrders++
+	u.OpenNotional += int64(price) * int64(quantity)
+	if side == "BUY" {
+		u.OpenBuyQuantity += quantity
+	} else {
+		u.OpenSellQuantity += quantity
+	}
+}
+
+func (ob *OrderBook) user(userID *big.Int) *UserUsage {
+	key := userID.String()
+	u, ok := ob.Users[key]
+	if !ok {
+		u = &UserUsage{}
+		ob.Users[key] = u
+	}
+	return u
+}
+
+// trackRest records a newly resting order
+func (ob *OrderBook) trackRest(order *pricelevel.Order, side string) {
+	ob.user(order.UserID).addOpen(side, order.Price, order.Remaining())
+}
+
+// trackFill records qty filled on an order; resting orders also release their open usage
+func (ob *OrderBook) trackFill(order *pricelevel.Order, side string, qty int, resting bool) {
+	u := ob.user(order.UserID)
+	if side == "BUY" {
+		u.NetPosition += qty
+	} else {
+		u.NetPosition -= qty
+	}
+	if !resting {
+		return
+	}
+
+	u.OpenNotional -= int64(order.Price) * int64(qty)
+	if side == "BUY" {
+		u.OpenBuyQuantity -= qty
+	} else {
+		u.OpenSellQuantity -= qty
+	}
+	if order.Remaining() == 0 {
+		u.OpenOrders--
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// trackCancel releases everything a resting order still holds open
+func (ob *OrderBook) trackCancel(order *pricelevel.Order, side string) {
+	ob.trackReduce(order, side, order.Remaining())
+	ob.user(order.UserID).OpenOrders--
+}
+
+// trackReduce releases qty of a resting order's open usage
+func (ob *OrderBook) trackReduce(order *pricelevel.Order, side string, qty int) {
+	u := ob.user(order.UserID)
+	u.OpenNotional -= int64(order.Price) * int64(qty)
+	if side == "BUY" {
+		u.OpenBuyQuantity -= qty
+	} else {
+		u.OpenSellQuantity -= qty
+	}
+}
@@ -0,0 +1,126 @@
+package orderbook
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func order(side string, id, user int64, price, quantity int) OrderRequest {
+	return OrderRequest{Side: side, OrderID: big.NewInt(id), UserID: big.NewInt(user), Price: price, Quantity: quantity}
+}
+
+// limitsBook holds, for user 1, two resting buys of 4 at 100 and a filled
+// buy of 3 against user 2: 2 open orders, 800 open notional, a net position
+// of 3 and 8 more open to buy
+func limitsBook(t *testing.T) *OrderBook {
+	t.Helper()
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(order("SELL", 1, 2, 90, 3))
+	ob.AddOrder(order("BUY", 2, 1, 90, 3))
+	ob.AddOrder(order("BUY", 3, 1, 100, 4))
+	ob.AddOrder(order("BUY", 4, 1, 100, 4))
+	return ob
+}
+
+func TestCheckLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  Limits
+		req     OrderRequest
+		wantErr string
+	}{
+		{"unlimited", Limits{}, order("BUY", 10, 1, 100, 1000), ""},
+		{"open orders below the cap", Limits{MaxOpenOrders: 3}, order("BUY", 10, 1, 100, 1), ""},
+		{"open orders at the cap", Limits{MaxOpenOrders: 2}, order("BUY", 10, 1, 100, 1), "open order limit 2"},
+		{"another user is not counted", Limits{MaxOpenOrders: 2}, order("BUY", 10, 3, 100, 1), ""},
+		{"notional up to the cap", Limits{MaxOpenNotional: 1000}, order("SELL", 10, 1, 100, 2), ""},
+		{"notional past the cap", Limits{MaxOpenNotional: 1000}, order("SELL", 10, 1, 101, 2), "open notional 1002"},
+		// 3 filled and 8 open to buy count as 11 long
+		{"buy within the position", Limits{MaxPosition: 12}, order("BUY", 10, 1, 100, 1), ""},
+		{"buy past the position", Limits{MaxPosition: 11}, order("BUY", 10, 1, 100, 1), "position 12"},
+		// open buys do not offset a sell: 3 filled less 14 sold is 11 short
+		{"sell within the position", Limits{MaxPosition: 11}, order("SELL", 10, 1, 100, 14), ""},
+		{"sell past the position", Limits{MaxPosition: 11}, order("SELL", 10, 1, 100, 15), "position -12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limitsBook(t).CheckLimits(tt.req, tt.limits)
+			checkLimitErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckBatchLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  Limits
+		batch   []OrderRequest
+		wantErr string
+	}{
+		{
+			"each fits, together past the open orders",
+			Limits{MaxOpenOrders: 4},
+			[]OrderRequest{order("BUY", 10, 1, 100, 1), order("BUY", 11, 1, 99, 1), order("BUY", 12, 1, 98, 1)},
+			"order 12: open order limit 4",
+		},
+		{
+			"another user's legs are counted apart",
+			Limits{MaxOpenOrders: 3},
+			[]OrderRequest{order("BUY", 10, 1, 100, 1), order("SELL", 11, 3, 200, 1), order("SELL", 12, 3, 201, 1)},
+			"",
+		},
+		{
+			"notional summed over the ladder",
+			Limits{MaxOpenNotional: 1200},
+			[]OrderRequest{order("SELL", 10, 1, 200, 1), order("SELL", 11, 1, 201, 1)},
+			"order 11: open notional 1201",
+		},
+		{
+			"buys add to the long side",
+			Limits{MaxPosition: 13},
+			[]OrderRequest{order("BUY", 10, 1, 100, 1), order("BUY", 11, 1, 99, 1), order("BUY", 12, 1, 98, 1)},
+			"order 12: position 14",
+		},
+		{
+			"open sells do not offset a buy",
+			Limits{MaxPosition: 11},
+			[]OrderRequest{order("SELL", 10, 1, 110, 7), order("SELL", 11, 1, 111, 7), order("BUY", 12, 1, 99, 1)},
+			"order 12: position 12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limitsBook(t).CheckBatchLimits(tt.batch, tt.limits)
+			checkLimitErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUsageFollowsFills(t *testing.T) {
+	ob := limitsBook(t)
+	if got, want := ob.Usage(big.NewInt(1)), (UserUsage{OpenOrders: 2, OpenBuyQuantity: 8, OpenNotional: 800, NetPosition: 3}); got != want {
+		t.Fatalf("usage %+v, want %+v", got, want)
+	}
+
+	// Selling into user 1's bids releases one whole order and part of the next
+	ob.AddOrder(order("SELL", 20, 2, 100, 6))
+	if got, want := ob.Usage(big.NewInt(1)), (UserUsage{OpenOrders: 1, OpenBuyQuantity: 2, OpenNotional: 200, NetPosition: 9}); got != want {
+		t.Errorf("usage %+v, want %+v", got, want)
+	}
+	if got, want := ob.Usage(big.NewInt(2)), (UserUsage{NetPosition: -9}); got != want {
+		t.Errorf("taker usage %+v, want %+v", got, want)
+	}
+}
+
+func checkLimitErr(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Errorf("no error, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
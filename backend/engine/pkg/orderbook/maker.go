@@ -0,0 +1,119 @@
+// dummyengine/pkg/orderbook/maker.go
+package orderbook
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"math/big"
+)
+
+// MakerQuote is the order the market maker wants resting on one side of the
+// book; a zero Price or Quantity withdraws that side
+type MakerQuote struct {
+	Price    int
+	Quantity int
+}
+
+// makerOrderBase lifts maker order IDs far above the 20-digit IDs real orders
+// carry, so the two never collide
+var makerOrderBase = new(big.Int).Lsh(big.NewInt(1), 192)
+
+// Requote brings the maker's resting orders in line with bid and ask and
+// reports whether anything changed. A side already resting whole where its
+// quote would rest keeps its time priority; any other is canceled and placed
+// again at the back of its level. Quotes are post-only: one that would cross
+// rests a tick behind the opposite top instead, or is left out, so the maker
+// never trades ahead of orders already resting. Depth is published once.
+func (ob *OrderBook) Requote(bid, ask MakerQuote) bool {
+	if ob.MakerID == nil {
+		return false
+	}
+
+	stale := map[string]MakerQuote{}
+	for side, quote := range map[string]MakerQuote{"BUY": bid, "SELL": ask} {
+		if !ob.quoted(side, quote) {
+			stale[side] = quote
+		}
+	}
+	if len(stale) == 0 {
+		return false
+	}
+
+	// Withdraw every stale side before placing, so a new bid never meets
+	// the maker's own old ask
+	for _, side := range []string{"BUY", "SELL"} {
+		if _, ok := stale[side]; ok {
+			ob.cancelAll(ob.MakerID, side)
+		}
+	}
+	for _, side := range []string{"BUY", "SELL"} {
+		quote, ok := stale[side]
+		if !ok || quote.Price <= 0 || quote.Quantity <= 0 {
+			continue
+		}
+		ob.MakerQuotes++
+		ob.placeOrder(OrderRequest{
+			Side:            side,
+			OrderID:         ob.makerOrderID(ob.MakerQuotes),
+			Price:           quote.Price,
+			Quantity:        quote.Quantity,
+			UserID:          ob.MakerID,
+			PostOnly:        true,
+			PostOnlyReprice: true,
+		})
+	}
+	ob.publishOrderBook()
+	return true
+}
+
+// quoted reports whether the maker rests exactly quote on side, at the price
+// a post-only order for it would take now
+func (ob *OrderBook) quoted(side string, quote MakerQuote) bool {
+	orders := ob.makerOrders(side)
+	if quote.Price <= 0 || quote.Quantity <= 0 {
+		return len(orders) == 0
+	}
+	if len(orders) != 1 || orders[0].Remaining() != quote.Quantity {
+		return false
+	}
+	price, err := ob.postOnlyPrice(side, quote.Price, quote.Quantity, true)
+	return err == nil && orders[0].Price == price
+}
+
+// makerOrders returns the maker's resting orders on side
+func (ob *OrderBook) makerOrders(side string) []*pricelevel.Order {
+	levels := ob.SellOrders.CommonHeap
+	if side == "BUY" {
+		levels = ob.BuyOrders.CommonHeap
+	}
+	var orders []*pricelevel.Order
+	for _, level := range levels {
+		for _, order := range level.Orders {
+			if order.UserID.Cmp(ob.MakerID) == 0 {
+				orders = append(orders, order)
+			}
+		}
+	}
+	return orders
+}
+
+// makerOrderID numbers the maker's nth order in this book the same way on
+// every replica
+func (ob *OrderBook) makerOrderID(n uint64) *big.Int {
+	id := new(big.Int).Lsh(ob.EventID, 64)
+	id.Add(id, new(big.Int).SetUint64(uint64(ob.Outcome)<<48|n&(1<<48-1)))
+	return id.Add(id, makerOrderBase)
+}
+
+// trackMaker keeps the maker's cash account as its quotes fill
+func (ob *OrderBook) trackMaker(buyOrder, sellOrder *pricelevel.Order, price, qty int) {
+	if ob.MakerID == nil {
+		return
+	}
+	notional := int64(price) * int64(qty)
+	if buyOrder.UserID.Cmp(ob.MakerID) == 0 {
+		ob.MakerCash -= notional
+	}
+	if sellOrder.UserID.Cmp(ob.MakerID) == 0 {
+		ob.MakerCash += notional
+	}
+}
@@ -0,0 +1,113 @@
+package orderbook
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func makerBook(t *testing.T) *OrderBook {
+	t.Helper()
+	ob := newTestBook(t, Params{})
+	ob.MakerID = big.NewInt(99)
+	return ob
+}
+
+func TestRequoteKeepsPriorityWhileUnchanged(t *testing.T) {
+	ob := makerBook(t)
+	bid, ask := MakerQuote{Price: 400, Quantity: 2}, MakerQuote{Price: 600, Quantity: 2}
+	if !ob.Requote(bid, ask) {
+		t.Fatal("first quotes not placed")
+	}
+	ob.AddOrder(buy(1, 400, 3, 0))
+	if ob.Requote(bid, ask) {
+		t.Error("unchanged quotes replaced")
+	}
+	first := ob.GetTopBuyOrder().Orders[0]
+	if first.UserID.Cmp(ob.MakerID) != 0 {
+		t.Fatalf("maker lost its place to order %s", first.ID)
+	}
+
+	// A new size goes to the back of the level
+	if !ob.Requote(MakerQuote{Price: 400, Quantity: 1}, ask) {
+		t.Fatal("resized quote not replaced")
+	}
+	orders := ob.GetTopBuyOrder().Orders
+	if len(orders) != 2 || orders[0].ID.Int64() != 1 || orders[1].UserID.Cmp(ob.MakerID) != 0 || orders[1].Quantity != 1 {
+		t.Errorf("bids %v, want order 1 ahead of the maker's 1", queue(ob.GetTopBuyOrder()))
+	}
+	if ob.MakerQuotes != 3 {
+		t.Errorf("%d maker orders placed, want 3", ob.MakerQuotes)
+	}
+}
+
+func TestRequoteNeverTakes(t *testing.T) {
+	ob := makerBook(t)
+	ob.AddOrder(sell(1, 450, 5, 0))
+
+	// The bid would cross the ask at 450, so it rests a tick behind it
+	ob.Requote(MakerQuote{Price: 460, Quantity: 2}, MakerQuote{Price: 600, Quantity: 2})
+	if top := ob.GetTopBuyOrder(); top == nil || top.Price != 449 || top.Quantity != 2 {
+		t.Fatalf("maker bid %v, want 2 at 449", top)
+	}
+	if u := ob.Usage(ob.MakerID); u.NetPosition != 0 || ob.MakerCash != 0 {
+		t.Errorf("maker traded: usage %+v cash %d", u, ob.MakerCash)
+	}
+
+	// Unchanged at its repriced level, it is not requoted
+	if ob.Requote(MakerQuote{Price: 460, Quantity: 2}, MakerQuote{Price: 600, Quantity: 2}) {
+		t.Error("repriced quote replaced again")
+	}
+
+	// A zero quote withdraws the side
+	ob.Requote(MakerQuote{}, MakerQuote{Price: 600, Quantity: 2})
+	if top := ob.GetTopBuyOrder(); top != nil {
+		t.Errorf("bid left at %d", top.Price)
+	}
+}
+
+func TestMakerCash(t *testing.T) {
+	ob := makerBook(t)
+	ob.Requote(MakerQuote{Price: 400, Quantity: 2}, MakerQuote{Price: 600, Quantity: 2})
+	ob.AddOrder(sell(1, 400, 2, 0)) // the maker buys 2 at 400
+	ob.AddOrder(buy(2, 600, 1, 0))  // and sells 1 at 600
+	if ob.MakerCash != -200 || ob.Usage(ob.MakerID).NetPosition != 1 {
+		t.Errorf("maker cash %d position %d, want -200 and 1", ob.MakerCash, ob.Usage(ob.MakerID).NetPosition)
+	}
+
+	restored := newTestBook(t, ob.Params)
+	restored.MakerID = ob.MakerID
+	restored.Restore(ob.Snapshot())
+	if restored.MakerCash != ob.MakerCash || restored.MakerQuotes != ob.MakerQuotes {
+		t.Errorf("restored maker cash %d quotes %d", restored.MakerCash, restored.MakerQuotes)
+	}
+}
+
+func TestMakerOrderIDs(t *testing.T) {
+	ob := makerBook(t)
+	other := makerBook(t)
+	other.Outcome = 1
+
+	seen := map[string]bool{}
+	for n := uint64(1); n <= 3; n++ {
+		for _, id := range []*big.Int{ob.makerOrderID(n), other.makerOrderID(n)} {
+			if seen[id.String()] {
+				t.Errorf("order ID %s issued twice", id)
+			}
+			seen[id.String()] = true
+			if id.Cmp(makerOrderBase) <= 0 {
+				t.Errorf("order ID %s within the range of real orders", id)
+			}
+		}
+	}
+	if !reflect.DeepEqual(ob.makerOrderID(2), makerBook(t).makerOrderID(2)) {
+		t.Error("replicas number the same order differently")
+	}
+}
+
+func TestRequoteWithoutAMaker(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	if ob.Requote(MakerQuote{Price: 400, Quantity: 1}, MakerQuote{}) || ob.GetTopBuyOrder() != nil {
+		t.Error("a book without a maker quoted")
+	}
+}
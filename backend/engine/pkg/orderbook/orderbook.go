@@ -3,14 +3,18 @@ package orderbook
 
 import (
 	"container/heap"
+	"dummyengine/pkg/confirm"
 	"dummyengine/pkg/customheap"
+	"dummyengine/pkg/fees"
 	"dummyengine/pkg/logger"
+	"dummyengine/pkg/matching"
 	"dummyengine/pkg/pricelevel"
 	"dummyengine/pkg/uniqueid"
-	"encoding/json"
+	"dummyengine/pkg/wire"
 	"fmt"
 	"github.com/streadway/amqp"
 	"math/big"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,26 +22,90 @@ type OrderBook struct {
 	EventID        *big.Int
 	BuyOrders      *customheap.BuyOrderBook
 	SellOrders     *customheap.SellOrderBook
-	Ch             *amqp.Channel
+	Ch             *atomic.Pointer[amqp.Channel] // shared with the exchange, swapped on reconnect; nil publishes nothing
 	TradeQueue     string
 	PriceQueue     string
 	OrderBookQueue string
+	ContentType    string // wire format used for everything this book publishes
+	Params         Params
+	Policy         matching.MatchingPolicy
+	Users          map[string]*UserUsage // per-user usage keyed by user ID
+	Observers      []TradeObserver
+	BookObservers  []BookObserver
+	Silent         bool             // apply orders without publishing, as a standby does
+	Clock          func() time.Time // time trades happen at; nil for the wall clock
+	Auction        bool             // in the opening call: orders rest without matching until Uncross
+	Outcome        int              // outcome whose shares trade here, 0 for a yes/no event
+	Settled        bool             // the event is settled and takes no more orders
+
+	// The event's LMSR market maker trades here as MakerID, nil without one.
+	// MakerCash is what it has received for shares less what it has paid,
+	// MakerQuotes how many orders it has placed in this book.
+	MakerID     *big.Int
+	MakerCash   int64
+	MakerQuotes uint64
+
+	trial bool // a scratch copy made by TryBatch, whose fills are not real
 }
 
-type TradeMessage struct {
-	ID        *big.Int `json:"id"`
-	OrderID   *big.Int `json:"order_id"`
-	UserID    *big.Int `json:"user_id"`
-	Price     int      `json:"price"`
-	Quantity  int      `json:"quantity"`
-	Timestamp int64    `json:"timestamp"`
+// TradeObserver is notified of every execution in a book
+type TradeObserver interface {
+	OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time)
 }
 
-type PriceUpdate struct {
-	Price int `json:"price"`
+// BookObserver is notified each time the book publishes new depth
+type BookObserver interface {
+	OnBookUpdate(ob *OrderBook)
 }
 
-func NewOrderBook(ch *amqp.Channel, eventID *big.Int, tradeQueue, priceQueue, orderBookQueue string) *OrderBook {
+// DefaultPayout is what a winning share pays out when CreateEvent sets none;
+// prices are quoted out of it, so price / payout is the implied probability
+const DefaultPayout = 1000
+
+// Params are the per-event rules chosen at CreateEvent and changed by UpdateEvent
+type Params struct {
+	MatchingPolicy string // "FIFO" || "PRO_RATA"
+	MinAllocation  int    // smallest pro-rata share worth allocating
+	Fees           fees.Schedule
+	Limits         Limits
+	Payout         int
+
+	// Prices are multiples of TickSize within [MinPrice, MaxPrice], a zero
+	// bound being open; quantities are multiples of LotSize
+	TickSize int
+	MinPrice int
+	MaxPrice int
+	LotSize  int
+
+	Outcomes int // number of outcomes, 2 for a yes/no event
+
+	// Orders are taken from OpenTime until CloseTime (unix seconds, zero for
+	// no limit); before OpenTime only into an opening auction
+	OpenTime  int64
+	CloseTime int64
+
+	// OpeningAuction starts the book in a call phase that ends with a single
+	// uncross, at AuctionEnd (unix seconds) if set or else on request
+	OpeningAuction bool
+	AuctionEnd     int64
+
+	// MakerLiquidity runs an LMSR market maker with that liquidity b, in
+	// shares, quoting MakerQuantity (LotSize by default) on each side of
+	// every book; zero runs none
+	MakerLiquidity int
+	MakerQuantity  int
+}
+
+type TradeMessage = wire.TradeMessage
+
+type PriceUpdate = wire.PriceUpdate
+
+func NewOrderBook(ch *atomic.Pointer[amqp.Channel], eventID *big.Int, tradeQueue, priceQueue, orderBookQueue string, params Params) (*OrderBook, error) {
+	policy, err := params.normalize()
+	if err != nil {
+		return nil, err
+	}
+
 	buyHeap := &customheap.BuyOrderBook{}
 	sellHeap := &customheap.SellOrderBook{}
 	heap.Init(buyHeap)
@@ -51,25 +119,189 @@ func NewOrderBook(ch *amqp.Channel, eventID *big.Int, tradeQueue, priceQueue, or
 		TradeQueue:     tradeQueue,
 		PriceQueue:     priceQueue,
 		OrderBookQueue: orderBookQueue,
-	}
+		ContentType:    wire.ContentTypeJSON,
+		Params:         params,
+		Policy:         policy,
+		Users:          make(map[string]*UserUsage),
+		Auction:        params.OpeningAuction,
+	}, nil
+}
+
+// OrderRequest describes an incoming order
+type OrderRequest struct {
+	Side            string // "BUY" || "SELL"
+	OrderID         *big.Int
+	Price           int
+	Quantity        int // total quantity
+	UserID          *big.Int
+	DisplayQuantity int    // iceberg peak; 0 shows the full quantity
+	Tier            string // owner's rate limit tier, used by the exchange only
+	Outcome         int    // book of a categorical event, used by the exchange only
+
+	// A post-only order that would cross the opposite top is refused, or with
+	// PostOnlyReprice moved one tick behind it
+	PostOnly        bool
+	PostOnlyReprice bool
+
+	// MinQty cancels the order unless at least that much fills on entry; the
+	// rest then rests as usual. AllOrNone orders only ever fill completely:
+	// one that cannot on entry is canceled if it crosses the opposite side,
+	// and otherwise rests whole until some order can fill it.
+	MinQty    int
+	AllOrNone bool
+}
+
+// Placement is what the book did with an order
+type Placement struct {
+	Filled   int
+	Price    int   // price the order rests at, moved if Repriced
+	Repriced bool  // a post-only order was moved to avoid taking liquidity
+	Err      error // the order was refused and left no trace in the book
+	Canceled bool  // refused for lack of liquidity rather than as invalid
 }
 
 func (ob *OrderBook) AddBuyOrder(orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int) {
+	ob.AddOrder(OrderRequest{Side: "BUY", OrderID: orderID, Price: orderPrice, Quantity: orderQuantity, UserID: orderUserID})
+}
+
+func (ob *OrderBook) AddSellOrder(orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int) {
+	ob.AddOrder(OrderRequest{Side: "SELL", OrderID: orderID, Price: orderPrice, Quantity: orderQuantity, UserID: orderUserID})
+}
+
+// AddOrder rests and matches a single order, publishes the resulting price and
+// depth, and returns what became of it
+func (ob *OrderBook) AddOrder(req OrderRequest) Placement {
+	placement := ob.placeOrder(req)
+	if placement.Err == nil {
+		if !ob.Auction {
+			ob.publishPriceUpdate(placement.Price)
+		}
+		ob.publishOrderBook()
+	}
+	return placement
+}
+
+// AddBatch rests and matches every order in one pass, then publishes a single
+// combined depth update. It returns what became of each request.
+func (ob *OrderBook) AddBatch(requests []OrderRequest) []Placement {
+	placements := make([]Placement, len(requests))
+	lastPrice := 0
+
+	for i, req := range requests {
+		placements[i] = ob.placeOrder(req)
+		if placements[i].Err == nil {
+			lastPrice = placements[i].Price
+		}
+	}
+
+	if lastPrice > 0 {
+		if !ob.Auction {
+			ob.publishPriceUpdate(lastPrice)
+		}
+		ob.publishOrderBook()
+	}
+	return placements
+}
+
+// TryBatch places requests on a scratch copy of the book and returns the
+// index and error of the first one the book would refuse, or -1 and nil.
+// The legs see each other as AddBatch would place them, so an all-or-none
+// batch that passes goes on whole.
+func (ob *OrderBook) TryBatch(requests []OrderRequest) (int, error) {
+	scratch, err := NewOrderBook(nil, ob.EventID, "", "", "", ob.Params)
+	if err != nil {
+		return 0, err
+	}
+	scratch.Restore(ob.Snapshot())
+	scratch.Outcome, scratch.MakerID = ob.Outcome, ob.MakerID
+	scratch.Silent, scratch.trial = true, true
+
+	for i, req := range requests {
+		if placement := scratch.placeOrder(req); placement.Err != nil {
+			return i, placement.Err
+		}
+	}
+	return -1, nil
+}
+
+// placeOrder rests and matches an order without publishing depth. The fill
+// counts the hidden iceberg reserve too.
+func (ob *OrderBook) placeOrder(req OrderRequest) Placement {
+	placement := Placement{Price: req.Price}
+	if ob.Auction && (req.PostOnly || req.MinQty > 0 || req.AllOrNone) {
+		placement.Err = ErrAuctionOrder
+		return placement
+	}
+	if req.PostOnly {
+		price, err := ob.postOnlyPrice(req.Side, req.Price, req.Quantity, req.PostOnlyReprice)
+		if err != nil {
+			placement.Err = err
+			return placement
+		}
+		placement.Price, placement.Repriced = price, price != req.Price
+	}
+
 	order := &pricelevel.Order{
-		ID:       orderID,
-		Price:    orderPrice,
-		Quantity: orderQuantity,
-		UserID:   orderUserID,
+		ID:              req.OrderID,
+		Price:           placement.Price,
+		Quantity:        req.Quantity,
+		UserID:          req.UserID,
+		PostOnly:        req.PostOnly,
+		PostOnlyReprice: req.PostOnlyReprice,
+		MinQty:          req.MinQty,
+		AllOrNone:       req.AllOrNone,
+	}
+
+	// Conditional orders see how much would fill before anything does
+	match := true
+	if req.MinQty > 0 || req.AllOrNone {
+		available := ob.fillable(order, req.Side)
+		required := req.MinQty
+		// Resting across the spread would leave the book crossed
+		if req.AllOrNone && available < req.Quantity && ob.crosses(order.Price, req.Side) {
+			required = req.Quantity
+		}
+		if available < required {
+			placement.Err = fmt.Errorf("%w: %d available, %d required", ErrMinQuantity, available, required)
+			placement.Canceled = true
+			return placement
+		}
+		match = !req.AllOrNone || available == req.Quantity
+	}
+
+	// An incoming iceberg takes liquidity with its full size; only the
+	// resting remainder is split into a visible slice and a reserve. During
+	// the opening auction nothing matches until the uncross.
+	if match && !ob.Auction {
+		ob.MatchOrders(order, req.Side)
+	}
+	placement.Filled = req.Quantity - order.Quantity
+	if order.Quantity == 0 {
+		return placement
+	}
+
+	if req.DisplayQuantity > 0 && req.DisplayQuantity < order.Quantity {
+		order.Peak = req.DisplayQuantity
+		order.Hidden = order.Quantity - req.DisplayQuantity
+		order.Quantity = req.DisplayQuantity
+	}
+
+	if req.Side == "BUY" {
+		ob.restBuyOrder(order)
+	} else {
+		ob.restSellOrder(order)
 	}
+	ob.trackRest(order, req.Side)
+	return placement
+}
 
+// restBuyOrder places an order at its price level without matching or publishing
+func (ob *OrderBook) restBuyOrder(order *pricelevel.Order) {
 	for _, level := range ob.BuyOrders.CommonHeap {
 		if level.Price == order.Price {
 			level.Orders = append(level.Orders, order)
 			level.Quantity += order.Quantity
 			heap.Fix(ob.BuyOrders, level.Index)
-			ob.MatchOrders()
-			ob.publishPriceUpdate(level.Price)
-			ob.publishOrderBook()
 			return
 		}
 	}
@@ -79,29 +311,16 @@ func (ob *OrderBook) AddBuyOrder(orderID *big.Int, orderPrice int, orderQuantity
 		Quantity: order.Quantity,
 		Orders:   []*pricelevel.Order{order},
 	}
-
 	heap.Push(ob.BuyOrders, newLevel)
-	ob.MatchOrders()
-	ob.publishPriceUpdate(newLevel.Price)
-	ob.publishOrderBook()
 }
 
-func (ob *OrderBook) AddSellOrder(orderID *big.Int, orderPrice int, orderQuantity int, orderUserID *big.Int) {
-	order := &pricelevel.Order{
-		ID:       orderID,
-		Price:    orderPrice,
-		Quantity: orderQuantity,
-		UserID:   orderUserID,
-	}
-
+// restSellOrder places an order at its price level without matching or publishing
+func (ob *OrderBook) restSellOrder(order *pricelevel.Order) {
 	for _, level := range ob.SellOrders.CommonHeap {
 		if level.Price == order.Price {
 			level.Orders = append(level.Orders, order)
 			level.Quantity += order.Quantity
 			heap.Fix(ob.SellOrders, level.Index)
-			ob.MatchOrders()
-			ob.publishPriceUpdate(level.Price)
-			ob.publishOrderBook()
 			return
 		}
 	}
@@ -111,11 +330,7 @@ func (ob *OrderBook) AddSellOrder(orderID *big.Int, orderPrice int, orderQuantit
 		Quantity: order.Quantity,
 		Orders:   []*pricelevel.Order{order},
 	}
-
 	heap.Push(ob.SellOrders, newLevel)
-	ob.MatchOrders()
-	ob.publishPriceUpdate(newLevel.Price)
-	ob.publishOrderBook()
 }
 
 func (ob *OrderBook) GetTopBuyOrder() *pricelevel.PriceLevel {
@@ -166,94 +381,187 @@ func (ob *OrderBook) GetAllSellOrders() {
 	fmt.Printf("Total Orders: %v\n", totalOrders)
 }
 
-func (ob *OrderBook) MatchOrders() {
-	for {
-		topBuy := ob.GetTopBuyOrder()
-		topSell := ob.GetTopSellOrder()
+// MatchOrders matches an incoming order against the opposite side, best level
+// first, while prices cross. Fills happen at the resting level's price and are
+// shared inside each level by the book's matching policy. Resting all-or-none
+// orders too large for what is left of the incoming one are passed over, so
+// they never hold up the orders behind them.
+func (ob *OrderBook) MatchOrders(incoming *pricelevel.Order, side string) {
+	var levels heap.Interface = ob.SellOrders
+	if side == "SELL" {
+		levels = ob.BuyOrders
+	}
 
-		if topBuy == nil || topSell == nil || topBuy.Price < topSell.Price {
-			break
+	for _, level := range ob.crossing(incoming.Price, side) {
+		if incoming.Quantity == 0 {
+			return
 		}
 
-		for len(topBuy.Orders) > 0 && len(topSell.Orders) > 0 {
-			buyOrder := topBuy.Orders[0]
-			sellOrder := topSell.Orders[0]
-
-			matchQty := min(buyOrder.Quantity, sellOrder.Quantity)
-
-			logger.Info("🚀 Matched Order",
-				"price", topSell.Price,
-				"quantity", matchQty,
-				"buyer", buyOrder.UserID,
-				"seller", sellOrder.UserID)
-
-			buyerSideTrade := TradeMessage{
-				ID:        uniqueid.GenerateBaseId(),
-				OrderID:   buyOrder.ID,
-				UserID:    buyOrder.UserID,
-				Price:     topSell.Price,
-				Quantity:  matchQty,
-				Timestamp: time.Now().Unix(),
-			}
-			ob.publishTrade(buyerSideTrade)
-
-			sellerSideTrade := TradeMessage{
-				ID:        uniqueid.GenerateBaseId(),
-				OrderID:   sellOrder.ID,
-				UserID:    sellOrder.UserID,
-				Price:     topSell.Price,
-				Quantity:  matchQty,
-				Timestamp: time.Now().Unix(),
-			}
-
-			ob.publishTrade(sellerSideTrade)
+		ob.matchLevel(incoming, level, func(resting *pricelevel.Order, qty int) {
+			ob.fill(incoming, resting, side, level, qty)
+		})
 
-			buyOrder.Quantity -= matchQty
-			sellOrder.Quantity -= matchQty
-			topBuy.Quantity -= matchQty
-			topSell.Quantity -= matchQty
+		if len(level.Orders) == 0 {
+			heap.Remove(levels, level.Index)
+		} else {
+			heap.Fix(levels, level.Index)
+		}
+	}
+}
 
-			if buyOrder.Quantity == 0 {
-				topBuy.Orders = topBuy.Orders[1:]
-			}
-			if sellOrder.Quantity == 0 {
-				topSell.Orders = topSell.Orders[1:]
+// matchLevel fills the incoming order against one price level until either is
+// exhausted or nothing left at the level can be filled
+func (ob *OrderBook) matchLevel(incoming *pricelevel.Order, level *pricelevel.PriceLevel, fill func(resting *pricelevel.Order, qty int)) {
+	for incoming.Quantity > 0 && len(level.Orders) > 0 {
+		allocations := ob.allocate(level.Orders, incoming.Quantity)
+
+		progressed := false
+		for i, qty := range allocations {
+			if qty > 0 {
+				fill(level.Orders[i], qty)
+				progressed = true
 			}
 		}
 
-		if len(topBuy.Orders) == 0 {
-			heap.Pop(ob.BuyOrders)
-		} else {
-			heap.Fix(ob.BuyOrders, topBuy.Index)
+		sweep(level)
+		if !progressed {
+			return
 		}
+	}
+}
 
-		if len(topSell.Orders) == 0 {
-			heap.Pop(ob.SellOrders)
-		} else {
-			heap.Fix(ob.SellOrders, topSell.Index)
+// sweep drops filled orders from a level; icebergs show their next slice at the back
+func sweep(level *pricelevel.PriceLevel) {
+	kept := make([]*pricelevel.Order, 0, len(level.Orders))
+	var replenished []*pricelevel.Order
+	for _, order := range level.Orders {
+		if order.Quantity > 0 {
+			kept = append(kept, order)
+		} else if order.Replenish() {
+			replenished = append(replenished, order)
+			level.Quantity += order.Quantity
 		}
 	}
+	level.Orders = append(kept, replenished...)
+}
+
+// fill executes qty between the incoming and a resting order and publishes
+// both trade legs. The resting order is the maker, the incoming one the taker.
+func (ob *OrderBook) fill(incoming, resting *pricelevel.Order, side string, level *pricelevel.PriceLevel, qty int) {
+	takerFee := ob.Params.Fees.Taker(level.Price, qty)
+	makerFee := ob.Params.Fees.Maker(level.Price, qty)
+
+	buyOrder, sellOrder := incoming, resting
+	buyFee, sellFee := takerFee, makerFee
+	buyLiquidity, sellLiquidity := fees.Taker, fees.Maker
+	if side == "SELL" {
+		buyOrder, sellOrder = resting, incoming
+		buyFee, sellFee = makerFee, takerFee
+		buyLiquidity, sellLiquidity = fees.Maker, fees.Taker
+	}
+
+	ob.trade(buyOrder, sellOrder, level.Price, qty, buyFee, sellFee, buyLiquidity, sellLiquidity)
+
+	incoming.Quantity -= qty
+	resting.Quantity -= qty
+	level.Quantity -= qty
+
+	restingSide := "SELL"
+	if side == "SELL" {
+		restingSide = "BUY"
+	}
+	ob.trackFill(incoming, side, qty, false)
+	ob.trackFill(resting, restingSide, qty, true)
+}
+
+// trade publishes both legs of a fill and tells the trade observers
+func (ob *OrderBook) trade(buyOrder, sellOrder *pricelevel.Order, price, qty, buyFee, sellFee int, buyLiquidity, sellLiquidity string) {
+	if ob.trial {
+		return
+	}
+	logger.Info("🚀 Matched Order",
+		"price", price,
+		"quantity", qty,
+		"buyer", buyOrder.UserID,
+		"seller", sellOrder.UserID,
+		"policy", ob.Policy.Name())
+
+	at := ob.now()
+	buyerSideTrade := TradeMessage{
+		ID:        uniqueid.GenerateBaseId(),
+		OrderID:   buyOrder.ID,
+		UserID:    buyOrder.UserID,
+		Price:     price,
+		Quantity:  qty,
+		Timestamp: at.Unix(),
+		EventID:   ob.EventID,
+		Fee:       buyFee,
+		Liquidity: buyLiquidity,
+		Side:      "BUY",
+		Outcome:   ob.Outcome,
+	}
+	ob.publishTrade(buyerSideTrade)
+
+	sellerSideTrade := TradeMessage{
+		ID:        uniqueid.GenerateBaseId(),
+		OrderID:   sellOrder.ID,
+		UserID:    sellOrder.UserID,
+		Price:     price,
+		Quantity:  qty,
+		Timestamp: at.Unix(),
+		EventID:   ob.EventID,
+		Fee:       sellFee,
+		Liquidity: sellLiquidity,
+		Side:      "SELL",
+		Outcome:   ob.Outcome,
+	}
+	ob.publishTrade(sellerSideTrade)
+	ob.trackMaker(buyOrder, sellOrder, price, qty)
+
+	for _, observer := range ob.Observers {
+		observer.OnTrade(ob.EventID, ob.Outcome, price, qty, at)
+	}
+}
+
+// now is the time on the book's clock
+func (ob *OrderBook) now() time.Time {
+	if ob.Clock == nil {
+		return time.Now()
+	}
+	return ob.Clock()
 }
 
 func (ob *OrderBook) PublishMessage(exchange, routingKey string, message interface{}) {
-	if ob.Ch == nil {
+	if ob.Silent {
+		return
+	}
+	var ch *amqp.Channel
+	if ob.Ch != nil {
+		ch = ob.Ch.Load()
+	}
+	Publish(ch, ob.ContentType, exchange, routingKey, message)
+}
+
+// Publish encodes message with contentType and publishes it persistently on ch
+func Publish(ch *amqp.Channel, contentType, exchange, routingKey string, message interface{}) {
+	if ch == nil {
 		logger.Error("❌ RabbitMQ channel not initialized. Cannot publish.")
 		return
 	}
 
-	body, err := json.Marshal(message)
+	body, err := wire.Marshal(contentType, message)
 	if err != nil {
 		logger.Error("❌ Failed to marshal message", "error", err)
 		return
 	}
 
-	err = ob.Ch.Publish(
+	err = ch.Publish(
 		exchange,
 		routingKey,
 		false, // Mandatory
 		false, // Immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
+			ContentType:  contentType,
 			Body:         body,
 			DeliveryMode: amqp.Persistent, // Make messages persistent
 		},
@@ -265,6 +573,7 @@ func (ob *OrderBook) PublishMessage(exchange, routingKey string, message interfa
 			"error", err)
 		return
 	}
+	confirm.Published(ch)
 
 	logger.Info("📤 Published event",
 		"exchange", exchange,
@@ -272,69 +581,38 @@ func (ob *OrderBook) PublishMessage(exchange, routingKey string, message interfa
 		"message", message)
 }
 
-func (ob *OrderBook) publishOrderBook() {
-	// Extract Price and Quantity for Buy Levels
-	var buyLevels []struct {
-		Price    int `json:"Price"`
-		Quantity int `json:"Quantity"`
-	}
-	for _, level := range ob.BuyOrders.CommonHeap {
-		buyLevels = append(buyLevels, struct {
-			Price    int `json:"Price"`
-			Quantity int `json:"Quantity"`
-		}{
-			Price:    level.Price,
-			Quantity: level.Quantity,
-		})
-	}
+// Depth returns the book's aggregated levels as published on order_book_exchange
+func (ob *OrderBook) Depth() *wire.OrderBookUpdate {
+	orderBookMsg := &wire.OrderBookUpdate{EventID: ob.EventID, Outcome: ob.Outcome}
 
-	// Extract Price and Quantity for Sell Levels
-	var sellLevels []struct {
-		Price    int `json:"Price"`
-		Quantity int `json:"Quantity"`
+	// Extract Price and Quantity for Buy and Sell Levels
+	for _, level := range ob.BuyOrders.CommonHeap {
+		orderBookMsg.BuyLevels = append(orderBookMsg.BuyLevels, wire.Level{Price: level.Price, Quantity: level.Quantity})
 	}
 	for _, level := range ob.SellOrders.CommonHeap {
-		sellLevels = append(sellLevels, struct {
-			Price    int `json:"Price"`
-			Quantity int `json:"Quantity"`
-		}{
-			Price:    level.Price,
-			Quantity: level.Quantity,
-		})
+		orderBookMsg.SellLevels = append(orderBookMsg.SellLevels, wire.Level{Price: level.Price, Quantity: level.Quantity})
 	}
+	return orderBookMsg
+}
 
-	// Construct the message
-	orderBookMsg := struct {
-		BuyLevels []struct {
-			Price    int `json:"Price"`
-			Quantity int `json:"Quantity"`
-		} `json:"buy_levels"`
-		SellLevels []struct {
-			Price    int `json:"Price"`
-			Quantity int `json:"Quantity"`
-		} `json:"sell_levels"`
-	}{
-		BuyLevels:  buyLevels,
-		SellLevels: sellLevels,
+func (ob *OrderBook) publishOrderBook() {
+	// Publish the message
+	ob.PublishMessage("order_book_exchange", "order_book.update", ob.Depth())
+	if ob.Auction {
+		ob.publishAuction(ob.Indicative(), false)
 	}
 
-	// Publish the message
-	ob.PublishMessage("order_book_exchange", "order_book.update", orderBookMsg)
+	for _, observer := range ob.BookObservers {
+		observer.OnBookUpdate(ob)
+	}
 }
 
 // PublishPriceUpdate publishes price changes
 func (ob *OrderBook) publishPriceUpdate(price int) {
-	ob.PublishMessage("price_exchange", "price.update", PriceUpdate{Price: price})
+	ob.PublishMessage("price_exchange", "price.update", &PriceUpdate{Price: price, EventID: ob.EventID, Outcome: ob.Outcome})
 }
 
 // PublishTrade publishes trade events
 func (ob *OrderBook) publishTrade(trade TradeMessage) {
-	ob.PublishMessage("trade_exchange", "trade.executed", trade)
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	ob.PublishMessage("trade_exchange", "trade.executed", &trade)
 }
@@ -0,0 +1,173 @@
+package orderbook
+
+import (
+	"dummyengine/pkg/fees"
+	"dummyengine/pkg/pricelevel"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestBook returns a book that has no broker to publish to
+func newTestBook(t *testing.T, params Params) *OrderBook {
+	t.Helper()
+	ob, err := NewOrderBook(nil, big.NewInt(1), "trade", "price", "book", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ob
+}
+
+func sell(id int64, price, quantity, display int) OrderRequest {
+	return OrderRequest{Side: "SELL", OrderID: big.NewInt(id), UserID: big.NewInt(1), Price: price, Quantity: quantity, DisplayQuantity: display}
+}
+
+func buy(id int64, price, quantity, display int) OrderRequest {
+	return OrderRequest{Side: "BUY", OrderID: big.NewInt(id), UserID: big.NewInt(2), Price: price, Quantity: quantity, DisplayQuantity: display}
+}
+
+// queue lists the level's orders as id:visible, front first
+func queue(level *pricelevel.PriceLevel) [][2]int {
+	var got [][2]int
+	for _, o := range level.Orders {
+		got = append(got, [2]int{int(o.ID.Int64()), o.Quantity})
+	}
+	return got
+}
+
+func TestIcebergShowsOnlyItsPeak(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 10, 3))
+
+	level := ob.GetTopSellOrder()
+	if level.Quantity != 3 {
+		t.Errorf("level shows %d, want the peak 3", level.Quantity)
+	}
+	if o := level.Orders[0]; o.Hidden != 7 || o.Remaining() != 10 {
+		t.Errorf("hidden %d remaining %d, want 7 and 10", o.Hidden, o.Remaining())
+	}
+
+	// A display quantity that covers the order is a plain order
+	ob.AddOrder(sell(2, 510, 4, 4))
+	ob.AddOrder(sell(3, 520, 4, 9))
+	for _, level := range ob.SellOrders.CommonHeap {
+		if o := level.Orders[0]; o.ID.Int64() != 1 && (o.Hidden != 0 || o.Quantity != 4) {
+			t.Errorf("order %s shows %d and hides %d, want all 4 shown", o.ID, o.Quantity, o.Hidden)
+		}
+	}
+}
+
+func TestIcebergReplenishLosesPriority(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 10, 3))
+	ob.AddOrder(sell(2, 500, 5, 0))
+
+	// Taking exactly the slice sends the iceberg behind the plain order
+	if filled := ob.AddOrder(buy(10, 500, 3, 0)).Filled; filled != 3 {
+		t.Fatalf("filled %d, want 3", filled)
+	}
+	level := ob.GetTopSellOrder()
+	if got, want := queue(level), [][2]int{{2, 5}, {1, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue %v, want %v", got, want)
+	}
+	if level.Quantity != 8 {
+		t.Errorf("level shows %d, want 8", level.Quantity)
+	}
+
+	// The plain order fills first; a partly taken slice shows what is left
+	ob.AddOrder(buy(11, 500, 6, 0))
+	if got, want := queue(ob.GetTopSellOrder()), [][2]int{{1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue %v, want %v", got, want)
+	}
+}
+
+func TestIcebergSweptInOneOrder(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 8, 3)) // slices of 3, 3 and a last one of 2
+	ob.AddOrder(sell(2, 500, 1, 0))
+
+	if filled := ob.AddOrder(buy(10, 500, 20, 0)).Filled; filled != 9 {
+		t.Errorf("filled %d, want the whole 9 resting, reserve included", filled)
+	}
+	if ob.GetTopSellOrder() != nil {
+		t.Errorf("sell side left with %v", queue(ob.GetTopSellOrder()))
+	}
+	if level := ob.GetTopBuyOrder(); level == nil || level.Quantity != 11 {
+		t.Errorf("buy rests %v, want 11", level)
+	}
+}
+
+func TestIncomingIcebergTakesItsReserve(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 500, 4, 0))
+	ob.AddOrder(sell(2, 510, 4, 0))
+
+	// The first slice takes the 500 level; the next ones keep going at the limit
+	if filled := ob.AddOrder(buy(10, 510, 10, 2)).Filled; filled != 8 {
+		t.Fatalf("filled %d, want 8", filled)
+	}
+	level := ob.GetTopBuyOrder()
+	if level == nil || level.Quantity != 2 || level.Orders[0].Hidden != 0 {
+		t.Fatalf("buy rests %v, want a last slice of 2", level)
+	}
+}
+
+func TestProRataSeesOnlyTheSlice(t *testing.T) {
+	ob := newTestBook(t, Params{MatchingPolicy: "PRO_RATA"})
+	ob.AddOrder(sell(1, 500, 10, 2))
+	ob.AddOrder(sell(2, 500, 2, 0))
+
+	// The first pass shares 4 by the visible 2 and 2; the iceberg's next
+	// slice then takes what is left
+	if filled := ob.AddOrder(buy(10, 500, 6, 0)).Filled; filled != 6 {
+		t.Fatalf("filled %d, want 6", filled)
+	}
+	level := ob.GetTopSellOrder()
+	if got, want := queue(level), [][2]int{{1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("queue %v, want %v", got, want)
+	}
+	if left := level.Orders[0].Remaining(); left != 6 {
+		t.Errorf("iceberg has %d left, want 6", left)
+	}
+}
+
+func TestNewOrderBookValidatesFees(t *testing.T) {
+	if _, err := NewOrderBook(nil, big.NewInt(1), "trade", "price", "book", Params{Fees: fees.Schedule{TakerBps: -5}}); err == nil {
+		t.Error("negative taker fee accepted")
+	}
+}
+
+// tradeTimes records the time of each execution the book reports
+type tradeTimes []time.Time
+
+func (tr *tradeTimes) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) {
+	*tr = append(*tr, at)
+}
+
+func TestTradeTime(t *testing.T) {
+	received := time.Date(2026, 1, 2, 9, 59, 30, 0, time.UTC)
+	tests := []struct {
+		name  string
+		clock func() time.Time
+		want  func(at time.Time) bool
+	}{
+		{"book clock", func() time.Time { return received }, func(at time.Time) bool { return at.Equal(received) }},
+		{"wall clock", nil, func(at time.Time) bool { return time.Since(at) < time.Minute }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := newTestBook(t, Params{})
+			var got tradeTimes
+			ob.Observers = append(ob.Observers, &got)
+			ob.Clock = tt.clock
+
+			ob.AddOrder(sell(1, 500, 2, 0))
+			ob.AddOrder(buy(2, 500, 2, 0))
+
+			if len(got) != 1 || !tt.want(got[0]) {
+				t.Fatalf("trades at %v", got)
+			}
+		})
+	}
+}
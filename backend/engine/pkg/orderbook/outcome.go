@@ -0,0 +1,75 @@
+// dummyengine/pkg/orderbook/outcome.go
+package orderbook
+
+import (
+	"dummyengine/pkg/wire"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ErrSettled refuses orders once the event has settled
+var ErrSettled = errors.New("event is settled")
+
+// Books is how many books the event trades in: one for a yes/no event, where
+// selling the outcome is buying its complement, otherwise one per outcome
+func (p Params) Books() int {
+	if p.Outcomes == 2 {
+		return 1
+	}
+	return p.Outcomes
+}
+
+// CheckMint reports whether minting qty complete sets, which adds qty shares
+// to the user's position here, would take them past limits
+func (ob *OrderBook) CheckMint(userID *big.Int, qty int, limits Limits) error {
+	return checkLimits(ob.Usage(userID), OrderRequest{Side: "BUY", Quantity: qty}, Limits{MaxPosition: limits.MaxPosition})
+}
+
+// CheckMerge reports whether the user holds qty shares here that no resting
+// sell order has already committed
+func (ob *OrderBook) CheckMerge(userID *big.Int, qty int) error {
+	u := ob.Usage(userID)
+	if free := u.NetPosition - u.OpenSellQuantity; free < qty {
+		return fmt.Errorf("outcome %d: %d free shares held, %d needed", ob.Outcome, max(free, 0), qty)
+	}
+	return nil
+}
+
+// Mint adds qty shares to the user's position, one leg of a complete set
+func (ob *OrderBook) Mint(userID *big.Int, qty int) {
+	ob.user(userID).NetPosition += qty
+}
+
+// Merge takes qty shares from the user's position, one leg of a complete set
+func (ob *OrderBook) Merge(userID *big.Int, qty int) {
+	ob.user(userID).NetPosition -= qty
+}
+
+// Settle closes the book for good and returns what every open position in it
+// is worth, by user ID. Long shares pay out if this book's outcome won, short
+// shares if it lost. Resting orders should be canceled first.
+func (ob *OrderBook) Settle(winner int) []wire.Payout {
+	ob.Settled = true
+
+	var payouts []wire.Payout
+	for key, u := range ob.Users {
+		if u.NetPosition == 0 {
+			continue
+		}
+		userID, _ := new(big.Int).SetString(key, 10)
+		shares := max(u.NetPosition, 0)
+		if ob.Outcome != winner {
+			shares = max(-u.NetPosition, 0)
+		}
+		payouts = append(payouts, wire.Payout{
+			UserID:   userID,
+			Outcome:  ob.Outcome,
+			Position: u.NetPosition,
+			Amount:   int64(shares) * int64(ob.Params.Payout),
+		})
+	}
+	sort.Slice(payouts, func(i, j int) bool { return payouts[i].UserID.Cmp(payouts[j].UserID) < 0 })
+	return payouts
+}
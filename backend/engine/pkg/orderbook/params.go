@@ -0,0 +1,168 @@
+// dummyengine/pkg/orderbook/params.go
+package orderbook
+
+import (
+	"dummyengine/pkg/matching"
+	"dummyengine/pkg/pricelevel"
+	"fmt"
+	"time"
+)
+
+// Defaults for rules CreateEvent leaves out
+const (
+	DefaultTickSize = 1
+	DefaultLotSize  = 1
+	DefaultOutcomes = 2
+)
+
+// normalize fills in defaults, checks the rules and builds their matching policy
+func (p *Params) normalize() (matching.MatchingPolicy, error) {
+	policy, err := matching.NewPolicy(p.MatchingPolicy, p.MinAllocation)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Fees.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.Limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	if p.Payout == 0 {
+		p.Payout = DefaultPayout
+	}
+	if p.TickSize == 0 {
+		p.TickSize = DefaultTickSize
+	}
+	if p.LotSize == 0 {
+		p.LotSize = DefaultLotSize
+	}
+	if p.Outcomes == 0 {
+		p.Outcomes = DefaultOutcomes
+	}
+	if p.MakerLiquidity > 0 && p.MakerQuantity == 0 {
+		p.MakerQuantity = p.LotSize
+	}
+	// An opening auction without an end of its own uncrosses at the open
+	if p.OpeningAuction && p.AuctionEnd == 0 {
+		p.AuctionEnd = p.OpenTime
+	}
+
+	switch {
+	case p.Payout < 0:
+		return nil, fmt.Errorf("invalid payout %d", p.Payout)
+	case p.TickSize < 0 || p.LotSize < 0:
+		return nil, fmt.Errorf("invalid tick size %d or lot size %d", p.TickSize, p.LotSize)
+	case p.MinPrice < 0 || p.MaxPrice < 0 || (p.MaxPrice > 0 && p.MaxPrice < p.MinPrice):
+		return nil, fmt.Errorf("invalid price range %d-%d", p.MinPrice, p.MaxPrice)
+	case p.MaxPrice > p.Payout || p.MinPrice > p.Payout:
+		return nil, fmt.Errorf("price range %d-%d goes past the payout %d", p.MinPrice, p.MaxPrice, p.Payout)
+	case p.MinPrice%p.TickSize != 0 || p.MaxPrice%p.TickSize != 0:
+		return nil, fmt.Errorf("price range %d-%d is not on tick size %d", p.MinPrice, p.MaxPrice, p.TickSize)
+	case p.Outcomes < 2:
+		return nil, fmt.Errorf("invalid outcome count %d", p.Outcomes)
+	case p.OpenTime < 0 || p.CloseTime < 0 || (p.CloseTime > 0 && p.CloseTime <= p.OpenTime):
+		return nil, fmt.Errorf("invalid trading hours %d-%d", p.OpenTime, p.CloseTime)
+	case p.AuctionEnd < 0 || (p.AuctionEnd > 0 && !p.OpeningAuction):
+		return nil, fmt.Errorf("invalid auction end %d", p.AuctionEnd)
+	case p.MakerLiquidity < 0 || p.MakerQuantity < 0 || p.MakerQuantity%p.LotSize != 0:
+		return nil, fmt.Errorf("invalid maker liquidity %d or quantity %d", p.MakerLiquidity, p.MakerQuantity)
+	}
+	return policy, nil
+}
+
+// CheckOrder checks an order against the event's rules at time at
+func (ob *OrderBook) CheckOrder(req OrderRequest, at time.Time) error {
+	if err := ob.checkTrading(at); err != nil {
+		return err
+	}
+	return ob.Params.check(req)
+}
+
+// check checks an order's price and quantities
+func (p Params) check(req OrderRequest) error {
+	if req.Price%p.TickSize != 0 {
+		return fmt.Errorf("price %d is not a multiple of tick size %d", req.Price, p.TickSize)
+	}
+	if req.Price < p.MinPrice || (p.MaxPrice > 0 && req.Price > p.MaxPrice) {
+		return fmt.Errorf("price %d is outside %d-%d", req.Price, p.MinPrice, p.MaxPrice)
+	}
+	for _, qty := range []int{req.Quantity, req.DisplayQuantity, req.MinQty} {
+		if qty%p.LotSize != 0 {
+			return fmt.Errorf("quantity %d is not a multiple of lot size %d", qty, p.LotSize)
+		}
+	}
+	return nil
+}
+
+// Trading reports whether the book matches orders at time at: it is open,
+// past its opening auction and not settled
+func (ob *OrderBook) Trading(at time.Time) bool {
+	return !ob.Auction && ob.checkTrading(at) == nil
+}
+
+// checkTrading refuses orders outside the event's trading hours or once it is settled
+func (ob *OrderBook) checkTrading(at time.Time) error {
+	if ob.Settled {
+		return ErrSettled
+	}
+	p := ob.Params
+	if p.OpenTime > 0 && at.Unix() < p.OpenTime && !ob.Auction {
+		return fmt.Errorf("event opens for trading at %d", p.OpenTime)
+	}
+	if p.CloseTime > 0 && at.Unix() >= p.CloseTime {
+		return fmt.Errorf("event closed for trading at %d", p.CloseTime)
+	}
+	return nil
+}
+
+// Update replaces the event's rules if CheckUpdate allows them
+func (ob *OrderBook) Update(params Params) error {
+	if err := ob.CheckUpdate(params); err != nil {
+		return err
+	}
+	policy, _ := params.normalize()
+	ob.Params, ob.Policy = params, policy
+	return nil
+}
+
+// CheckUpdate reports whether the book may take new rules. The payout,
+// outcome count, opening auction and maker liquidity are fixed at creation;
+// price and quantity rules may only change if every resting order still
+// meets them.
+func (ob *OrderBook) CheckUpdate(params Params) error {
+	if _, err := params.normalize(); err != nil {
+		return err
+	}
+
+	old := ob.Params
+	switch {
+	case params.Payout != old.Payout:
+		return fmt.Errorf("payout cannot change once the event is created")
+	case params.Outcomes != old.Outcomes:
+		return fmt.Errorf("outcome count cannot change once the event is created")
+	case params.OpeningAuction != old.OpeningAuction:
+		return fmt.Errorf("opening auction cannot be added or removed once the event is created")
+	case params.AuctionEnd != old.AuctionEnd && !ob.Auction:
+		return fmt.Errorf("opening auction is over")
+	case params.MakerLiquidity != old.MakerLiquidity:
+		return fmt.Errorf("maker liquidity cannot change once the event is created")
+	}
+
+	return ob.checkResting(params)
+}
+
+// checkResting returns an error for the first resting order params would not allow
+func (ob *OrderBook) checkResting(params Params) error {
+	for _, levels := range [][]*pricelevel.PriceLevel{ob.BuyOrders.CommonHeap, ob.SellOrders.CommonHeap} {
+		for _, level := range levels {
+			for _, order := range level.Orders {
+				req := OrderRequest{Price: order.Price, Quantity: order.Remaining(), DisplayQuantity: order.Peak}
+				if err := params.check(req); err != nil {
+					return fmt.Errorf("resting order %s: %w", order.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package orderbook
+
+import "testing"
+
+func TestNormalizePriceRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr bool
+	}{
+		{"defaults", Params{}, false},
+		{"within payout", Params{Payout: 100, MinPrice: 10, MaxPrice: 100}, false},
+		{"max price past payout", Params{Payout: 100, MaxPrice: 110}, true},
+		{"min price past payout", Params{Payout: 100, MinPrice: 110}, true},
+		{"max price past default payout", Params{MaxPrice: DefaultPayout + DefaultTickSize}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.params.normalize(); (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
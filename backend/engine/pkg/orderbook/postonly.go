@@ -0,0 +1,60 @@
+// dummyengine/pkg/orderbook/postonly.go
+package orderbook
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"errors"
+	"fmt"
+)
+
+// ErrWouldTake refuses a post-only order that would cross the opposite top
+var ErrWouldTake = errors.New("post-only order would take liquidity")
+
+// postOnlyPrice returns a price at which a post-only order rests without
+// taking liquidity: its own if it does not cross, otherwise one tick behind
+// the opposite top when reprice is set and that is within the event's price
+// range, or ErrWouldTake. The opposite top is the best level quantity could
+// trade with: resting all-or-none orders larger than it are passed over in
+// matching and may leave the book crossed, so they do not count.
+func (ob *OrderBook) postOnlyPrice(side string, price, quantity int, reprice bool) (int, error) {
+	top := ob.takeable(side, quantity)
+	if side == "BUY" {
+		if top == nil || price < top.Price {
+			return price, nil
+		}
+		if !reprice {
+			return 0, ErrWouldTake
+		}
+		if price = top.Price - ob.Params.TickSize; price <= 0 || price < ob.Params.MinPrice {
+			return 0, fmt.Errorf("%w: no valid price below the best ask %d", ErrWouldTake, top.Price)
+		}
+		return price, nil
+	}
+
+	if top == nil || price > top.Price {
+		return price, nil
+	}
+	if !reprice {
+		return 0, ErrWouldTake
+	}
+	if price = top.Price + ob.Params.TickSize; ob.Params.MaxPrice > 0 && price > ob.Params.MaxPrice {
+		return 0, fmt.Errorf("%w: no valid price above the best bid %d", ErrWouldTake, top.Price)
+	}
+	return price, nil
+}
+
+// takeable returns the best opposite level holding an order that an incoming
+// order of quantity on side could fill against, or nil
+func (ob *OrderBook) takeable(side string, quantity int) *pricelevel.PriceLevel {
+	var top *pricelevel.PriceLevel
+	ob.opposite(side, func(level *pricelevel.PriceLevel) bool {
+		for _, order := range level.Orders {
+			if !order.AllOrNone || order.Quantity <= quantity {
+				top = level
+				return false
+			}
+		}
+		return true
+	})
+	return top
+}
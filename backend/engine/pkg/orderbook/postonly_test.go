@@ -0,0 +1,95 @@
+package orderbook
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func postOnly(side string, id int64, price int, reprice bool) OrderRequest {
+	return OrderRequest{Side: side, OrderID: big.NewInt(id), UserID: big.NewInt(3), Price: price, Quantity: 2, PostOnly: true, PostOnlyReprice: reprice}
+}
+
+// spreadBook has a best bid of 400 and a best ask of 410
+func spreadBook(t *testing.T) *OrderBook {
+	t.Helper()
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(buy(1, 400, 5, 0))
+	ob.AddOrder(sell(2, 410, 5, 0))
+	return ob
+}
+
+func TestPostOnlyThatWouldTakeIsRefused(t *testing.T) {
+	// Touching the opposite top is taking, as much as crossing it
+	for _, req := range []OrderRequest{postOnly("BUY", 10, 410, false), postOnly("BUY", 10, 450, false), postOnly("SELL", 10, 400, false)} {
+		ob := spreadBook(t)
+		placement := ob.AddOrder(req)
+		if !errors.Is(placement.Err, ErrWouldTake) {
+			t.Errorf("%s at %d: %+v, want ErrWouldTake", req.Side, req.Price, placement)
+		}
+		if ob.GetTopBuyOrder().Quantity != 5 || ob.GetTopSellOrder().Quantity != 5 {
+			t.Errorf("%s at %d traded or rested", req.Side, req.Price)
+		}
+		if got := ob.Usage(req.UserID); got != (UserUsage{}) {
+			t.Errorf("refused order left usage %+v", got)
+		}
+	}
+}
+
+func TestPostOnlyRepricesOneTickBehind(t *testing.T) {
+	ob := spreadBook(t)
+	if p := ob.AddOrder(postOnly("BUY", 10, 450, true)); p.Err != nil || !p.Repriced || p.Price != 409 || p.Filled != 0 {
+		t.Errorf("buy %+v, want repriced to 409", p)
+	}
+	// The next sell is held off the bid that buy just became
+	if p := ob.AddOrder(postOnly("SELL", 11, 390, true)); p.Err != nil || !p.Repriced || p.Price != 410 {
+		t.Errorf("sell %+v, want repriced to 410", p)
+	}
+	// Inside the spread the order keeps its own price
+	if p := ob.AddOrder(postOnly("BUY", 12, 405, true)); p.Err != nil || p.Repriced || p.Price != 405 {
+		t.Errorf("buy inside the spread %+v", p)
+	}
+	if top := ob.GetTopBuyOrder(); top.Price != 409 || top.Quantity != 2 {
+		t.Errorf("best bid %d x %d, want the repriced 2 at 409", top.Quantity, top.Price)
+	}
+	if top := ob.GetTopSellOrder(); top.Price != 410 || top.Quantity != 7 {
+		t.Errorf("best ask %d x %d, want the sell joined at 410", top.Quantity, top.Price)
+	}
+}
+
+func TestPostOnlyRepriceBelowTheFirstTick(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	ob.AddOrder(sell(1, 1, 5, 0))
+	if p := ob.AddOrder(postOnly("BUY", 10, 3, true)); !errors.Is(p.Err, ErrWouldTake) {
+		t.Errorf("buy under an ask of 1: %+v, want refused", p)
+	}
+}
+
+func TestPostOnlyOnAnEmptySide(t *testing.T) {
+	ob := newTestBook(t, Params{})
+	if p := ob.AddOrder(postOnly("SELL", 10, 1, false)); p.Err != nil || p.Price != 1 {
+		t.Errorf("sell with no bids %+v", p)
+	}
+}
+
+func TestModifiedPostOnlyStaysPassive(t *testing.T) {
+	ob := spreadBook(t)
+	ob.AddOrder(postOnly("BUY", 10, 405, false))
+	ok := func(OrderRequest) error { return nil }
+
+	// Moved onto the ask it is refused and keeps resting where it was
+	if _, err := ob.ModifyOrder(ModifyRequest{OrderID: big.NewInt(10), UserID: big.NewInt(3), Price: 410, Quantity: 2}, ok); !errors.Is(err, ErrWouldTake) {
+		t.Fatalf("modify across the spread: %v", err)
+	}
+	if top := ob.GetTopBuyOrder(); top.Price != 405 || top.Orders[0].ID.Int64() != 10 {
+		t.Errorf("best bid %d, want the order still at 405", top.Price)
+	}
+
+	// Moved within the spread it stays post-only
+	if _, err := ob.ModifyOrder(ModifyRequest{OrderID: big.NewInt(10), UserID: big.NewInt(3), Price: 407, Quantity: 2}, ok); err != nil {
+		t.Fatal(err)
+	}
+	if o := ob.GetTopBuyOrder().Orders[0]; o.Price != 407 || !o.PostOnly {
+		t.Errorf("replacement %+v, want post-only at 407", o)
+	}
+}
@@ -0,0 +1,73 @@
+// dummyengine/pkg/orderbook/snapshot.go
+package orderbook
+
+import (
+	"dummyengine/pkg/pricelevel"
+	"math/big"
+)
+
+// BookSnapshot is the full state of one book: its rules, every resting order
+// in time priority within its level, and per-user usage
+type BookSnapshot struct {
+	EventID *big.Int
+	Params  Params
+	Buy     []pricelevel.Order
+	Sell    []pricelevel.Order
+	Users   map[string]UserUsage
+	Auction bool // still in the opening auction
+	Outcome int
+	Settled bool
+
+	MakerCash   int64
+	MakerQuotes uint64
+}
+
+// Snapshot copies the book's state
+func (ob *OrderBook) Snapshot() BookSnapshot {
+	s := BookSnapshot{
+		EventID: ob.EventID,
+		Params:  ob.Params,
+		Users:   make(map[string]UserUsage, len(ob.Users)),
+		Auction: ob.Auction,
+		Outcome: ob.Outcome,
+		Settled: ob.Settled,
+
+		MakerCash:   ob.MakerCash,
+		MakerQuotes: ob.MakerQuotes,
+	}
+	for _, level := range ob.BuyOrders.CommonHeap {
+		for _, order := range level.Orders {
+			s.Buy = append(s.Buy, *order)
+		}
+	}
+	for _, level := range ob.SellOrders.CommonHeap {
+		for _, order := range level.Orders {
+			s.Sell = append(s.Sell, *order)
+		}
+	}
+	for key, u := range ob.Users {
+		s.Users[key] = *u
+	}
+	return s
+}
+
+// Restore rests the snapshot's orders in their saved priority and takes over
+// its usage, auction phase, settlement and maker account. It does not match
+// or publish, and expects an empty book for the snapshot's outcome.
+func (ob *OrderBook) Restore(s BookSnapshot) {
+	for i := range s.Buy {
+		order := s.Buy[i]
+		ob.restBuyOrder(&order)
+	}
+	for i := range s.Sell {
+		order := s.Sell[i]
+		ob.restSellOrder(&order)
+	}
+	for key, u := range s.Users {
+		usage := u
+		ob.Users[key] = &usage
+	}
+	ob.Auction = s.Auction
+	ob.Settled = s.Settled
+	ob.MakerCash, ob.MakerQuotes = s.MakerCash, s.MakerQuotes
+}
@@ -0,0 +1,180 @@
+// dummyengine/pkg/partition/partition.go
+package partition
+
+import (
+	"dummyengine/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultVirtualNodes is how many points each partition gets on the ring
+const DefaultVirtualNodes = 128
+
+// point is one virtual node of a partition on the hash ring
+type point struct {
+	hash      uint64
+	partition int
+}
+
+// Map assigns every event to one of Count partitions by consistent hashing.
+// Each partition owns VirtualNodes points on a ring at hash("partition-<p>-<v>");
+// an event belongs to the first point at or after hash of its decimal ID,
+// wrapping around. Producers compute the same
+// to pick the routing key.
+//
+// Events moved by a handoff are recorded as overrides, which take precedence
+// over the ring and are saved to Path so they survive restarts.
+type Map struct {
+	Count        int
+	VirtualNodes int
+	Path         string
+	Hold         func() bool // while true, overrides stay in memory until Flush
+
+	ring      []point // sorted by hash
+	mu        sync.RWMutex
+	overrides map[string]int // event ID -> partition
+}
+
+// NewMap builds the ring and restores overrides from path if it exists
+func NewMap(count, virtualNodes int, path string) (*Map, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid partition count %d", count)
+	}
+	if virtualNodes < 1 {
+		virtualNodes = DefaultVirtualNodes
+	}
+
+	m := &Map{
+		Count:        count,
+		VirtualNodes: virtualNodes,
+		Path:         path,
+		overrides:    make(map[string]int),
+	}
+	for p := 0; p < count; p++ {
+		for v := 0; v < virtualNodes; v++ {
+			m.ring = append(m.ring, point{hash: hash("partition-" + strconv.Itoa(p) + "-" + strconv.Itoa(v)), partition: p})
+		}
+	}
+	sort.Slice(m.ring, func(i, j int) bool { return m.ring[i].hash < m.ring[j].hash })
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Owner returns the partition that owns the event
+func (m *Map) Owner(eventID *big.Int) int {
+	m.mu.RLock()
+	p, moved := m.overrides[eventID.String()]
+	m.mu.RUnlock()
+	if moved {
+		return p
+	}
+	return m.Hashed(eventID)
+}
+
+// Hashed returns the event's partition on the ring, ignoring handoffs
+func (m *Map) Hashed(eventID *big.Int) int {
+	h := hash(eventID.String())
+	i := sort.Search(len(m.ring), func(i int) bool { return m.ring[i].hash >= h })
+	if i == len(m.ring) {
+		i = 0
+	}
+	return m.ring[i].partition
+}
+
+// Assign records that the event now belongs to partition p and saves the overrides
+func (m *Map) Assign(eventID *big.Int, p int) error {
+	if p < 0 || p >= m.Count {
+		return fmt.Errorf("partition %d out of range [0, %d)", p, m.Count)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p == m.Hashed(eventID) {
+		delete(m.overrides, eventID.String())
+	} else {
+		m.overrides[eventID.String()] = p
+	}
+	return m.save()
+}
+
+// Flush saves the overrides, as when a hold is lifted
+func (m *Map) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.save()
+}
+
+// RoutingKey returns the per-partition routing key; a single partition keeps the base key
+func RoutingKey(base string, p, count int) string {
+	if count <= 1 {
+		return base
+	}
+	return base + "." + strconv.Itoa(p)
+}
+
+// QueueName returns the per-partition queue; a single partition keeps the base name
+func QueueName(base string, p, count int) string {
+	return RoutingKey(base, p, count)
+}
+
+// hash is FNV-1a 64 followed by the murmur3 finalizer; FNV alone barely
+// moves the high bits for short, similar keys such as sequential IDs
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func (m *Map) load() error {
+	if m.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, &m.overrides); err != nil {
+		return fmt.Errorf("parse partition overrides %s: %w", m.Path, err)
+	}
+	logger.Info("🗺️ Partition overrides restored", "path", m.Path, "events", len(m.overrides))
+	return nil
+}
+
+// save writes the overrides atomically through a temp file; callers hold mu
+func (m *Map) save() error {
+	if m.Path == "" || (m.Hold != nil && m.Hold()) {
+		return nil
+	}
+	data, err := json.Marshal(m.overrides)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.Path), 0755); err != nil {
+		return err
+	}
+	tmp := m.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.Path)
+}
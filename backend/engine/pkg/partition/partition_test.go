@@ -0,0 +1,115 @@
+package partition
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOwnerSpreadsEvents(t *testing.T) {
+	const events = 10000
+	m, err := NewMap(5, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned := make([]int, m.Count)
+	for id := int64(1); id <= events; id++ {
+		owned[m.Owner(big.NewInt(id))]++
+	}
+	// Sequential IDs still land on every partition, give or take a third
+	for p, n := range owned {
+		if share := events / m.Count; n < share*2/3 || n > share*4/3 {
+			t.Errorf("partition %d owns %d of %d events", p, n, events)
+		}
+	}
+}
+
+func TestAddingAPartitionOnlyMovesItsShare(t *testing.T) {
+	const events = 10000
+	before, _ := NewMap(4, 0, "")
+	after, _ := NewMap(5, 0, "")
+	moved := 0
+	for id := int64(1); id <= events; id++ {
+		from, to := before.Owner(big.NewInt(id)), after.Owner(big.NewInt(id))
+		if from == to {
+			continue
+		}
+		if to != 4 {
+			t.Fatalf("event %d moved from %d to %d, want only moves to the new partition", id, from, to)
+		}
+		moved++
+	}
+	if moved > events/3 {
+		t.Errorf("%d of %d events moved for a fifth partition", moved, events)
+	}
+}
+
+func TestAssignSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "partitions.json")
+	m, err := NewMap(3, 0, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventID := big.NewInt(42)
+	home := m.Hashed(eventID)
+	away := (home + 1) % m.Count
+
+	if err := m.Assign(eventID, away); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewMap(3, 0, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Owner(eventID); got != away {
+		t.Errorf("owner after restart %d, want %d", got, away)
+	}
+	if got := restored.Hashed(eventID); got != home {
+		t.Errorf("ring owner %d, want %d", got, home)
+	}
+
+	// Moving back home drops the override rather than pinning the event
+	if err := restored.Assign(eventID, home); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{}" {
+		t.Errorf("overrides %s, want none", data)
+	}
+}
+
+func TestAssignOutOfRange(t *testing.T) {
+	m, _ := NewMap(3, 0, "")
+	for _, p := range []int{-1, 3} {
+		if err := m.Assign(big.NewInt(1), p); err == nil {
+			t.Errorf("assigned to partition %d of 3", p)
+		}
+	}
+}
+
+func TestNewMap(t *testing.T) {
+	if _, err := NewMap(0, 0, ""); err == nil {
+		t.Error("built a map without partitions")
+	}
+	path := filepath.Join(t.TempDir(), "partitions.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMap(2, 0, path); err == nil {
+		t.Error("corrupt overrides ignored")
+	}
+}
+
+func TestRoutingKey(t *testing.T) {
+	// A single partition keeps the names producers already use
+	if got := RoutingKey("Order.add", 0, 1); got != "Order.add" {
+		t.Errorf("single partition key %q", got)
+	}
+	if got := QueueName("order_queue", 2, 3); got != "order_queue.2" {
+		t.Errorf("queue %q", got)
+	}
+}
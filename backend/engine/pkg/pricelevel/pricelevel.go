@@ -8,8 +8,39 @@ import (
 type Order struct {
 	ID       *big.Int
 	Price    int
-	Quantity int
+	Quantity int // visible quantity, the only part counted in PriceLevel.Quantity
 	UserID   *big.Int
+
+	// Iceberg orders show at most Peak and keep the rest in Hidden
+	Peak   int
+	Hidden int
+
+	// Post-only orders never take liquidity, not even when modified; with
+	// PostOnlyReprice they are moved behind the opposite top instead
+	PostOnly        bool
+	PostOnlyReprice bool
+
+	// MinQty applies again whenever the order is re-entered by a modify
+	MinQty int
+
+	// All-or-none orders only ever fill completely, in a single match
+	AllOrNone bool
+}
+
+// Remaining is the order's total open quantity, visible and hidden
+func (o *Order) Remaining() int {
+	return o.Quantity + o.Hidden
+}
+
+// Replenish refills an exhausted iceberg slice from its reserve. It reports
+// whether a new slice was shown, in which case the order loses time priority.
+func (o *Order) Replenish() bool {
+	if o.Quantity > 0 || o.Hidden == 0 {
+		return false
+	}
+	o.Quantity = min(o.Peak, o.Hidden)
+	o.Hidden -= o.Quantity
+	return true
 }
 
 type PriceLevel struct {
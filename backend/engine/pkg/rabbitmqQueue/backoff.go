@@ -0,0 +1,38 @@
+// dummyengine/pkg/rabbitmqQueue/backoff.go
+package rabbitmqQueue
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff is the reconnect delay of the Connect supervisor. It doubles with
+// every attempt from min up to max and starts over once a session is set up.
+type backoff struct {
+	min, max time.Duration
+	attempt  int // attempts since the last reset
+}
+
+// next returns the delay before another attempt, with full jitter over the
+// upper half so that several engines do not reconnect in lockstep
+func (b *backoff) next() time.Duration {
+	delay := b.min
+	for i := 0; i < b.attempt && delay < b.max; i++ {
+		delay *= 2
+	}
+	b.attempt++
+	if delay > b.max {
+		delay = b.max
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// reset starts over from min after a successful setup
+func (b *backoff) reset() {
+	b.attempt = 0
+}
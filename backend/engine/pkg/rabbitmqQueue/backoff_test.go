@@ -0,0 +1,45 @@
+package rabbitmqQueue
+
+import (
+	"testing"
+	"time"
+)
+
+// within fails unless d lies in the jittered range of a delay of want
+func within(t *testing.T, attempt int, d, want time.Duration) {
+	t.Helper()
+	if d < want/2 || d > want {
+		t.Errorf("attempt %d: delay %v, want between %v and %v", attempt, d, want/2, want)
+	}
+}
+
+func TestBackoffDoublesUpToTheCap(t *testing.T) {
+	b := &backoff{min: time.Second, max: 30 * time.Second}
+	want := []time.Duration{1, 2, 4, 8, 16, 30, 30, 30}
+	for i, w := range want {
+		within(t, i, b.next(), w*time.Second)
+	}
+
+	// Far past the cap the doubling neither overflows nor exceeds it
+	b.attempt = 200
+	within(t, b.attempt, b.next(), 30*time.Second)
+}
+
+func TestBackoffStartsOverAfterASetup(t *testing.T) {
+	b := &backoff{min: time.Second, max: 30 * time.Second}
+	for i := 0; i < 4; i++ {
+		b.next()
+	}
+	b.reset()
+	within(t, 0, b.next(), time.Second)
+	within(t, 1, b.next(), 2*time.Second)
+}
+
+func TestBackoffWithoutAMinimum(t *testing.T) {
+	b := &backoff{max: time.Second}
+	for i := 0; i < 3; i++ {
+		if d := b.next(); d != 0 {
+			t.Errorf("attempt %d: delay %v, want none", i, d)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// dummyengine/pkg/rabbitmqQueue/event.go
+package rabbitmqQueue
+
+import (
+	"dummyengine/pkg/fees"
+	"dummyengine/pkg/orderbook"
+	"fmt"
+)
+
+// eventRules are the JSON names of the rules an update mask may list
+var eventRules = map[string]bool{
+	"matchingPolicy": true, "minAllocation": true, "fees": true, "limits": true,
+	"payout": true, "tickSize": true, "minPrice": true, "maxPrice": true,
+	"lotSize": true, "outcomes": true, "openTime": true, "closeTime": true,
+	"openingAuction": true, "auctionEnd": true, "makerLiquidity": true, "makerQuantity": true,
+}
+
+// applyEventParams copies the event rules a CreateEvent or UpdateEvent
+// carries onto params. With an update mask exactly the listed rules are set,
+// zero included; without one, rules left at zero keep what params holds.
+func applyEventParams(params *orderbook.Params, orderMsg EventMessage) error {
+	masked := make(map[string]bool, len(orderMsg.UpdateMask))
+	for _, name := range orderMsg.UpdateMask {
+		if !eventRules[name] {
+			return fmt.Errorf("unknown field %q in update mask", name)
+		}
+		masked[name] = true
+	}
+	// apply reports whether a rule is set: listed in the mask, or given
+	// when there is none
+	apply := func(name string, given bool) bool {
+		if len(masked) == 0 {
+			return given
+		}
+		return masked[name]
+	}
+
+	if apply("matchingPolicy", orderMsg.MatchingPolicy != "") {
+		params.MatchingPolicy = orderMsg.MatchingPolicy
+	}
+	if apply("minAllocation", orderMsg.MinAllocation != 0) {
+		params.MinAllocation = orderMsg.MinAllocation
+	}
+	if apply("fees", orderMsg.Fees != nil) {
+		params.Fees = fees.Schedule{}
+		if orderMsg.Fees != nil {
+			params.Fees = fees.Schedule{
+				MakerBps: orderMsg.Fees.MakerBps,
+				TakerBps: orderMsg.Fees.TakerBps,
+				MinFee:   orderMsg.Fees.MinFee,
+			}
+		}
+	}
+	if apply("limits", orderMsg.Limits != nil) {
+		params.Limits = orderbook.Limits{}
+		if orderMsg.Limits != nil {
+			params.Limits = orderbook.Limits{
+				MaxOpenOrders:   orderMsg.Limits.MaxOpenOrders,
+				MaxOpenNotional: orderMsg.Limits.MaxOpenNotional,
+				MaxPosition:     orderMsg.Limits.MaxPosition,
+			}
+		}
+	}
+
+	for _, field := range []struct {
+		name string
+		from int
+		to   *int
+	}{
+		{"payout", orderMsg.Payout, &params.Payout},
+		{"tickSize", orderMsg.TickSize, &params.TickSize},
+		{"minPrice", orderMsg.MinPrice, &params.MinPrice},
+		{"maxPrice", orderMsg.MaxPrice, &params.MaxPrice},
+		{"lotSize", orderMsg.LotSize, &params.LotSize},
+		{"outcomes", orderMsg.Outcomes, &params.Outcomes},
+		{"makerLiquidity", orderMsg.MakerLiquidity, &params.MakerLiquidity},
+		{"makerQuantity", orderMsg.MakerQuantity, &params.MakerQuantity},
+	} {
+		if apply(field.name, field.from != 0) {
+			*field.to = field.from
+		}
+	}
+	for _, field := range []struct {
+		name string
+		from int64
+		to   *int64
+	}{
+		{"openTime", orderMsg.OpenTime, &params.OpenTime},
+		{"closeTime", orderMsg.CloseTime, &params.CloseTime},
+		{"auctionEnd", orderMsg.AuctionEnd, &params.AuctionEnd},
+	} {
+		if apply(field.name, field.from != 0) {
+			*field.to = field.from
+		}
+	}
+	if apply("openingAuction", orderMsg.OpeningAuction) {
+		params.OpeningAuction = orderMsg.OpeningAuction
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package rabbitmqQueue
+
+import (
+	"dummyengine/pkg/orderbook"
+	"testing"
+)
+
+func TestApplyEventParams(t *testing.T) {
+	current := orderbook.Params{Payout: 1000, MaxPrice: 900, CloseTime: 1700000000, OpeningAuction: true, AuctionEnd: 1690000000}
+	tests := []struct {
+		name    string
+		msg     EventMessage
+		want    orderbook.Params
+		wantErr bool
+	}{
+		{
+			name: "zero keeps without a mask",
+			msg:  EventMessage{TickSize: 5},
+			want: orderbook.Params{Payout: 1000, MaxPrice: 900, TickSize: 5, CloseTime: 1700000000, OpeningAuction: true, AuctionEnd: 1690000000},
+		},
+		{
+			name: "mask clears listed fields",
+			msg:  EventMessage{UpdateMask: []string{"maxPrice", "closeTime", "openingAuction", "auctionEnd"}, TickSize: 5},
+			want: orderbook.Params{Payout: 1000},
+		},
+		{
+			name: "mask sets listed fields only",
+			msg:  EventMessage{UpdateMask: []string{"maxPrice"}, MaxPrice: 800, TickSize: 5},
+			want: orderbook.Params{Payout: 1000, MaxPrice: 800, CloseTime: 1700000000, OpeningAuction: true, AuctionEnd: 1690000000},
+		},
+		{
+			name:    "unknown field in mask",
+			msg:     EventMessage{UpdateMask: []string{"maxprice"}},
+			want:    current,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := current
+			err := applyEventParams(&params, tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if params != tt.want {
+				t.Errorf("params %+v, want %+v", params, tt.want)
+			}
+		})
+	}
+}
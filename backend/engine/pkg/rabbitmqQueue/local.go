@@ -0,0 +1,106 @@
+// dummyengine/pkg/rabbitmqQueue/local.go
+package rabbitmqQueue
+
+import (
+	"context"
+	"dummyengine/pkg/uniqueid"
+	"dummyengine/pkg/wire"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrUnavailable is returned by Submit while no consumer loop is running,
+// e.g. when disconnected or standing by
+var ErrUnavailable = errors.New("engine is not accepting orders")
+
+// localAck completes an in-process submission and collects what it produced
+type localAck struct {
+	done    chan struct{}
+	once    sync.Once
+	err     error
+	reports []*wire.ExecutionReport
+}
+
+func (l *localAck) Ack(tag uint64, multiple bool) error {
+	l.finish(nil)
+	return nil
+}
+
+func (l *localAck) Nack(tag uint64, multiple, requeue bool) error {
+	l.finish(errors.New("message was not processed"))
+	return nil
+}
+
+func (l *localAck) Reject(tag uint64, requeue bool) error {
+	return l.Nack(tag, false, requeue)
+}
+
+func (l *localAck) finish(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+// RejectedError carries the reason a submitted message was rejected outright,
+// where a broker delivery would have been dead-lettered
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Reason
+}
+
+// Submit runs msg through the consumer loop exactly like a delivery from the
+// order queue, journal and sequencing included, and returns the execution
+// reports it produced. If ctx expires after the message was handed over it
+// may still be processed.
+func (c *RabbitMQQueue) Submit(ctx context.Context, msg *EventMessage) ([]*wire.ExecutionReport, error) {
+	if !c.consuming.Load() {
+		return nil, ErrUnavailable
+	}
+
+	body, err := wire.Marshal(wire.ContentTypeProtobuf, msg)
+	if err != nil {
+		return nil, err
+	}
+	ack := &localAck{done: make(chan struct{})}
+	delivery := amqp.Delivery{
+		Acknowledger: ack,
+		ContentType:  wire.ContentTypeProtobuf,
+		MessageId:    uniqueid.GenerateBaseId().String(),
+		Timestamp:    time.Now(),
+		Body:         body,
+	}
+
+	select {
+	case c.local <- delivery:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case <-ack.done:
+		return ack.reports, ack.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// handleLocal processes a submitted message, collecting its reports
+func (c *RabbitMQQueue) handleLocal(msg amqp.Delivery) {
+	c.current = msg.Acknowledger.(*localAck)
+	defer func() { c.current = nil }()
+	c.handle(msg)
+}
+
+// OnReport collects the reports of the submission being processed
+func (c *RabbitMQQueue) OnReport(report *wire.ExecutionReport) {
+	if c.current != nil {
+		c.current.reports = append(c.current.reports, report)
+	}
+}
@@ -0,0 +1,131 @@
+// dummyengine/pkg/rabbitmqQueue/partition.go
+package rabbitmqQueue
+
+import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/confirm"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/partition"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// handoffTimeout bounds the wait for the broker to confirm a handed-off book
+// or a forwarded message
+const handoffTimeout = 10 * time.Second
+
+// partitionKey returns the routing key that reaches partition p
+func partitionKey(p int) string {
+	cfg := config.AppConfig.Partition
+	return partition.RoutingKey(cfg.OrderRoutingKey, p, cfg.Count)
+}
+
+// forward re-routes a message for an event owned by another partition to
+// that partition's queue, untouched, and acks the original once the broker
+// has confirmed the copy
+func (c *RabbitMQQueue) forward(msg amqp.Delivery, owner int) {
+	key := partitionKey(owner)
+	if c.replaying() {
+		msg.Ack(false) // the leader already forwarded it
+		return
+	}
+
+	err := c.Ch.Publish(
+		config.AppConfig.Partition.OrderExchange,
+		key,
+		false, // Mandatory
+		false, // Immediate
+		amqp.Publishing{
+			ContentType:   msg.ContentType,
+			MessageId:     msg.MessageId,
+			CorrelationId: msg.CorrelationId,
+			ReplyTo:       msg.ReplyTo,
+			Headers:       msg.Headers,
+			Body:          msg.Body,
+			DeliveryMode:  amqp.Persistent,
+		},
+	)
+	if err != nil {
+		logger.Error("❌ Failed to forward message, requeueing", "routing_key", key, "error", err)
+		msg.Nack(false, true)
+		return
+	}
+	confirm.Published(c.Ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+	if err := c.Confirms.Wait(ctx); err != nil {
+		logger.Error("❌ Forwarded message not confirmed, requeueing", "routing_key", key, "error", err)
+		msg.Nack(false, true)
+		return
+	}
+
+	logger.Info("↪️ Forwarded message to owning partition", "partition", owner, "routing_key", key)
+	msg.Ack(false)
+}
+
+// moveEvent hands an event over to the target partition. The book leaves this
+// instance only once the broker has confirmed the AdoptEvent carrying it;
+// orders still arriving here afterwards are forwarded behind it.
+func (c *RabbitMQQueue) moveEvent(eventID *big.Int, target int, contentType string) error {
+	send := func(snapshots []orderbook.BookSnapshot) error {
+		if c.replaying() {
+			return nil // the leader already sent it
+		}
+		body, err := json.Marshal(snapshots)
+		if err != nil {
+			return err
+		}
+		adopt := &EventMessage{Task: "AdoptEvent", ID: eventID.String(), Partition: target, Snapshot: body}
+		payload, err := wire.Marshal(contentType, adopt)
+		if err != nil {
+			return err
+		}
+
+		err = c.Ch.Publish(
+			config.AppConfig.Partition.OrderExchange,
+			partitionKey(target),
+			false, // Mandatory
+			false, // Immediate
+			amqp.Publishing{
+				ContentType:  contentType,
+				Body:         payload,
+				DeliveryMode: amqp.Persistent,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		confirm.Published(c.Ch)
+
+		ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+		defer cancel()
+		return c.Confirms.Wait(ctx)
+	}
+
+	return c.Exchange.HandOff(eventID, target, send)
+}
+
+// adoptEvent restores the books handed over by another partition
+func (c *RabbitMQQueue) adoptEvent(eventID *big.Int, orderMsg EventMessage) error {
+	var snapshots []orderbook.BookSnapshot
+	if err := json.Unmarshal(orderMsg.Snapshot, &snapshots); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no snapshot for event %s", eventID)
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.EventID == nil || snapshot.EventID.Cmp(eventID) != 0 {
+			return fmt.Errorf("snapshot is for event %v, not %s", snapshot.EventID, eventID)
+		}
+	}
+	return c.Exchange.Adopt(eventID, snapshots)
+}
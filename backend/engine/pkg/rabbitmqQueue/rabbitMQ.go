@@ -2,10 +2,22 @@
 package rabbitmqQueue
 
 import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/confirm"
 	"dummyengine/pkg/exchange"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"dummyengine/pkg/journal"
+	"dummyengine/pkg/leader"
 	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/ratelimit"
+	"dummyengine/pkg/uniqueid"
+	"dummyengine/pkg/wire"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/streadway/amqp"
@@ -18,78 +30,254 @@ type RabbitMQQueue struct {
 	Queue    string
 	URL      string
 	Exchange *exchange.Exchange
+	Confirms *confirm.Tracker // publish confirms on the current channel
+
+	// With a lease the instance runs as one of a primary/standby pair: the
+	// leader journals its input, the others follow the journal
+	Lease     *leader.Lease
+	OnLead    func() // runs once a standby has taken over, before it consumes
+	leader    atomic.Bool
+	renewed   time.Time       // last successful lease renewal, see renew
+	confirmed uint64          // last sequence whose outputs the broker confirmed
+	pending   []journal.Entry // followed entries not yet confirmed by the leader
+	recent    *journal.Recent
+
+	// Reconnect backoff bounds, doubled per failed attempt with jitter
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	local     chan amqp.Delivery // in-process submissions, see Submit
+	consuming atomic.Bool
+	current   *localAck // submission being processed
+
+	consumerTag string
+	stop        chan struct{} // closed by StopConsuming
+	stopOnce    sync.Once
+	done        chan struct{} // closed when Connect returns
 }
 
+// auctionInterval is how often the consumer loop uncrosses due auctions
+// between inputs
+const auctionInterval = time.Second
+
 // EventMessage represents the structure received from RabbitMQ
-type EventMessage struct {
-	Task          string `json:"task"`               // "Order" || "CreateEvent" || "Settlement"
-	ID            string `json:"eventId"`            // EventID
-	OrderID       string `json:"orderId,omitempty"`  // OrderID
-	OrderPrice    int    `json:"price,omitempty"`    // OrderPrice
-	OrderUserID   string `json:"userId,omitempty"`   // OrderUserID
-	OrderQuantity int    `json:"quantity,omitempty"` // OrderQuantity
-	Type          string `json:"type,omitempty"`     // "BUY" || "SELL"
-}
+type EventMessage = wire.EventMessage
 
 // NewRabbitMQConsumer initializes a new consumer
 func NewRabbitMQQueue(url, queue, tradeQueue, priceQueue, orderBookQueue string) *RabbitMQQueue {
-	return &RabbitMQQueue{
-		Queue:    queue,
-		URL:      url,
-		Exchange: nil, // Initialize to nil, will be set after connection
+	// The exchange outlives every connection; the channel is swapped in on each (re)connect
+	ex := exchange.NewExchange(nil, tradeQueue, priceQueue, orderBookQueue)
+	ex.ContentType = config.AppConfig.RabbitMQ.ContentType
+	ex.GlobalLimits = orderbook.Limits{
+		MaxOpenOrders:   config.AppConfig.Limits.MaxOpenOrders,
+		MaxOpenNotional: config.AppConfig.Limits.MaxOpenNotional,
+		MaxPosition:     config.AppConfig.Limits.MaxPosition,
 	}
+	if tiers := config.AppConfig.Limits.RateTiers; len(tiers) > 0 {
+		// Tiers were validated when the configuration was loaded
+		ex.RateLimiter, _ = ratelimit.NewLimiter(tiers)
+	}
+	ex.MakerID = config.AppConfig.Maker.UserID
+
+	q := &RabbitMQQueue{
+		Queue:      queue,
+		URL:        url,
+		Exchange:   ex,
+		MinBackoff: config.AppConfig.RabbitMQ.ReconnectMinDelay,
+		MaxBackoff: config.AppConfig.RabbitMQ.ReconnectMaxDelay,
+
+		recent:      journal.NewRecent(recentLimit),
+		consumerTag: "engine-" + uniqueid.GenerateBaseId().String(),
+		local:       make(chan amqp.Delivery),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	ex.ReportObservers = append(ex.ReportObservers, q)
+	return q
 }
 
-// Connect establishes the RabbitMQ connection
+// Connect supervises the RabbitMQ connection: it dials, consumes until the
+// connection or channel closes, then reconnects with backoff. It returns only
+// after StopConsuming, leaving the last session open for the confirm wait.
 func (c *RabbitMQQueue) Connect() {
-	var err error
+	defer close(c.done)
+
+	retry := &backoff{min: c.MinBackoff, max: c.MaxBackoff}
+	for !c.stopping() {
+		if err := c.dial(); err != nil {
+			delay := retry.next()
+			logger.Error("❌ Failed to connect to RabbitMQ", "error", err, "attempt", retry.attempt, "retry_in", delay.String())
+			c.sleep(delay)
+			continue
+		}
+
+		if err := c.setup(); err != nil {
+			c.closeSession()
+			delay := retry.next()
+			logger.Error("❌ Failed to set up RabbitMQ channel", "error", err, "attempt", retry.attempt, "retry_in", delay.String())
+			c.sleep(delay)
+			continue
+		}
+		retry.reset()
+
+		logger.Info("✅ Connected to RabbitMQ", "url", c.URL, "queue", c.Queue)
+		c.Exchange.SetChannel(c.Ch)
+
+		err := c.run()
+		if c.stopping() {
+			logger.Info("🛑 Stopped consuming", "queue", c.Queue, "reason", err)
+			return
+		}
+		c.closeSession()
+
+		delay := retry.next()
+		logger.Warn("🔄 RabbitMQ session ended, reconnecting...", "reason", err, "retry_in", delay.String())
+		c.sleep(delay)
+	}
+}
 
-	// Attempt connection
-	c.Conn, err = amqp.Dial(c.URL)
+// run consumes orders as the leader, after following the journal until
+// taking over when running as a standby
+func (c *RabbitMQQueue) run() error {
+	if c.Lease == nil {
+		return c.Consume()
+	}
+
+	if c.leader.Load() {
+		// A standby may have taken over while this instance was disconnected
+		if held, err := c.renew(); err != nil {
+			return err
+		} else if !held {
+			logger.Fatal("🚨 Leader lease lost while disconnected, stopping", "instance", c.Lease.Holder)
+		}
+	} else if err := c.Follow(); err != nil {
+		return err
+	}
+	return c.Consume()
+}
+
+// dial opens a fresh connection and channel
+func (c *RabbitMQQueue) dial() error {
+	conn, err := amqp.Dial(c.URL)
 	if err != nil {
-		logger.Fatal("❌ Failed to connect to RabbitMQ", "error", err)
+		return err
 	}
 
-	c.Ch, err = c.Conn.Channel()
+	ch, err := conn.Channel()
 	if err != nil {
-		logger.Fatal("❌ Failed to open a channel", "error", err)
+		conn.Close()
+		return err
 	}
 
-	logger.Info("✅ Connected to RabbitMQ", "url", c.URL, "queue", c.Queue)
+	c.Conn = conn
+	c.Ch = ch
+	return nil
+}
+
+// setup declares the topology, turns on publish confirms and sets the prefetch
+func (c *RabbitMQQueue) setup() error {
+	cfg := config.AppConfig.RabbitMQ
+	if err := DeclareTopology(c.Ch, cfg); err != nil {
+		return err
+	}
 
-	// Auto-reconnect on failure
-	c.Conn.NotifyClose(make(chan *amqp.Error))
-	c.reconnect()
+	confirms, err := confirm.Enable(c.Ch)
+	if err != nil {
+		return fmt.Errorf("enable publish confirms: %w", err)
+	}
+	c.Confirms = confirms
 
-	// Start consuming
-	c.Consume()
+	if cfg.Prefetch > 0 {
+		if err := c.Ch.Qos(cfg.Prefetch, 0, false); err != nil {
+			return fmt.Errorf("set prefetch: %w", err)
+		}
+	}
+	return nil
 }
 
-// Reconnect handles RabbitMQ reconnection logic
-func (c *RabbitMQQueue) reconnect() {
-	for {
-		logger.Warn("🔄 Reconnecting to RabbitMQ...")
-		time.Sleep(5 * time.Second)
+// closeSession releases whatever is left of the current connection
+func (c *RabbitMQQueue) closeSession() {
+	if c.Ch != nil {
+		_ = c.Ch.Close()
+	}
+	if c.Conn != nil {
+		_ = c.Conn.Close()
+	}
+	c.Ch = nil
+	c.Conn = nil
+	c.Confirms = nil
+	c.Exchange.SetChannel(nil)
+}
 
-		var err error
-		c.Conn, err = amqp.Dial(c.URL)
-		if err == nil {
-			c.Ch, err = c.Conn.Channel()
-			if err == nil {
-				logger.Info("✅ Reconnected to RabbitMQ")
-				c.Exchange = exchange.NewExchange(c.Ch, "trade_queue", "price_queue", "orderBook_queue")
-				c.Consume()
-				return
-			}
+// StopConsuming cancels the consumer and waits until every delivery already
+// handed to the engine has been processed and acked or rejected
+func (c *RabbitMQQueue) StopConsuming(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("draining %s: %w", c.Queue, ctx.Err())
+	}
+}
+
+// WaitForConfirms waits until the broker has confirmed every publish on the
+// current channel. Call it after StopConsuming.
+func (c *RabbitMQQueue) WaitForConfirms(ctx context.Context) error {
+	if c.Confirms == nil {
+		return errors.New("no open channel")
+	}
+	return c.Confirms.Wait(ctx)
+}
+
+// Following reports whether this instance is a standby that has not taken
+// over; its state then mirrors the leader's and is not its own to save
+func (c *RabbitMQQueue) Following() bool {
+	return c.Lease != nil && !c.leader.Load()
+}
+
+// Close closes the channel and then the connection
+func (c *RabbitMQQueue) Close() {
+	c.closeSession()
+	if c.Lease != nil && c.leader.Load() {
+		if err := c.Lease.Release(); err != nil {
+			logger.Error("❌ Failed to release leader lease", "path", c.Lease.Path, "error", err)
 		}
 	}
+	logger.Info("👋 RabbitMQ connection closed")
 }
 
-// Consume listens to the queue
-func (c *RabbitMQQueue) Consume() {
+func (c *RabbitMQQueue) stopping() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d, returning early if StopConsuming is called
+func (c *RabbitMQQueue) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-c.stop:
+	}
+}
+
+// Consume registers the consumer on the current channel and processes
+// deliveries until the connection or channel is closed
+func (c *RabbitMQQueue) Consume() error {
+	// Buffered so the library never blocks on a notification we stopped reading
+	connClosed := c.Conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := c.Ch.NotifyClose(make(chan *amqp.Error, 1))
+
 	msgs, err := c.Ch.Consume(
 		c.Queue,
-		"",
+		c.consumerTag,
 		false,
 		false,
 		false,
@@ -97,21 +285,143 @@ func (c *RabbitMQQueue) Consume() {
 		nil,
 	)
 	if err != nil {
-		logger.Fatal("❌ Failed to register a consumer", "error", err)
+		logger.Error("❌ Failed to register a consumer", "error", err)
+		return err
 	}
 
 	logger.Info("📥 Consuming messages", "queue", c.Queue)
+	c.consuming.Store(true)
+	defer c.consuming.Store(false)
+
+	// Leaders renew their lease and heartbeat the journal; nil blocks forever
+	var heartbeat <-chan time.Time
+	if c.Lease != nil {
+		ticker := time.NewTicker(c.Lease.TTL / 3)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	// Auctions end on time even when no input arrives to uncross them
+	auctions := time.NewTicker(auctionInterval)
+	defer auctions.Stop()
 
+	for {
+		select {
+		case amqpErr := <-connClosed:
+			return closeReason("connection", amqpErr)
+		case amqpErr := <-chClosed:
+			return closeReason("channel", amqpErr)
+		case <-c.stop:
+			return c.drain(msgs)
+		case at := <-auctions.C:
+			c.Exchange.Tick(at)
+		case <-heartbeat:
+			if err := c.heartbeat(); err != nil {
+				return fmt.Errorf("journal heartbeat: %w", err)
+			}
+		case msg, ok := <-msgs:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			c.handle(msg)
+		case msg := <-c.local:
+			c.handleLocal(msg)
+		}
+	}
+}
+
+// drain cancels the consumer and processes the deliveries the broker had
+// already pushed, so nothing in flight is left half-handled
+func (c *RabbitMQQueue) drain(msgs <-chan amqp.Delivery) error {
+	c.consuming.Store(false)
+	if err := c.Ch.Cancel(c.consumerTag, false); err != nil {
+		return fmt.Errorf("cancel consumer: %w", err)
+	}
+
+	drained := 0
 	for msg := range msgs {
-		c.processMessage(msg)
+		c.handle(msg)
+		drained++
 	}
+	logger.Info("🚰 Drained in-flight messages", "queue", c.Queue, "count", drained)
+	return errors.New("consumer cancelled for shutdown")
 }
 
+// reply answers a request on its reply_to queue, in the request's own encoding
+func (c *RabbitMQQueue) reply(msg amqp.Delivery, contentType string, response interface{}) {
+	if c.replaying() {
+		return
+	}
+	if msg.ReplyTo == "" {
+		logger.Warn("⚠️ Query without reply_to, dropping response", "correlation_id", msg.CorrelationId)
+		return
+	}
+
+	body, err := wire.Marshal(contentType, response)
+	if err != nil {
+		logger.Error("❌ Failed to marshal reply", "error", err)
+		return
+	}
+
+	err = c.Ch.Publish(
+		"",
+		msg.ReplyTo,
+		false, // Mandatory
+		false, // Immediate
+		amqp.Publishing{
+			ContentType:   contentType,
+			CorrelationId: msg.CorrelationId,
+			Body:          body,
+		},
+	)
+	if err != nil {
+		logger.Error("❌ Failed to publish reply", "reply_to", msg.ReplyTo, "error", err)
+		return
+	}
+	confirm.Published(c.Ch)
+}
+
+func closeReason(source string, amqpErr *amqp.Error) error {
+	if amqpErr == nil {
+		return fmt.Errorf("%s closed", source)
+	}
+	return fmt.Errorf("%s closed: %w", source, amqpErr)
+}
+
+// processMessage handles one delivery. Every path ends in exactly one ack or
+// reject so that a bad message can never stall the queue.
 func (c *RabbitMQQueue) processMessage(msg amqp.Delivery) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("🔥 Panic while processing message", "panic", r)
+			c.reject(msg, fmt.Sprintf("panic: %v", r))
+		}
+	}()
+
+	contentType, err := wire.Negotiate(msg.ContentType)
+	if err != nil {
+		logger.Error("❌ Unsupported message encoding", "error", err)
+		c.reject(msg, err.Error())
+		return
+	}
+
 	var orderMsg EventMessage
-	if err := json.Unmarshal(msg.Body, &orderMsg); err != nil {
+	if err := wire.Unmarshal(contentType, msg.Body, &orderMsg); err != nil {
 		logger.Error("❌ Failed to parse message", "error", err)
-		msg.Nack(false, false) // Reject message
+		c.reject(msg, "unparseable message: "+err.Error())
+		return
+	}
+
+	c.Exchange.ReceivedAt = receivedAt(msg)
+	c.Exchange.UncrossDue()
+
+	// Market makers requote on both sides of the input, see Exchange.Requote
+	c.Exchange.Requote()
+	defer c.Exchange.Requote()
+
+	// A mass cancel without an event spans every book this partition owns
+	if orderMsg.Task == "MassCancel" && orderMsg.ID == "" {
+		c.massCancel(msg, contentType, orderMsg, nil)
 		return
 	}
 
@@ -119,47 +429,236 @@ func (c *RabbitMQQueue) processMessage(msg amqp.Delivery) {
 	ID := new(big.Int)
 	if err := ID.UnmarshalText([]byte(orderMsg.ID)); err != nil {
 		logger.Error("❌ Failed to convert orderMsg.ID to big.Int", "error", err, "event", orderMsg)
+		c.reject(msg, "invalid eventId: "+orderMsg.ID)
 		return
 	}
 
+	// Another partition's events go on to their owner, except the handoff
+	// that is making this partition the owner
+	if orderMsg.Task != "AdoptEvent" {
+		if owner, ok := c.Exchange.Owner(ID); !ok {
+			// A synchronous caller needs an answer now, not one from another instance
+			if _, local := msg.Acknowledger.(*localAck); local {
+				c.reject(msg, (&exchange.NotOwnerError{EventID: ID, Owner: owner}).Error())
+				return
+			}
+			c.forward(msg, owner)
+			return
+		}
+	}
+
 	switch orderMsg.Task {
 	case "CreateEvent":
 		logger.Info("📌 Creating event", "event_id", ID.String())
-		c.Exchange.AddEvent(ID)
+		var params orderbook.Params
+		err := applyEventParams(&params, orderMsg)
+		if err == nil {
+			err = c.Exchange.AddEvent(ID, params)
+		}
+		if err != nil {
+			logger.Error("❌ Invalid event parameters", "error", err, "event", orderMsg)
+			c.reject(msg, "invalid event parameters: "+err.Error())
+			return
+		}
+
+	case "UpdateEvent":
+		logger.Info("🛠️ Updating event", "event_id", ID.String())
+		params, err := c.Exchange.EventParams(ID)
+		if err == nil {
+			err = applyEventParams(&params, orderMsg)
+		}
+		if err == nil {
+			err = c.Exchange.UpdateEvent(ID, params)
+		}
+		if err != nil {
+			logger.Warn("⚠️ Event update rejected", "event_id", ID.String(), "error", err)
+			c.reject(msg, "invalid event parameters: "+err.Error())
+			return
+		}
+
+	case "QueryEvent":
+		info, err := c.Exchange.EventInfo(ID)
+		if err != nil {
+			info = &wire.EventInfo{EventID: ID, Error: err.Error()}
+		}
+		c.reply(msg, contentType, info)
 
 	case "Settlement":
-		logger.Info("💰 Processing settlement", "event_id", ID.String())
-		c.Exchange.Settlement(ID)
+		if orderMsg.WinningOutcome == nil {
+			c.reject(msg, "settlement needs a winningOutcome")
+			return
+		}
+		logger.Info("💰 Processing settlement", "event_id", ID.String(), "winning_outcome", *orderMsg.WinningOutcome)
+		if err := c.Exchange.Settlement(ID, *orderMsg.WinningOutcome); err != nil {
+			logger.Warn("⚠️ Settlement rejected", "event_id", ID.String(), "error", err)
+			c.reject(msg, err.Error())
+			return
+		}
+
+	case "QueryUsage":
+		userID, ok := new(big.Int).SetString(orderMsg.OrderUserID, 10)
+		if !ok {
+			c.reject(msg, "invalid userId: "+orderMsg.OrderUserID)
+			return
+		}
+
+		report, err := c.Exchange.UsageReport(ID, orderMsg.Outcome, userID)
+		if err != nil {
+			report = &wire.UsageReport{EventID: ID, Outcome: orderMsg.Outcome, UserID: userID, Error: err.Error()}
+		}
+		c.reply(msg, contentType, report)
+
+	case "BatchOrder":
+		batchID := orderMsg.BatchID
+		if batchID == "" {
+			batchID = uniqueid.GenerateBaseId().String()
+		}
+		for i := range orderMsg.Orders {
+			if orderMsg.Orders[i].Tier == "" {
+				orderMsg.Orders[i].Tier = orderMsg.Tier
+			}
+		}
+		logger.Info("📦 Processing order batch", "event_id", ID.String(), "outcome", orderMsg.Outcome, "batch_id", batchID, "orders", len(orderMsg.Orders))
+		c.Exchange.AddBatch(ID, orderMsg.Outcome, batchID, orderMsg.Orders, orderMsg.BatchAllOrNone)
 
 	case "Order":
 		orderID := new(big.Int)
 		if err := orderID.UnmarshalText([]byte(orderMsg.OrderID)); err != nil {
 			logger.Error("❌ Failed to convert orderMsg.OrderID to big.Int", "error", err, "event", orderMsg)
+			c.reject(msg, "invalid orderId: "+orderMsg.OrderID)
 			return
 		}
 
 		OrderUserID := new(big.Int)
 		if err := OrderUserID.UnmarshalText([]byte(orderMsg.OrderUserID)); err != nil {
 			logger.Error("❌ Failed to convert orderMsg.OrderUserID to big.Int", "error", err, "event", orderMsg)
+			c.reject(msg, "invalid userId: "+orderMsg.OrderUserID)
 			return
 		}
 
-		switch orderMsg.Type {
-		case "BUY":
-			c.Exchange.AddBuyOrder(ID, orderID, orderMsg.OrderPrice, orderMsg.OrderQuantity, OrderUserID)
-		case "SELL":
-			c.Exchange.AddSellOrder(ID, orderID, orderMsg.OrderPrice, orderMsg.OrderQuantity, OrderUserID)
-		default:
-			logger.Warn("⚠️ Unknown order type", "order_type", orderMsg.Type)
-			msg.Nack(false, false)
+		req := orderbook.OrderRequest{
+			Side:            orderMsg.Type,
+			OrderID:         orderID,
+			Price:           orderMsg.OrderPrice,
+			Quantity:        orderMsg.OrderQuantity,
+			UserID:          OrderUserID,
+			DisplayQuantity: orderMsg.DisplayQuantity,
+			Tier:            orderMsg.Tier,
+			PostOnly:        orderMsg.PostOnly,
+			PostOnlyReprice: orderMsg.PostOnlyReprice,
+			MinQty:          orderMsg.MinQty,
+			AllOrNone:       orderMsg.AllOrNone,
+			Outcome:         orderMsg.Outcome,
+		}
+		if err := exchange.ValidateOrder(req); err != nil {
+			logger.Warn("⚠️ Invalid order", "error", err, "event", orderMsg)
+			c.reject(msg, err.Error())
+			return
+		}
+
+		c.Exchange.AddOrder(ID, req)
+
+	case "CancelOrder", "ModifyOrder":
+		orderID, ok := new(big.Int).SetString(orderMsg.OrderID, 10)
+		if !ok {
+			c.reject(msg, "invalid orderId: "+orderMsg.OrderID)
+			return
+		}
+		userID, ok := new(big.Int).SetString(orderMsg.OrderUserID, 10)
+		if !ok {
+			c.reject(msg, "invalid userId: "+orderMsg.OrderUserID)
+			return
+		}
+
+		if orderMsg.Task == "CancelOrder" {
+			c.Exchange.CancelOrder(ID, orderID, userID)
+		} else {
+			c.Exchange.ModifyOrder(ID, orderbook.ModifyRequest{
+				OrderID:  orderID,
+				UserID:   userID,
+				Price:    orderMsg.OrderPrice,
+				Quantity: orderMsg.OrderQuantity,
+				Tier:     orderMsg.Tier,
+			})
+		}
+
+	case "Uncross":
+		logger.Info("🔔 Uncrossing opening auction", "event_id", ID.String())
+		if err := c.Exchange.Uncross(ID); err != nil {
+			logger.Warn("⚠️ Uncross rejected", "event_id", ID.String(), "error", err)
+			c.reject(msg, err.Error())
+			return
+		}
+
+	case "MassCancel":
+		c.massCancel(msg, contentType, orderMsg, ID)
+		return
+
+	case "MintSet", "MergeSet":
+		userID, ok := new(big.Int).SetString(orderMsg.OrderUserID, 10)
+		if !ok {
+			c.reject(msg, "invalid userId: "+orderMsg.OrderUserID)
+			return
+		}
+		action := exchange.ActionMint
+		if orderMsg.Task == "MergeSet" {
+			action = exchange.ActionMerge
+		}
+
+		logger.Info("🧩 Processing complete sets", "event_id", ID.String(), "user_id", userID.String(), "action", action, "quantity", orderMsg.OrderQuantity)
+		report := c.Exchange.CompleteSet(exchange.CompleteSetRequest{
+			EventID:  ID,
+			UserID:   userID,
+			Action:   action,
+			Quantity: orderMsg.OrderQuantity,
+			Tier:     orderMsg.Tier,
+		})
+		if msg.ReplyTo != "" {
+			c.reply(msg, contentType, report)
+		}
+
+	case "MoveEvent":
+		logger.Info("🚚 Moving event", "event_id", ID.String(), "to", orderMsg.Partition)
+		if err := c.moveEvent(ID, orderMsg.Partition, contentType); err != nil {
+			logger.Error("❌ Event handoff failed", "event_id", ID.String(), "error", err)
+			c.reject(msg, "handoff failed: "+err.Error())
+			return
+		}
+
+	case "AdoptEvent":
+		logger.Info("🤝 Adopting event", "event_id", ID.String())
+		if err := c.adoptEvent(ID, orderMsg); err != nil {
+			logger.Error("❌ Failed to adopt event", "event_id", ID.String(), "error", err)
+			c.reject(msg, "adopt failed: "+err.Error())
 			return
 		}
 
 	default:
 		logger.Warn("⚠️ Unknown task type", "task_type", orderMsg.Task)
-		msg.Nack(false, false)
+		c.reject(msg, "unknown task type: "+orderMsg.Task)
 		return
 	}
 
 	msg.Ack(false) // Acknowledge message
 }
+
+// massCancel runs a MassCancel task for eventID, or every owned event when
+// nil, and answers with the summary if the request asked for a reply
+func (c *RabbitMQQueue) massCancel(msg amqp.Delivery, contentType string, orderMsg EventMessage, eventID *big.Int) {
+	req := exchange.MassCancelRequest{EventID: eventID, Side: orderMsg.Type}
+	if orderMsg.OrderUserID != "" {
+		userID, ok := new(big.Int).SetString(orderMsg.OrderUserID, 10)
+		if !ok {
+			c.reject(msg, "invalid userId: "+orderMsg.OrderUserID)
+			return
+		}
+		req.UserID = userID
+	}
+
+	logger.Info("🧹 Processing mass cancel", "event_id", orderMsg.ID, "user_id", orderMsg.OrderUserID, "side", orderMsg.Type)
+	summary := c.Exchange.MassCancel(req)
+	if msg.ReplyTo != "" {
+		c.reply(msg, contentType, summary)
+	}
+	msg.Ack(false)
+}
@@ -0,0 +1,277 @@
+// dummyengine/pkg/rabbitmqQueue/standby.go
+package rabbitmqQueue
+
+import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/confirm"
+	"dummyengine/pkg/journal"
+	"dummyengine/pkg/logger"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// journalTimeout bounds the wait for the broker to confirm a journal record
+const journalTimeout = 10 * time.Second
+
+// receivedHeader carries the time an input was first received, in Unix nanoseconds
+const receivedHeader = "x-engine-received-at"
+
+// recentLimit is how many journaled bodies are remembered to skip redeliveries;
+// it only has to exceed the prefetch
+const recentLimit = 10000
+
+// discard acknowledges nothing; replayed journal entries have no delivery of their own
+type discard struct{}
+
+func (discard) Ack(tag uint64, multiple bool) error           { return nil }
+func (discard) Nack(tag uint64, multiple, requeue bool) error { return nil }
+func (discard) Reject(tag uint64, requeue bool) error         { return nil }
+
+// replaying reports whether messages are being applied from the journal
+// with publishing off
+func (c *RabbitMQQueue) replaying() bool {
+	return c.Exchange.Silent()
+}
+
+// handle journals a delivery before processing it when running as the leader
+// of a primary/standby pair, and processes it directly otherwise
+func (c *RabbitMQQueue) handle(msg amqp.Delivery) {
+	stampReceived(&msg, time.Now())
+	if c.Lease == nil {
+		c.processMessage(msg)
+		return
+	}
+
+	// Already journaled, and so already applied, before the ack was lost
+	if msg.Redelivered && c.recent.Contains(msg.Body) {
+		logger.Warn("♻️ Skipping redelivered message already journaled", "message_id", msg.MessageId)
+		msg.Ack(false)
+		return
+	}
+
+	sequence := c.Exchange.Sequence + 1
+	if err := c.appendJournal(journal.Record(sequence, c.confirmed, msg)); err != nil {
+		logger.Error("❌ Failed to journal message, requeueing", "sequence", sequence, "error", err)
+		msg.Nack(false, true)
+		return
+	}
+	// The wait covered every earlier publish, the previous input's outputs included
+	c.confirmed = c.Exchange.Sequence
+	c.Exchange.Sequence = sequence
+	c.recent.Add(msg.Body)
+
+	c.processMessage(msg)
+}
+
+// stampReceived records when an input arrived in a header that the journal
+// carries along, so that the standby applies it at the same time
+func stampReceived(msg *amqp.Delivery, at time.Time) {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[receivedHeader] = at.UnixNano()
+	msg.Headers = headers
+}
+
+// receivedAt reads the stamp, falling back to now for unstamped inputs
+func receivedAt(msg amqp.Delivery) time.Time {
+	if nanos, ok := msg.Headers[receivedHeader].(int64); ok {
+		return time.Unix(0, nanos)
+	}
+	return time.Now()
+}
+
+// appendJournal publishes a journal record and waits until the broker has it
+func (c *RabbitMQQueue) appendJournal(record amqp.Publishing) error {
+	cfg := config.AppConfig.Standby
+	if err := c.Ch.Publish(cfg.JournalExchange, cfg.JournalRoutingKey, false, false, record); err != nil {
+		return err
+	}
+	confirm.Published(c.Ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), journalTimeout)
+	defer cancel()
+	return c.Confirms.Wait(ctx)
+}
+
+// renew takes or renews the lease, noting when it last succeeded. The time
+// is taken before the attempt so that it never runs ahead of the expiry on file.
+func (c *RabbitMQQueue) renew() (bool, error) {
+	at := time.Now()
+	held, err := c.Lease.Acquire()
+	if held {
+		c.renewed = at
+	}
+	return held, err
+}
+
+// heartbeat renews the lease and moves the standby's confirmed sequence
+// forward. Losing the lease means a standby has taken over, so this instance
+// stops at once rather than publish alongside it. A renewal that keeps
+// failing stops consuming while a heartbeat period of the lease is left,
+// before a standby could take it.
+func (c *RabbitMQQueue) heartbeat() error {
+	held, err := c.renew()
+	if err != nil {
+		if time.Until(c.renewed.Add(c.Lease.TTL)) <= c.Lease.TTL/3 {
+			return fmt.Errorf("leader lease not renewed since %s: %w", c.renewed.Format(time.RFC3339Nano), err)
+		}
+		logger.Error("❌ Failed to renew leader lease", "path", c.Lease.Path, "error", err)
+	} else if !held {
+		logger.Fatal("🚨 Leader lease lost to another instance, stopping", "instance", c.Lease.Holder)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), journalTimeout)
+	defer cancel()
+	if err := c.Confirms.Wait(ctx); err != nil {
+		return err
+	}
+	c.confirmed = c.Exchange.Sequence
+	return c.appendJournal(journal.Heartbeat(c.confirmed))
+}
+
+// Follow runs this instance as a standby: it applies the leader's journal
+// with publishing off, keeping identical books, until it wins the lease. It
+// returns nil once it has taken over and should start consuming orders.
+func (c *RabbitMQQueue) Follow() error {
+	cfg := config.AppConfig.Standby
+	c.Exchange.SetSilent(true)
+
+	connClosed := c.Conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := c.Ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	tag := c.consumerTag + "-journal"
+	deliveries, err := c.Ch.Consume(
+		cfg.JournalQueue,
+		tag,
+		false, // autoAck
+		true,  // exclusive: one follower per journal
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("👀 Following leader journal", "queue", cfg.JournalQueue, "sequence", c.Exchange.Sequence)
+
+	retry := time.NewTicker(c.Lease.TTL / 3)
+	defer retry.Stop()
+
+	for {
+		if acquired, err := c.renew(); err != nil {
+			logger.Error("❌ Failed to check leader lease", "path", c.Lease.Path, "error", err)
+		} else if acquired {
+			return c.takeOver(tag, deliveries)
+		}
+
+		select {
+		case amqpErr := <-connClosed:
+			return closeReason("connection", amqpErr)
+		case amqpErr := <-chClosed:
+			return closeReason("channel", amqpErr)
+		case <-c.stop:
+			return errors.New("stopped while following")
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("journal delivery channel closed")
+			}
+			c.follow(d)
+		case <-retry.C:
+		}
+	}
+}
+
+// follow queues a journal entry and applies whatever the leader has confirmed
+func (c *RabbitMQQueue) follow(d amqp.Delivery) {
+	entry, err := journal.Parse(d)
+	if err != nil {
+		logger.Error("❌ Dropping malformed journal record", "error", err)
+		d.Ack(false)
+		return
+	}
+
+	if entry.Heartbeat {
+		d.Ack(false)
+	} else {
+		last := c.Exchange.Sequence
+		if n := len(c.pending); n > 0 {
+			last = c.pending[n-1].Sequence
+		}
+		if entry.Sequence <= last {
+			d.Ack(false) // redelivered after a standby restart
+			return
+		}
+		if entry.Sequence != last+1 {
+			logger.Error("🚨 Gap in leader journal", "expected", last+1, "got", entry.Sequence)
+		}
+		c.pending = append(c.pending, entry)
+	}
+	c.applyThrough(entry.Confirmed)
+}
+
+// applyThrough applies pending entries up to and including sequence
+func (c *RabbitMQQueue) applyThrough(sequence uint64) {
+	for len(c.pending) > 0 && c.pending[0].Sequence <= sequence {
+		entry := c.pending[0]
+		c.pending = c.pending[1:]
+
+		replay := entry.Delivery
+		replay.Acknowledger = discard{}
+		c.Exchange.Sequence = entry.Sequence
+		c.recent.Add(replay.Body)
+		c.processMessage(replay)
+
+		entry.Delivery.Ack(false)
+	}
+}
+
+// takeOver finishes the journal and becomes the leader. Entries the old
+// leader confirmed are applied silently; the rest may never have had their
+// outputs published, so they are applied with publishing back on. Outputs
+// are therefore at-least-once across a failover.
+func (c *RabbitMQQueue) takeOver(tag string, deliveries <-chan amqp.Delivery) error {
+	cfg := config.AppConfig.Standby
+	logger.Info("👑 Won leader lease, taking over", "instance", c.Lease.Holder, "sequence", c.Exchange.Sequence)
+
+	if err := c.Ch.Cancel(tag, false); err != nil {
+		return err
+	}
+	for d := range deliveries {
+		c.follow(d)
+	}
+	for {
+		d, ok, err := c.Ch.Get(cfg.JournalQueue, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		c.follow(d)
+	}
+
+	c.promote()
+	return nil
+}
+
+// promote applies the entries the old leader never confirmed, publishing
+// their outputs, and makes this instance the leader
+func (c *RabbitMQQueue) promote() {
+	unconfirmed := len(c.pending)
+	c.Exchange.SetSilent(false)
+	c.applyThrough(math.MaxUint64)
+	c.leader.Store(true)
+	if c.OnLead != nil {
+		c.OnLead()
+	}
+
+	logger.Info("✅ Took over as leader", "sequence", c.Exchange.Sequence, "republished", unconfirmed)
+}
@@ -0,0 +1,183 @@
+package rabbitmqQueue
+
+import (
+	"dummyengine/pkg/exchange"
+	"dummyengine/pkg/journal"
+	"dummyengine/pkg/leader"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// acks counts acknowledgements of journal deliveries
+type acks struct{ n int }
+
+func (a *acks) Ack(tag uint64, multiple bool) error           { a.n++; return nil }
+func (a *acks) Nack(tag uint64, multiple, requeue bool) error { return nil }
+func (a *acks) Reject(tag uint64, requeue bool) error         { return nil }
+
+func newFollower() *RabbitMQQueue {
+	c := &RabbitMQQueue{
+		Exchange: exchange.NewExchange(nil, "trade", "price", "book"),
+		recent:   journal.NewRecent(recentLimit),
+	}
+	c.Exchange.SetSilent(true)
+	return c
+}
+
+// record builds the journal delivery of an input as the leader writes it
+func record(t *testing.T, a *acks, sequence, confirmed uint64, msg EventMessage) amqp.Delivery {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := journal.Record(sequence, confirmed, amqp.Delivery{ContentType: "application/json", Body: body})
+	return amqp.Delivery{Acknowledger: a, Headers: p.Headers, ContentType: p.ContentType, Body: p.Body}
+}
+
+func heartbeat(a *acks, confirmed uint64) amqp.Delivery {
+	return amqp.Delivery{Acknowledger: a, Headers: journal.Heartbeat(confirmed).Headers}
+}
+
+var (
+	create = EventMessage{Task: "CreateEvent", ID: "7"}
+	bid    = EventMessage{Task: "Order", ID: "7", OrderID: "1", OrderUserID: "1", OrderPrice: 400, OrderQuantity: 2, Type: "BUY"}
+	ask    = EventMessage{Task: "Order", ID: "7", OrderID: "2", OrderUserID: "2", OrderPrice: 450, OrderQuantity: 1, Type: "SELL"}
+)
+
+func TestFollowAppliesOnlyConfirmedInput(t *testing.T) {
+	c, a := newFollower(), &acks{}
+
+	// Nothing is applied, or acked, before the leader has confirmed it
+	c.follow(record(t, a, 1, 0, create))
+	c.follow(record(t, a, 2, 0, bid))
+	if c.Exchange.Sequence != 0 || len(c.Exchange.OrderBooks) != 0 || a.n != 0 {
+		t.Fatalf("applied unconfirmed input: sequence %d, %d books, %d acks", c.Exchange.Sequence, len(c.Exchange.OrderBooks), a.n)
+	}
+
+	// The next record confirms the one before it
+	c.follow(record(t, a, 3, 1, ask))
+	if c.Exchange.Sequence != 1 || c.Exchange.OrderBooks["7"] == nil || len(c.pending) != 2 {
+		t.Fatalf("sequence %d, %d pending, want the event created and 2 pending", c.Exchange.Sequence, len(c.pending))
+	}
+
+	// A heartbeat confirms the rest while the leader is idle
+	c.follow(heartbeat(a, 3))
+	if c.Exchange.Sequence != 3 || len(c.pending) != 0 || a.n != 4 {
+		t.Fatalf("sequence %d, %d pending, %d acks after the heartbeat", c.Exchange.Sequence, len(c.pending), a.n)
+	}
+	ob := c.Exchange.OrderBooks["7"]
+	if ob.GetTopBuyOrder() == nil || ob.GetTopSellOrder() == nil {
+		t.Error("followed orders not resting")
+	}
+	if !ob.Silent {
+		t.Error("a standby's book would publish")
+	}
+}
+
+func TestFollowSkipsRedeliveredRecords(t *testing.T) {
+	c, a := newFollower(), &acks{}
+	c.follow(record(t, a, 1, 0, create))
+	c.follow(record(t, a, 2, 1, bid))
+
+	// After a standby restart the broker hands back records already taken,
+	// both applied and pending
+	c.follow(record(t, a, 1, 0, create))
+	c.follow(record(t, a, 2, 1, bid))
+	if len(c.pending) != 1 || c.pending[0].Sequence != 2 {
+		t.Fatalf("pending %+v, want only sequence 2", c.pending)
+	}
+	c.follow(heartbeat(a, 2))
+	if level := c.Exchange.OrderBooks["7"].GetTopBuyOrder(); level == nil || level.Quantity != 2 {
+		t.Errorf("bid level %v, want the order applied once", level)
+	}
+}
+
+func TestPromoteAppliesUnconfirmedInputAndPublishes(t *testing.T) {
+	c, a := newFollower(), &acks{}
+	c.follow(record(t, a, 1, 0, create))
+	c.follow(record(t, a, 2, 1, bid))
+	c.follow(record(t, a, 3, 1, ask))
+
+	// The old leader died before confirming 2 and 3; the new one applies them
+	// with publishing on, as their outputs may never have gone out
+	c.promote()
+	if !c.leader.Load() || c.Exchange.Silent() || c.Exchange.OrderBooks["7"].Silent {
+		t.Fatal("promoted instance still silent")
+	}
+	if c.Exchange.Sequence != 3 || len(c.pending) != 0 {
+		t.Errorf("sequence %d, %d pending, want everything applied", c.Exchange.Sequence, len(c.pending))
+	}
+
+	// Input the leader consumes again after the failover is not applied twice
+	if !c.recent.Contains(record(t, a, 0, 0, bid).Body) {
+		t.Error("followed input not remembered for redeliveries")
+	}
+}
+
+func TestReceivedTimeFollowsTheJournal(t *testing.T) {
+	at := time.Unix(1700000000, 123)
+	msg := amqp.Delivery{Headers: amqp.Table{"x-trace": "abc"}, Body: []byte("{}")}
+	stamped := msg
+	stampReceived(&stamped, at)
+	if _, leaked := msg.Headers[receivedHeader]; leaked {
+		t.Error("stamp written into the broker's headers")
+	}
+
+	// The standby reads back the leader's time, not its own
+	p := journal.Record(1, 0, stamped)
+	entry, err := journal.Parse(amqp.Delivery{Headers: p.Headers, Body: p.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := receivedAt(entry.Delivery); !got.Equal(at) {
+		t.Errorf("received at %v, want %v", got, at)
+	}
+}
+
+func TestHeartbeatStopsBeforeTheLeaseExpires(t *testing.T) {
+	// A lease file under a regular file can never be written
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ttl := 3 * time.Second
+	c := newFollower()
+	c.Lease = leader.NewLease(filepath.Join(blocker, "lease.json"), "primary", ttl)
+
+	// Two periods into the lease only one is left, too little to try again
+	c.renewed = time.Now().Add(-2 * ttl / 3)
+	if err := c.heartbeat(); err == nil {
+		t.Fatal("kept consuming with the lease about to expire")
+	}
+}
+
+func TestRenewNotesOnlySuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	c := newFollower()
+	c.Lease = leader.NewLease(path, "standby", time.Minute)
+	if ok, _ := leader.NewLease(path, "primary", time.Minute).Acquire(); !ok {
+		t.Fatal("primary could not take the lease")
+	}
+
+	if held, err := c.renew(); held || err != nil || !c.renewed.IsZero() {
+		t.Fatalf("renew = %v, %v with renewed %v, want the primary's lease left alone", held, err, c.renewed)
+	}
+
+	before := time.Now()
+	c.Lease.Holder = "primary"
+	if held, err := c.renew(); !held || err != nil {
+		t.Fatalf("renew = %v, %v", held, err)
+	}
+	if c.renewed.Before(before) {
+		t.Errorf("renewed %v, want at least %v", c.renewed, before)
+	}
+	if _, expires, _ := c.Lease.Current(); expires.Before(c.renewed.Add(time.Minute)) {
+		t.Errorf("lease expires %v, before the renewal time plus the TTL", expires)
+	}
+}
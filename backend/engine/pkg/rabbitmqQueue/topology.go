@@ -0,0 +1,134 @@
+// dummyengine/pkg/rabbitmqQueue/topology.go
+package rabbitmqQueue
+
+import (
+	"context"
+	"dummyengine/pkg/config"
+	"dummyengine/pkg/confirm"
+	"dummyengine/pkg/deadletter"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/uniqueid"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// DeclareTopology declares the exchanges, queues and bindings listed in the
+// configuration, plus the dead-letter exchange and queue. Declarations are
+// idempotent, so this runs on every (re)connect.
+func DeclareTopology(ch *amqp.Channel, cfg config.RabbitMQConfig) error {
+	if err := ch.ExchangeDeclare(cfg.DeadLetterExchange, "direct", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter exchange %q: %w", cfg.DeadLetterExchange, err)
+	}
+	if _, err := ch.QueueDeclare(cfg.DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter queue %q: %w", cfg.DeadLetterQueue, err)
+	}
+	if err := ch.QueueBind(cfg.DeadLetterQueue, cfg.DeadLetterRoutingKey, cfg.DeadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("bind dead-letter queue %q: %w", cfg.DeadLetterQueue, err)
+	}
+
+	for _, ex := range cfg.Exchanges {
+		if err := ch.ExchangeDeclare(ex.Name, ex.Kind, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("declare exchange %q: %w", ex.Name, err)
+		}
+	}
+
+	for _, q := range cfg.Queues {
+		var args amqp.Table
+		if q.DeadLetter {
+			args = amqp.Table{
+				"x-dead-letter-exchange":    cfg.DeadLetterExchange,
+				"x-dead-letter-routing-key": cfg.DeadLetterRoutingKey,
+			}
+		}
+		if _, err := ch.QueueDeclare(q.Name, true, false, false, false, args); err != nil {
+			return fmt.Errorf("declare queue %q: %w", q.Name, err)
+		}
+	}
+
+	for _, b := range cfg.Bindings {
+		if err := ch.QueueBind(b.Queue, b.RoutingKey, b.Exchange, false, nil); err != nil {
+			return fmt.Errorf("bind queue %q to %q: %w", b.Queue, b.Exchange, err)
+		}
+	}
+
+	logger.Info("🧭 RabbitMQ topology declared",
+		"exchanges", len(cfg.Exchanges),
+		"queues", len(cfg.Queues),
+		"bindings", len(cfg.Bindings),
+		"dlq", cfg.DeadLetterQueue)
+	return nil
+}
+
+// deadLetterTimeout bounds the wait for the broker to confirm a parked message
+const deadLetterTimeout = 10 * time.Second
+
+// reject moves a message to the dead-letter queue with a reason header and
+// acks the original once the broker confirms the copy. If the republish or
+// its confirm fails it falls back to a plain nack, which the broker still
+// dead-letters through the queue arguments.
+func (c *RabbitMQQueue) reject(msg amqp.Delivery, reason string) {
+	// In-process submissions hand the reason straight back to the caller
+	if local, ok := msg.Acknowledger.(*localAck); ok {
+		logger.Warn("⚠️ Submitted message rejected", "reason", reason)
+		local.finish(&RejectedError{Reason: reason})
+		return
+	}
+
+	// The leader already dead-lettered it
+	if c.replaying() {
+		logger.Warn("🪦 Replayed message rejected", "reason", reason)
+		msg.Ack(false)
+		return
+	}
+
+	cfg := config.AppConfig.RabbitMQ
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[deadletter.ReasonHeader] = reason
+	headers[deadletter.OriginalQueueHeader] = c.Queue
+	headers[deadletter.RejectedAtHeader] = time.Now().Unix()
+
+	// Parked messages need a stable handle for the dlq tool
+	messageID := msg.MessageId
+	if messageID == "" {
+		messageID = uniqueid.GenerateBaseId().String()
+	}
+
+	err := c.Ch.Publish(
+		cfg.DeadLetterExchange,
+		cfg.DeadLetterRoutingKey,
+		false, // Mandatory
+		false, // Immediate
+		amqp.Publishing{
+			ContentType:  msg.ContentType,
+			MessageId:    messageID,
+			Headers:      headers,
+			Body:         msg.Body,
+			Timestamp:    time.Now(),
+			DeliveryMode: amqp.Persistent,
+		},
+	)
+	if err != nil {
+		logger.Error("❌ Failed to dead-letter message, nacking instead", "reason", reason, "error", err)
+		msg.Nack(false, false)
+		return
+	}
+	confirm.Published(c.Ch)
+
+	// The original is acked only once the parked copy is safe with the broker
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+	if err := c.Confirms.Wait(ctx); err != nil {
+		logger.Error("❌ Dead-lettered copy not confirmed, nacking instead", "reason", reason, "error", err)
+		msg.Nack(false, false)
+		return
+	}
+
+	logger.Warn("🪦 Message dead-lettered", "message_id", messageID, "reason", reason, "dlq", cfg.DeadLetterQueue)
+	msg.Ack(false)
+}
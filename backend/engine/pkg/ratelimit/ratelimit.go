@@ -0,0 +1,220 @@
+// dummyengine/pkg/ratelimit/ratelimit.go
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTier applies to users whose orders name no tier or an unknown one
+const DefaultTier = "default"
+
+// pruneEvery is how many checks pass between sweeps of idle buckets
+const pruneEvery = 4096
+
+// Tier sets the order rates of one class of users: UserRate across all
+// events and EventRate within any single event, in orders per second, each
+// allowing bursts of up to the matching Burst. A zero rate is unlimited.
+type Tier struct {
+	UserRate   float64 `json:"userRate"`
+	UserBurst  int     `json:"userBurst"`
+	EventRate  float64 `json:"eventRate"`
+	EventBurst int     `json:"eventBurst"`
+}
+
+func (t Tier) Validate() error {
+	if t.UserRate < 0 || t.EventRate < 0 || t.UserBurst < 0 || t.EventBurst < 0 {
+		return fmt.Errorf("invalid rate tier: %+v", t)
+	}
+	return nil
+}
+
+// ThrottledError rejects orders over their owner's rate
+type ThrottledError struct {
+	Scope string // "user" || "event"
+	Tier  string
+	Rate  float64
+	Burst int
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("rate limited: %s rate of %g orders/s (burst %d) exceeded for tier %s", e.Scope, e.Rate, e.Burst, e.Tier)
+}
+
+// Counters count the decisions taken for one tier
+type Counters struct {
+	Allowed        uint64 `json:"allowed"`
+	ThrottledUser  uint64 `json:"throttledUser"`
+	ThrottledEvent uint64 `json:"throttledEvent"`
+}
+
+// Stats is the limiter's state as exposed for monitoring
+type Stats struct {
+	Tiers        map[string]Counters `json:"tiers"`
+	UserBuckets  int                 `json:"userBuckets"`
+	EventBuckets int                 `json:"eventBuckets"`
+}
+
+// Limiter keeps a token bucket per user and per user and event. Time is
+// passed in rather than read from the clock, so replaying the same inputs
+// with the same times gives the same decisions.
+type Limiter struct {
+	Tiers map[string]Tier
+
+	mu       sync.Mutex // the consumer decides, monitoring reads Stats
+	users    map[string]*bucket
+	events   map[string]*bucket // user + "/" + event
+	counters map[string]*Counters
+	checks   int
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	rate     float64 // as of the last refill
+	capacity float64
+}
+
+func NewLimiter(tiers map[string]Tier) (*Limiter, error) {
+	for name, tier := range tiers {
+		if err := tier.Validate(); err != nil {
+			return nil, fmt.Errorf("tier %s: %w", name, err)
+		}
+	}
+	return &Limiter{
+		Tiers:    tiers,
+		users:    make(map[string]*bucket),
+		events:   make(map[string]*bucket),
+		counters: make(map[string]*Counters),
+	}, nil
+}
+
+// Check reports whether the user may place n more orders in the event at
+// time at, without using up any tokens. A refusal is counted as throttled.
+func (l *Limiter) Check(userID, eventID, tier string, n int, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _, err := l.check(userID, eventID, tier, n, at)
+	return err
+}
+
+// Take is Check followed, when allowed, by spending n tokens from both buckets
+func (l *Limiter) Take(userID, eventID, tier string, n int, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	user, event, err := l.check(userID, eventID, tier, n, at)
+	if err != nil {
+		return err
+	}
+	name, t := l.tier(tier)
+
+	if t.UserRate > 0 {
+		user.tokens -= float64(n)
+	}
+	if t.EventRate > 0 {
+		event.tokens -= float64(n)
+	}
+	l.counter(name).Allowed += uint64(n)
+
+	if l.checks++; l.checks%pruneEvery == 0 {
+		l.prune(at)
+	}
+	return nil
+}
+
+// Stats returns the counters per tier and the number of tracked buckets
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := Stats{
+		Tiers:        make(map[string]Counters, len(l.counters)),
+		UserBuckets:  len(l.users),
+		EventBuckets: len(l.events),
+	}
+	for name, c := range l.counters {
+		stats.Tiers[name] = *c
+	}
+	return stats
+}
+
+// check refills the user's buckets to at and tests them for n tokens,
+// counting a refusal; callers hold mu
+func (l *Limiter) check(userID, eventID, tier string, n int, at time.Time) (*bucket, *bucket, error) {
+	name, t := l.tier(tier)
+
+	var user, event *bucket
+	if t.UserRate > 0 {
+		user = refill(l.users, userID, t.UserRate, t.UserBurst, at)
+		if user.tokens < float64(n) {
+			l.counter(name).ThrottledUser += uint64(n)
+			return nil, nil, &ThrottledError{Scope: "user", Tier: name, Rate: t.UserRate, Burst: burst(t.UserRate, t.UserBurst)}
+		}
+	}
+	if t.EventRate > 0 {
+		event = refill(l.events, userID+"/"+eventID, t.EventRate, t.EventBurst, at)
+		if event.tokens < float64(n) {
+			l.counter(name).ThrottledEvent += uint64(n)
+			return nil, nil, &ThrottledError{Scope: "event", Tier: name, Rate: t.EventRate, Burst: burst(t.EventRate, t.EventBurst)}
+		}
+	}
+	return user, event, nil
+}
+
+// tier resolves a tier name, falling back to DefaultTier
+func (l *Limiter) tier(name string) (string, Tier) {
+	if t, ok := l.Tiers[name]; ok && name != "" {
+		return name, t
+	}
+	return DefaultTier, l.Tiers[DefaultTier]
+}
+
+func (l *Limiter) counter(name string) *Counters {
+	c, ok := l.counters[name]
+	if !ok {
+		c = &Counters{}
+		l.counters[name] = c
+	}
+	return c
+}
+
+// prune forgets buckets that have refilled completely, which behave exactly
+// like new ones; callers hold mu
+func (l *Limiter) prune(at time.Time) {
+	for _, buckets := range []map[string]*bucket{l.users, l.events} {
+		for key, b := range buckets {
+			if b.tokens+at.Sub(b.last).Seconds()*b.rate >= b.capacity {
+				delete(buckets, key)
+			}
+		}
+	}
+}
+
+// refill tops a bucket up for the time elapsed since it was last seen. Time
+// never runs backwards for a bucket, so inputs received out of order cannot
+// mint tokens.
+func refill(buckets map[string]*bucket, key string, rate float64, size int, at time.Time) *bucket {
+	capacity := float64(burst(rate, size))
+	b, ok := buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: at}
+		buckets[key] = b
+	}
+	b.rate, b.capacity = rate, capacity
+	if elapsed := at.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		b.last = at
+	}
+	b.tokens = min(capacity, b.tokens)
+	return b
+}
+
+// burst is the bucket size, at least one order and by default one second's worth
+func burst(rate float64, size int) int {
+	if size > 0 {
+		return size
+	}
+	return max(1, int(rate))
+}
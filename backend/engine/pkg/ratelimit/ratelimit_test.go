@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+func newLimiter(t *testing.T, tiers map[string]Tier) *Limiter {
+	t.Helper()
+	l, err := NewLimiter(tiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func throttledScope(err error) string {
+	var throttled *ThrottledError
+	if errors.As(err, &throttled) {
+		return throttled.Scope
+	}
+	return ""
+}
+
+func TestEventThrottleSpendsNoUserTokens(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{DefaultTier: {UserRate: 1, UserBurst: 3, EventRate: 1, EventBurst: 1}})
+
+	if err := l.Take("u1", "e1", "", 1, start); err != nil {
+		t.Fatal(err)
+	}
+	// Refused by the event bucket after passing the user one: nothing is spent
+	for i := 0; i < 5; i++ {
+		if scope := throttledScope(l.Take("u1", "e1", "", 1, start)); scope != "event" {
+			t.Fatalf("retry %d: scope %q, want event", i, scope)
+		}
+	}
+	// so the user still has 2 of 3 for other events
+	if err := l.Take("u1", "e2", "", 1, start); err != nil {
+		t.Error(err)
+	}
+	if err := l.Take("u1", "e3", "", 1, start); err != nil {
+		t.Error(err)
+	}
+	if scope := throttledScope(l.Take("u1", "e4", "", 1, start)); scope != "user" {
+		t.Errorf("scope %q, want user", scope)
+	}
+}
+
+func TestRefill(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{DefaultTier: {UserRate: 2, UserBurst: 2}})
+	if err := l.Take("u1", "e1", "", 2, start); err != nil {
+		t.Fatal(err)
+	}
+
+	// Half a token is not an order
+	if err := l.Take("u1", "e1", "", 1, start.Add(250*time.Millisecond)); err == nil {
+		t.Error("order allowed on half a token")
+	}
+	if err := l.Take("u1", "e1", "", 1, start.Add(500*time.Millisecond)); err != nil {
+		t.Error(err)
+	}
+
+	// A long pause refills only up to the burst
+	if err := l.Take("u1", "e1", "", 3, start.Add(time.Hour)); err == nil {
+		t.Error("more than the burst allowed after a pause")
+	}
+	if err := l.Take("u1", "e1", "", 2, start.Add(time.Hour)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOutOfOrderTimesMintNothing(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{DefaultTier: {UserRate: 1, UserBurst: 1}})
+	if err := l.Take("u1", "e1", "", 1, start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	// An input stamped earlier than the last one does not refill, and does
+	// not move the bucket's clock back for the next one either
+	if err := l.Take("u1", "e1", "", 1, start); err == nil {
+		t.Error("order allowed at an earlier time")
+	}
+	if err := l.Take("u1", "e1", "", 1, start.Add(1500*time.Millisecond)); err == nil {
+		t.Error("refilled from the earlier time")
+	}
+	if err := l.Take("u1", "e1", "", 1, start.Add(2*time.Second)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckSpendsNothing(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{DefaultTier: {UserRate: 1, UserBurst: 2}})
+	for i := 0; i < 3; i++ {
+		if err := l.Check("u1", "e1", "", 2, start); err != nil {
+			t.Fatalf("check %d: %v", i, err)
+		}
+	}
+	if err := l.Check("u1", "e1", "", 3, start); err == nil {
+		t.Error("check past the burst allowed")
+	}
+	if got := l.Stats().Tiers[DefaultTier]; got.Allowed != 0 || got.ThrottledUser != 3 {
+		t.Errorf("counters %+v, want only the refusal counted", got)
+	}
+}
+
+func TestTiers(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{
+		DefaultTier: {UserRate: 1},
+		"pro":       {UserRate: 10},
+		"open":      {},
+	})
+
+	// An unknown tier is held to the default one and counted under it
+	if err := l.Take("u1", "e1", "gold", 1, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Take("u1", "e1", "gold", 1, start); err == nil {
+		t.Error("unknown tier got more than the default burst")
+	}
+	// Without a burst a tier gets a second's worth
+	if err := l.Take("u2", "e1", "pro", 10, start); err != nil {
+		t.Error(err)
+	}
+	if err := l.Take("u3", "e1", "open", 100000, start); err != nil {
+		t.Error(err)
+	}
+
+	stats := l.Stats()
+	if _, ok := stats.Tiers["gold"]; ok {
+		t.Error("counters kept for an unknown tier")
+	}
+	if got := stats.Tiers[DefaultTier]; got.Allowed != 1 || got.ThrottledUser != 1 {
+		t.Errorf("default counters %+v", got)
+	}
+	if got := stats.Tiers["open"]; got.Allowed != 100000 {
+		t.Errorf("open counters %+v", got)
+	}
+}
+
+func TestIdleBucketsArePruned(t *testing.T) {
+	l := newLimiter(t, map[string]Tier{
+		DefaultTier: {UserRate: 1, UserBurst: 1, EventRate: 1, EventBurst: 1},
+		"mm":        {UserRate: 1e6, EventRate: 1e6},
+	})
+	if err := l.Take("idle", "e1", "", 1, start); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second later the idle user's buckets are full again and forgotten at
+	// the next sweep; the busy user's, just drawn on, are not
+	at := start.Add(time.Second)
+	for i := 1; i < pruneEvery; i++ {
+		if err := l.Take("busy", "e1", "mm", 1, at); err != nil {
+			t.Fatal(err)
+		}
+		at = at.Add(time.Microsecond)
+	}
+	if stats := l.Stats(); stats.UserBuckets != 1 || stats.EventBuckets != 1 {
+		t.Errorf("%d user and %d event buckets, want only the busy user's", stats.UserBuckets, stats.EventBuckets)
+	}
+}
+
+func TestNewLimiterValidates(t *testing.T) {
+	if _, err := NewLimiter(map[string]Tier{"bad": {EventRate: -1}}); err == nil {
+		t.Error("negative rate accepted")
+	}
+}
@@ -0,0 +1,145 @@
+// dummyengine/pkg/registry/registry.go
+package registry
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/orderbook"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Event states as the engine sees them
+const (
+	StateAuction = "AUCTION" // in the opening auction
+	StateTrading = "TRADING"
+	StateSettled = "SETTLED"
+)
+
+// Entry is one event the engine knows, kept apart from its orders
+type Entry struct {
+	EventID   *big.Int         `json:"eventId"`
+	Params    orderbook.Params `json:"params"`
+	State     string           `json:"state"`
+	UpdatedAt int64            `json:"updatedAt"` // unix seconds
+}
+
+// Registry is the set of events this engine owns, saved to Path on every
+// change so that a restart remembers them even before any order arrives
+type Registry struct {
+	Path string
+	Hold func() bool // while true, changes stay in memory until Flush
+
+	mu      sync.RWMutex
+	entries map[string]Entry // by decimal event ID
+}
+
+// NewRegistry restores the registry from path if it exists
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{Path: path, entries: make(map[string]Entry)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Put records an event, replacing what was known about it
+func (r *Registry) Put(entry Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[entry.EventID.String()] = entry
+	return r.save()
+}
+
+// Remove forgets an event, as when it is handed to another partition
+func (r *Registry) Remove(eventID *big.Int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[eventID.String()]; !ok {
+		return nil
+	}
+	delete(r.entries, eventID.String())
+	return r.save()
+}
+
+// Flush saves the registry, as when a hold is lifted
+func (r *Registry) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save()
+}
+
+// Get returns what is known about an event
+func (r *Registry) Get(eventID *big.Int) (Entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.entries[eventID.String()]
+	return entry, ok
+}
+
+// Entries returns every event in ID order
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].EventID.Cmp(entries[j].EventID) < 0 })
+	return entries
+}
+
+func (r *Registry) load() error {
+	if r.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse event registry %s: %w", r.Path, err)
+	}
+	for _, entry := range entries {
+		if entry.EventID == nil {
+			return fmt.Errorf("parse event registry %s: entry without eventId", r.Path)
+		}
+		r.entries[entry.EventID.String()] = entry
+	}
+	logger.Info("🗂️ Event registry restored", "path", r.Path, "events", len(r.entries))
+	return nil
+}
+
+// save writes the registry atomically through a temp file; callers hold mu
+func (r *Registry) save() error {
+	if r.Path == "" || (r.Hold != nil && r.Hold()) {
+		return nil
+	}
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].EventID.Cmp(entries[j].EventID) < 0 })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	tmp := r.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.Path)
+}
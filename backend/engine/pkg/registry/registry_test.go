@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"dummyengine/pkg/orderbook"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistrySurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "events.json")
+	r, err := NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := orderbook.Params{MatchingPolicy: "PRO_RATA", OpeningAuction: true, AuctionEnd: 1060}
+	for _, id := range []int64{10, 9, 2} {
+		if err := r.Put(Entry{EventID: big.NewInt(id), Params: params, State: StateAuction}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Put(Entry{EventID: big.NewInt(9), Params: params, State: StateTrading}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.Entries()
+	// In numeric order, not the "10" < "9" of their keys
+	if len(entries) != 2 || entries[0].EventID.Int64() != 9 || entries[1].EventID.Int64() != 10 {
+		t.Fatalf("entries %+v, want events 9 and 10", entries)
+	}
+	if entries[0].State != StateTrading || entries[1].State != StateAuction {
+		t.Errorf("states %s and %s, want the latest put", entries[0].State, entries[1].State)
+	}
+	if entries[1].Params != params {
+		t.Errorf("params %+v, want %+v", entries[1].Params, params)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestRemoveUnknownEventWritesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	r, err := NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("registry written for a no-op remove: %v", err)
+	}
+}
+
+func TestNewRegistryRefusesACorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"truncated":  `[{"eventId": 1`,
+		"without id": `[{"state": "TRADING"}]`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewRegistry(path); err == nil {
+			t.Errorf("%s registry loaded", name)
+		}
+	}
+}
+
+func TestRegistryWithoutAPathKeepsNothing(t *testing.T) {
+	r, err := NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Put(Entry{EventID: big.NewInt(1), State: StateTrading}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(big.NewInt(1)); !ok {
+		t.Error("entry not kept in memory")
+	}
+}
@@ -0,0 +1,197 @@
+// dummyengine/pkg/ticker/ticker.go
+package ticker
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Window is the span of the rolling statistics
+const Window = 24 * time.Hour
+
+// bucketSize is the resolution at which trades age out of the window
+const bucketSize = time.Minute
+
+// bucket aggregates the trades of one minute
+type bucket struct {
+	start    int64 // unix seconds
+	volume   int64
+	notional int64
+	high     int
+	low      int
+}
+
+// market is the ticker state of one book: an event, or one outcome of a categorical event
+type market struct {
+	eventID   *big.Int
+	outcome   int
+	payout    int
+	lastPrice int
+	lastQty   int
+	bidPrice  int
+	bidQty    int
+	askPrice  int
+	askQty    int
+	buckets   []bucket // oldest first
+	published wire.Ticker
+}
+
+// Tracker maintains a ticker per book from trades and book updates and
+// publishes it whenever a value changes
+type Tracker struct {
+	Publish func(t *wire.Ticker)
+
+	mu      sync.Mutex
+	markets map[string]*market
+}
+
+func NewTracker(publish func(t *wire.Ticker)) *Tracker {
+	return &Tracker{
+		Publish: publish,
+		markets: make(map[string]*market),
+	}
+}
+
+// OnTrade records an execution; the ticker goes out with the book update that follows it
+func (t *Tracker) OnTrade(eventID *big.Int, outcome, price, quantity int, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := t.market(eventID, outcome)
+	m.lastPrice = price
+	m.lastQty = quantity
+
+	start := at.Truncate(bucketSize).Unix()
+	if n := len(m.buckets); n == 0 || m.buckets[n-1].start != start {
+		m.buckets = append(m.buckets, bucket{start: start, high: price, low: price})
+	}
+	b := &m.buckets[len(m.buckets)-1]
+	b.volume += int64(quantity)
+	b.notional += int64(price) * int64(quantity)
+	b.high = max(b.high, price)
+	b.low = min(b.low, price)
+}
+
+// OnBookUpdate refreshes the best bid and ask and publishes if anything changed
+func (t *Tracker) OnBookUpdate(ob *orderbook.OrderBook) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := t.market(ob.EventID, ob.Outcome)
+	m.payout = ob.Params.Payout
+	m.bidPrice, m.bidQty, m.askPrice, m.askQty = 0, 0, 0, 0
+	if top := ob.GetTopBuyOrder(); top != nil {
+		m.bidPrice, m.bidQty = top.Price, top.Quantity
+	}
+	if top := ob.GetTopSellOrder(); top != nil {
+		m.askPrice, m.askQty = top.Price, top.Quantity
+	}
+	t.publishIfChanged(m, time.Now())
+}
+
+// Get returns the current ticker for an event's outcome book
+func (t *Tracker) Get(eventID *big.Int, outcome int) (wire.Ticker, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.markets[wire.MarketKey(eventID, outcome)]
+	if !ok {
+		return wire.Ticker{}, false
+	}
+	return m.snapshot(time.Now()), true
+}
+
+// Run re-evaluates every ticker each tick so that trades ageing out of the
+// 24h window are published too, until stop is closed
+func (t *Tracker) Run(tick time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			t.mu.Lock()
+			for _, m := range t.markets {
+				t.publishIfChanged(m, now)
+			}
+			t.mu.Unlock()
+		}
+	}
+}
+
+func (t *Tracker) market(eventID *big.Int, outcome int) *market {
+	key := wire.MarketKey(eventID, outcome)
+	m, ok := t.markets[key]
+	if !ok {
+		m = &market{eventID: eventID, outcome: outcome, payout: orderbook.DefaultPayout}
+		t.markets[key] = m
+	}
+	return m
+}
+
+// publishIfChanged publishes m unless it matches what was last sent; callers hold mu
+func (t *Tracker) publishIfChanged(m *market, now time.Time) {
+	next := m.snapshot(now)
+
+	last := m.published
+	next.Timestamp, last.Timestamp = 0, 0
+	if next == last {
+		return
+	}
+
+	next.Timestamp = now.Unix()
+	m.published = next
+	if t.Publish != nil {
+		t.Publish(&next)
+	}
+}
+
+// snapshot expires old buckets and computes the ticker as of now
+func (m *market) snapshot(now time.Time) wire.Ticker {
+	cutoff := now.Add(-Window).Unix()
+	expired := 0
+	for expired < len(m.buckets) && m.buckets[expired].start+int64(bucketSize/time.Second) <= cutoff {
+		expired++
+	}
+	m.buckets = m.buckets[expired:]
+
+	t := wire.Ticker{
+		EventID:         m.eventID,
+		Outcome:         m.outcome,
+		LastPrice:       m.lastPrice,
+		LastQuantity:    m.lastQty,
+		BestBid:         m.bidPrice,
+		BestBidQuantity: m.bidQty,
+		BestAsk:         m.askPrice,
+		BestAskQuantity: m.askQty,
+	}
+
+	var notional int64
+	for i, b := range m.buckets {
+		t.Volume24h += b.volume
+		notional += b.notional
+		if i == 0 || b.high > t.High24h {
+			t.High24h = b.high
+		}
+		if i == 0 || b.low < t.Low24h {
+			t.Low24h = b.low
+		}
+	}
+	if t.Volume24h > 0 {
+		t.VWAP24h = float64(notional) / float64(t.Volume24h)
+	}
+
+	reference := float64(m.lastPrice)
+	if m.lastPrice == 0 && m.bidPrice > 0 && m.askPrice > 0 {
+		reference = float64(m.bidPrice+m.askPrice) / 2
+	}
+	if m.payout > 0 {
+		t.ImpliedProbability = reference / float64(m.payout)
+	}
+	return t
+}
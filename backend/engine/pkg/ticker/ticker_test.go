@@ -0,0 +1,117 @@
+package ticker
+
+import (
+	"dummyengine/pkg/orderbook"
+	"dummyengine/pkg/wire"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var event = big.NewInt(7)
+
+func TestRollingStats(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(nil)
+	tr.OnTrade(event, 0, 400, 10, now.Add(-23*time.Hour))
+	tr.OnTrade(event, 0, 600, 5, now.Add(-2*time.Hour))
+	tr.OnTrade(event, 0, 450, 5, now.Add(-2*time.Hour+10*time.Second)) // same minute
+	tr.OnTrade(event, 0, 500, 20, now.Add(-time.Minute))
+
+	got := tr.market(event, 0).snapshot(now)
+	if got.LastPrice != 500 || got.LastQuantity != 20 {
+		t.Errorf("last %d x %d, want 500 x 20", got.LastPrice, got.LastQuantity)
+	}
+	if got.Volume24h != 40 || got.High24h != 600 || got.Low24h != 400 {
+		t.Errorf("volume %d high %d low %d, want 40, 600, 400", got.Volume24h, got.High24h, got.Low24h)
+	}
+	// (400*10 + 600*5 + 450*5 + 500*20) / 40
+	if want := 481.25; got.VWAP24h != want {
+		t.Errorf("vwap %v, want %v", got.VWAP24h, want)
+	}
+	if n := len(tr.market(event, 0).buckets); n != 3 {
+		t.Errorf("%d buckets, want 3 with the two trades of one minute together", n)
+	}
+}
+
+func TestRollover(t *testing.T) {
+	traded := time.Date(2026, 3, 1, 10, 0, 30, 0, time.UTC)
+	tr := NewTracker(nil)
+	tr.OnTrade(event, 0, 300, 4, traded)
+	tr.OnTrade(event, 0, 700, 1, traded.Add(time.Hour))
+	m := tr.market(event, 0)
+
+	// A trade ages out by whole minutes: its bucket, 10:00 to 10:01, leaves
+	// the window once all of it is more than 24h old
+	if got := m.snapshot(traded.Add(Window + 29*time.Second)); got.Volume24h != 5 || got.Low24h != 300 {
+		t.Errorf("at 10:00:59 the next day volume %d low %d, want 5 and 300", got.Volume24h, got.Low24h)
+	}
+	got := m.snapshot(traded.Add(Window + 30*time.Second))
+	if got.Volume24h != 1 || got.High24h != 700 || got.Low24h != 700 || got.VWAP24h != 700 {
+		t.Errorf("at 10:01 the next day %+v, want only the 700 trade", got)
+	}
+
+	// With everything aged out the 24h stats are empty but the last trade stays
+	got = m.snapshot(traded.Add(2 * Window))
+	if got.Volume24h != 0 || got.High24h != 0 || got.Low24h != 0 || got.VWAP24h != 0 {
+		t.Errorf("empty window %+v", got)
+	}
+	if got.LastPrice != 700 {
+		t.Errorf("last price %d, want 700", got.LastPrice)
+	}
+	if len(m.buckets) != 0 {
+		t.Errorf("%d buckets kept", len(m.buckets))
+	}
+}
+
+func TestPublishesChangesOnly(t *testing.T) {
+	var published []wire.Ticker
+	tr := NewTracker(func(t *wire.Ticker) { published = append(published, *t) })
+
+	ob, err := orderbook.NewOrderBook(nil, event, "trade", "price", "book", orderbook.Params{Payout: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob.AddOrder(orderbook.OrderRequest{Side: "BUY", OrderID: big.NewInt(1), UserID: big.NewInt(1), Price: 40, Quantity: 3})
+	ob.AddOrder(orderbook.OrderRequest{Side: "SELL", OrderID: big.NewInt(2), UserID: big.NewInt(2), Price: 60, Quantity: 2})
+
+	tr.OnBookUpdate(ob)
+	tr.OnBookUpdate(ob)
+	if len(published) != 1 {
+		t.Fatalf("%d tickers for one change", len(published))
+	}
+	first := published[0]
+	if first.BestBid != 40 || first.BestBidQuantity != 3 || first.BestAsk != 60 || first.BestAskQuantity != 2 {
+		t.Errorf("top of book %+v", first)
+	}
+	// Before any trade the implied probability comes from the mid
+	if first.ImpliedProbability != 0.5 {
+		t.Errorf("implied probability %v, want the mid 50 out of 100", first.ImpliedProbability)
+	}
+
+	// A trade goes out with the book update after it, priced off the last trade
+	now := time.Now()
+	tr.OnTrade(event, 0, 60, 1, now)
+	if len(published) != 1 {
+		t.Fatalf("trade published on its own")
+	}
+	tr.OnBookUpdate(ob)
+	if len(published) != 2 || published[1].ImpliedProbability != 0.6 || published[1].Volume24h != 1 {
+		t.Fatalf("tickers %+v", published)
+	}
+
+	// Ageing out is a change too
+	tr.mu.Lock()
+	tr.publishIfChanged(tr.market(event, 0), now.Add(Window+2*time.Minute))
+	tr.publishIfChanged(tr.market(event, 0), now.Add(Window+3*time.Minute))
+	tr.mu.Unlock()
+	if len(published) != 3 || published[2].Volume24h != 0 {
+		t.Errorf("tickers %+v, want one more with an empty window", published)
+	}
+}
+
+func TestGetUnknownEvent(t *testing.T) {
+	if _, ok := NewTracker(nil).Get(event, 0); ok {
+		t.Error("ticker for an event never seen")
+	}
+}
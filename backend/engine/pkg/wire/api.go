@@ -0,0 +1,283 @@
+// dummyengine/pkg/wire/api.go
+package wire
+
+import (
+	"math/big"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Requests and stream messages of the gRPC Engine service, see engine.proto
+
+// SubmitOrderRequest places a single order
+type SubmitOrderRequest struct {
+	EventID         *big.Int `json:"event_id"`
+	OrderID         *big.Int `json:"order_id"`
+	UserID          *big.Int `json:"user_id"`
+	Side            string   `json:"side"` // "BUY" || "SELL"
+	Price           int      `json:"price"`
+	Quantity        int      `json:"quantity"`
+	DisplayQuantity int      `json:"display_quantity,omitempty"`
+	Tier            string   `json:"tier,omitempty"` // rate limit tier of the user
+	PostOnly        bool     `json:"post_only,omitempty"`
+	PostOnlyReprice bool     `json:"post_only_reprice,omitempty"`
+	MinQty          int      `json:"min_qty,omitempty"`
+	AllOrNone       bool     `json:"all_or_none,omitempty"`
+	Outcome         int      `json:"outcome,omitempty"` // book of a categorical event
+}
+
+// CancelOrderRequest cancels a resting order
+type CancelOrderRequest struct {
+	EventID *big.Int `json:"event_id"`
+	OrderID *big.Int `json:"order_id"`
+	UserID  *big.Int `json:"user_id"`
+}
+
+// ModifyOrderRequest amends a resting order; a zero price keeps the current one
+type ModifyOrderRequest struct {
+	EventID  *big.Int `json:"event_id"`
+	OrderID  *big.Int `json:"order_id"`
+	UserID   *big.Int `json:"user_id"`
+	Price    int      `json:"price"`
+	Quantity int      `json:"quantity"` // new total open quantity
+	Tier     string   `json:"tier,omitempty"`
+}
+
+// SubscribeBookRequest opens a depth and trade stream for one outcome of an event
+type SubscribeBookRequest struct {
+	EventID *big.Int `json:"event_id"`
+	Outcome int      `json:"outcome,omitempty"`
+}
+
+// PublicTrade is an anonymous execution as shown to market data subscribers
+type PublicTrade struct {
+	EventID   *big.Int `json:"event_id"`
+	Price     int      `json:"price"`
+	Quantity  int      `json:"quantity"`
+	Timestamp int64    `json:"timestamp"`
+	Outcome   int      `json:"outcome,omitempty"`
+}
+
+// BookEvent is one message of a SubscribeBook stream: either depth or a trade
+type BookEvent struct {
+	Book  *OrderBookUpdate `json:"book,omitempty"`
+	Trade *PublicTrade     `json:"trade,omitempty"`
+}
+
+func (m *SubmitOrderRequest) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.OrderID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 3, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 4, m.Side)
+	b = appendInt(b, 5, int64(m.Price))
+	b = appendInt(b, 6, int64(m.Quantity))
+	b = appendInt(b, 7, int64(m.DisplayQuantity))
+	b = appendString(b, 8, m.Tier)
+	b = appendBool(b, 9, m.PostOnly)
+	b = appendBool(b, 10, m.PostOnlyReprice)
+	b = appendInt(b, 11, int64(m.MinQty))
+	b = appendBool(b, 12, m.AllOrNone)
+	b = appendInt(b, 13, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *SubmitOrderRequest) UnmarshalProto(data []byte) error {
+	*m = SubmitOrderRequest{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.OrderID)
+		case 3:
+			return readBigInt(typ, data, &m.UserID)
+		case 4:
+			return readString(typ, data, &m.Side)
+		case 5:
+			return readInt(typ, data, &m.Price)
+		case 6:
+			return readInt(typ, data, &m.Quantity)
+		case 7:
+			return readInt(typ, data, &m.DisplayQuantity)
+		case 8:
+			return readString(typ, data, &m.Tier)
+		case 9:
+			return readBool(typ, data, &m.PostOnly)
+		case 10:
+			return readBool(typ, data, &m.PostOnlyReprice)
+		case 11:
+			return readInt(typ, data, &m.MinQty)
+		case 12:
+			return readBool(typ, data, &m.AllOrNone)
+		case 13:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (m *CancelOrderRequest) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.OrderID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 3, m.UserID); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (m *CancelOrderRequest) UnmarshalProto(data []byte) error {
+	*m = CancelOrderRequest{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.OrderID)
+		case 3:
+			return readBigInt(typ, data, &m.UserID)
+		}
+		return 0
+	})
+}
+
+func (m *ModifyOrderRequest) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.OrderID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 3, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 4, int64(m.Price))
+	b = appendInt(b, 5, int64(m.Quantity))
+	b = appendString(b, 6, m.Tier)
+	return b, nil
+}
+
+func (m *ModifyOrderRequest) UnmarshalProto(data []byte) error {
+	*m = ModifyOrderRequest{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.OrderID)
+		case 3:
+			return readBigInt(typ, data, &m.UserID)
+		case 4:
+			return readInt(typ, data, &m.Price)
+		case 5:
+			return readInt(typ, data, &m.Quantity)
+		case 6:
+			return readString(typ, data, &m.Tier)
+		}
+		return 0
+	})
+}
+
+func (m *SubscribeBookRequest) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *SubscribeBookRequest) UnmarshalProto(data []byte) error {
+	*m = SubscribeBookRequest{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (m *PublicTrade) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(m.Price))
+	b = appendInt(b, 3, int64(m.Quantity))
+	b = appendInt(b, 4, m.Timestamp)
+	b = appendInt(b, 5, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *PublicTrade) UnmarshalProto(data []byte) error {
+	*m = PublicTrade{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readInt(typ, data, &m.Price)
+		case 3:
+			return readInt(typ, data, &m.Quantity)
+		case 4:
+			return readInt64(typ, data, &m.Timestamp)
+		case 5:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (m *BookEvent) MarshalProto() ([]byte, error) {
+	var b []byte
+	if m.Book != nil {
+		book, err := m.Book.MarshalProto()
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, 1, book)
+	}
+	if m.Trade != nil {
+		trade, err := m.Trade.MarshalProto()
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, 2, trade)
+	}
+	return b, nil
+}
+
+func (m *BookEvent) UnmarshalProto(data []byte) error {
+	*m = BookEvent{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			m.Book = &OrderBookUpdate{}
+			return readMessage(typ, data, m.Book)
+		case 2:
+			m.Trade = &PublicTrade{}
+			return readMessage(typ, data, m.Trade)
+		}
+		return 0
+	})
+}
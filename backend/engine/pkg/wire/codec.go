@@ -0,0 +1,246 @@
+// dummyengine/pkg/wire/codec.go
+package wire
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Small helpers over protowire used by the hand-written encoders. Zero values
+// are omitted, matching proto3 semantics, except for IDs: a nil ID is omitted
+// but a zero one is written as empty bytes, so that ID 0 survives the trip.
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// appendOptionalInt writes v whenever it is set, zero included, so that the
+// reader can tell a zero from an absent field
+func appendOptionalInt(b []byte, num protowire.Number, v *int) []byte {
+	if v == nil {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(*v)))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendStrings writes a repeated string field, empty elements included
+func appendStrings(b []byte, num protowire.Number, v []string) []byte {
+	for _, s := range v {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	return b
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// appendMessage writes a nested message, even when it is empty, so repeated
+// fields keep their element count
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// appendBigInt writes a non-negative ID as big-endian bytes
+func appendBigInt(b []byte, num protowire.Number, v *big.Int) ([]byte, error) {
+	if v == nil {
+		return b, nil
+	}
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("field %d: %s is negative", num, v)
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v.Bytes()), nil
+}
+
+// appendDecimal writes a decimal string ID as big-endian bytes
+func appendDecimal(b []byte, num protowire.Number, v string) ([]byte, error) {
+	if v == "" {
+		return b, nil
+	}
+	n, ok := new(big.Int).SetString(v, 10)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("field %d: %q is not a non-negative integer", num, v)
+	}
+	return appendBigInt(b, num, n)
+}
+
+// fieldFunc decodes one field value and returns the bytes consumed. Returning
+// 0 marks the field as unknown so that it is skipped.
+type fieldFunc func(num protowire.Number, typ protowire.Type, data []byte) int
+
+func decode(data []byte, field fieldFunc) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		n = field(num, typ, data)
+		if n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func readInt(typ protowire.Type, data []byte, dst *int) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(data)
+	if n > 0 {
+		*dst = int(int64(v))
+	}
+	return n
+}
+
+// readOptionalInt reads a field written by appendOptionalInt
+func readOptionalInt(typ protowire.Type, data []byte, dst **int) int {
+	var v int
+	n := readInt(typ, data, &v)
+	if n > 0 {
+		*dst = &v
+	}
+	return n
+}
+
+func readInt64(typ protowire.Type, data []byte, dst *int64) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(data)
+	if n > 0 {
+		*dst = int64(v)
+	}
+	return n
+}
+
+func readBool(typ protowire.Type, data []byte, dst *bool) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(data)
+	if n > 0 {
+		*dst = v != 0
+	}
+	return n
+}
+
+func readDouble(typ protowire.Type, data []byte, dst *float64) int {
+	if typ != protowire.Fixed64Type {
+		return 0
+	}
+	v, n := protowire.ConsumeFixed64(data)
+	if n > 0 {
+		*dst = math.Float64frombits(v)
+	}
+	return n
+}
+
+func readString(typ protowire.Type, data []byte, dst *string) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeString(data)
+	if n > 0 {
+		*dst = v
+	}
+	return n
+}
+
+// readStrings appends one element of a repeated string field to dst
+func readStrings(typ protowire.Type, data []byte, dst *[]string) int {
+	var v string
+	n := readString(typ, data, &v)
+	if n > 0 {
+		*dst = append(*dst, v)
+	}
+	return n
+}
+
+func readBytes(typ protowire.Type, data []byte, dst *[]byte) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeBytes(data)
+	if n > 0 {
+		*dst = append([]byte(nil), v...)
+	}
+	return n
+}
+
+func readBigInt(typ protowire.Type, data []byte, dst **big.Int) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n > 0 {
+		*dst = new(big.Int).SetBytes(raw) // empty bytes are ID 0
+	}
+	return n
+}
+
+// readDecimal reads big-endian bytes back into a decimal string ID
+func readDecimal(typ protowire.Type, data []byte, dst *string) int {
+	var v *big.Int
+	n := readBigInt(typ, data, &v)
+	if n > 0 {
+		*dst = v.String()
+	}
+	return n
+}
+
+// readMessage decodes a nested message into dst
+func readMessage(typ protowire.Type, data []byte, dst Message) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	if err := dst.UnmarshalProto(raw); err != nil {
+		return -1
+	}
+	return n
+}
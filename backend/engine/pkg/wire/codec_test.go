@@ -0,0 +1,261 @@
+package wire
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// messages returns a fresh value of every type with a binary encoding
+func messages() []Message {
+	return []Message{
+		&EventMessage{}, &TradeMessage{}, &PriceUpdate{}, &OrderBookUpdate{},
+		&ExecutionReport{}, &UsageReport{}, &EventInfo{}, &Candle{}, &Ticker{},
+		&AuctionUpdate{}, &MassCancelReport{},
+		&CompleteSetReport{}, &SettlementReport{},
+		&SubmitOrderRequest{}, &CancelOrderRequest{}, &ModifyOrderRequest{},
+		&SubscribeBookRequest{}, &PublicTrade{}, &BookEvent{},
+	}
+}
+
+// decimalFields are string fields carrying a decimal ID
+var decimalFields = map[string]bool{"ID": true, "OrderID": true, "OrderUserID": true}
+
+// fill sets every field of v, recursively, to a non-zero value: integers
+// to sign times a per-field number, IDs to id unless it is nil
+func fill(v reflect.Value, sign int, id *big.Int) {
+	bigIntType := reflect.TypeOf(big.Int{})
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), v.Type().Field(i)
+		n := int64(sign * (i + 3))
+		switch f.Kind() {
+		case reflect.Int, reflect.Int64:
+			f.SetInt(n)
+		case reflect.Bool:
+			f.SetBool(true)
+		case reflect.Float64:
+			f.SetFloat(float64(n) + 0.25)
+		case reflect.String:
+			if decimalFields[field.Name] {
+				f.SetString(id.String())
+			} else {
+				f.SetString("s" + field.Name)
+			}
+		case reflect.Slice:
+			if f.Type().Elem().Kind() == reflect.Uint8 {
+				f.SetBytes([]byte{0, 1, 2})
+				continue
+			}
+			elem := reflect.New(f.Type().Elem()).Elem()
+			if elem.Kind() == reflect.String {
+				// An empty element must survive too
+				f.Set(reflect.Append(f, elem, reflect.ValueOf("s"+field.Name)))
+				continue
+			}
+			fill(elem, sign, id)
+			f.Set(reflect.Append(f, elem))
+		case reflect.Ptr:
+			switch elem := f.Type().Elem(); {
+			case elem == bigIntType:
+				f.Set(reflect.ValueOf(new(big.Int).Set(id)))
+			case elem.Kind() == reflect.Struct:
+				f.Set(reflect.New(elem))
+				fill(f.Elem(), sign, id)
+			default:
+				f.Set(reflect.New(elem))
+				f.Elem().SetInt(n)
+			}
+		}
+	}
+}
+
+// zeroIDs sets every ID of v, recursively, to zero and leaves the rest alone
+func zeroIDs(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), v.Type().Field(i)
+		switch {
+		case f.Type() == reflect.TypeOf((*big.Int)(nil)):
+			f.Set(reflect.ValueOf(big.NewInt(0)))
+		case f.Kind() == reflect.String && decimalFields[field.Name]:
+			f.SetString("0")
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	variants := []struct {
+		name string
+		set  func(v reflect.Value)
+	}{
+		{"zero", func(v reflect.Value) {}},
+		{"zero IDs", zeroIDs},
+		{"positive", func(v reflect.Value) { fill(v, 1, huge) }},
+		{"negative", func(v reflect.Value) { fill(v, -1, big.NewInt(1)) }},
+	}
+	for _, msg := range messages() {
+		for _, variant := range variants {
+			typ := reflect.TypeOf(msg).Elem()
+			t.Run(typ.Name()+"/"+variant.name, func(t *testing.T) {
+				in := reflect.New(typ)
+				variant.set(in.Elem())
+
+				data, err := in.Interface().(Message).MarshalProto()
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := reflect.New(typ)
+				if err := out.Interface().(Message).UnmarshalProto(data); err != nil {
+					t.Fatal(err)
+				}
+
+				want, _ := json.Marshal(in.Interface())
+				got, _ := json.Marshal(out.Interface())
+				if string(got) != string(want) {
+					t.Errorf("round trip changed the message\n got: %s\nwant: %s", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestMarshalRejectsNegativeIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"big.Int ID", &TradeMessage{EventID: big.NewInt(-1)}},
+		{"decimal ID", &EventMessage{ID: "-1"}},
+		{"nested ID", &SettlementReport{Payouts: []Payout{{UserID: big.NewInt(-5)}}}},
+		{"not a number", &EventMessage{Orders: []BatchOrderEntry{{OrderID: "x"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.msg.MarshalProto(); err == nil {
+				t.Fatal("marshaled without error")
+			}
+		})
+	}
+}
+
+func TestZeroIDIsPresent(t *testing.T) {
+	data, err := (&PriceUpdate{EventID: big.NewInt(0)}).MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out PriceUpdate
+	if err := out.UnmarshalProto(data); err != nil {
+		t.Fatal(err)
+	}
+	if out.EventID == nil || out.EventID.Sign() != 0 {
+		t.Fatalf("event ID 0 decoded as %v", out.EventID)
+	}
+
+	data, err = (&PriceUpdate{}).MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.UnmarshalProto(data); err != nil || out.EventID != nil {
+		t.Fatalf("absent event ID decoded as %v, %v", out.EventID, err)
+	}
+}
+
+// schema compiles engine.proto, the contract the hand-written codec follows
+func schema(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{Resolver: &protocompile.SourceResolver{}}
+	files, err := compiler.Compile(context.Background(), "engine.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files[0]
+}
+
+// checkDecoded reports, under path, fields of m the schema does not know and
+// schema fields that m lacks although the encoder was given every one
+func checkDecoded(t *testing.T, path string, m protoreflect.Message) {
+	t.Helper()
+	if unknown := m.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("%s: encoded fields missing from engine.proto or of another type: %x", path, unknown)
+	}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			// Only one member of a oneof survives decoding
+			if m.WhichOneof(oneof) == nil {
+				t.Errorf("%s: no member of oneof %s encoded", path, oneof.Name())
+			}
+		} else if !m.Has(field) {
+			t.Errorf("%s: field %s (%d) not encoded", path, field.Name(), field.Number())
+			continue
+		}
+		if field.Message() == nil || !m.Has(field) {
+			continue
+		}
+		name := path + "." + string(field.Name())
+		if field.IsList() {
+			list := m.Get(field).List()
+			for j := 0; j < list.Len(); j++ {
+				checkDecoded(t, name, list.Get(j).Message())
+			}
+		} else {
+			checkDecoded(t, name, m.Get(field).Message())
+		}
+	}
+}
+
+func TestCodecMatchesTheSchema(t *testing.T) {
+	file := schema(t)
+	for _, msg := range messages() {
+		typ := reflect.TypeOf(msg).Elem()
+		t.Run(typ.Name(), func(t *testing.T) {
+			desc := file.Messages().ByName(protoreflect.Name(typ.Name()))
+			if desc == nil {
+				t.Fatal("message missing from engine.proto")
+			}
+
+			// Everything the codec writes decodes under the schema, field
+			// numbers and wire types included, and nothing is left out
+			in := reflect.New(typ)
+			fill(in.Elem(), 1, big.NewInt(5))
+			data, err := in.Interface().(Message).MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := dynamicpb.NewMessage(desc)
+			if err := proto.Unmarshal(data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			checkDecoded(t, typ.Name(), decoded)
+
+			// and what the schema writes the codec reads back in full
+			encoded, err := proto.Marshal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := reflect.New(typ)
+			if err := out.Interface().(Message).UnmarshalProto(encoded); err != nil {
+				t.Fatal(err)
+			}
+			again, err := out.Interface().(Message).MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			redecoded := dynamicpb.NewMessage(desc)
+			if err := proto.Unmarshal(again, redecoded); err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(decoded, redecoded) {
+				t.Errorf("codec lost fields written by the schema\n got: %v\nwant: %v", redecoded, decoded)
+			}
+		})
+	}
+}
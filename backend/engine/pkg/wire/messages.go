@@ -0,0 +1,1276 @@
+// dummyengine/pkg/wire/messages.go
+package wire
+
+import (
+	"math/big"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// EventMessage represents the structure received from RabbitMQ
+type EventMessage struct {
+	Task          string `json:"task"`               // "Order" || "BatchOrder" || "CreateEvent" || "Settlement" || "QueryUsage" || "MoveEvent" || "AdoptEvent" || "CancelOrder" || "ModifyOrder" || "MassCancel" || "Uncross" || "UpdateEvent" || "QueryEvent" || "MintSet" || "MergeSet"
+	ID            string `json:"eventId"`            // EventID
+	OrderID       string `json:"orderId,omitempty"`  // OrderID
+	OrderPrice    int    `json:"price,omitempty"`    // OrderPrice
+	OrderUserID   string `json:"userId,omitempty"`   // OrderUserID
+	OrderQuantity int    `json:"quantity,omitempty"` // OrderQuantity
+	Type          string `json:"type,omitempty"`     // "BUY" || "SELL"
+
+	// Iceberg orders show at most DisplayQuantity of OrderQuantity at a time
+	DisplayQuantity int `json:"displayQuantity,omitempty"`
+
+	// Rate limit tier of the order's owner, as assigned by the order service;
+	// empty uses the default tier
+	Tier string `json:"tier,omitempty"`
+
+	// Post-only orders never take liquidity: one that would cross is rejected,
+	// or with PostOnlyReprice rests one tick behind the opposite top
+	PostOnly        bool `json:"postOnly,omitempty"`
+	PostOnlyReprice bool `json:"postOnlyReprice,omitempty"`
+
+	// MinQty cancels an order unless at least that much fills on entry;
+	// AllOrNone fills it completely in one match or not at all
+	MinQty    int  `json:"minQty,omitempty"`
+	AllOrNone bool `json:"allOrNone,omitempty"`
+
+	// Outcome picks the book of a categorical event for Order, BatchOrder and
+	// QueryUsage. Yes/no events have a single book, outcome 0.
+	Outcome int `json:"outcome,omitempty"`
+
+	// WinningOutcome names the winner for Settlement, which is rejected
+	// without one
+	WinningOutcome *int `json:"winningOutcome,omitempty"`
+
+	// CreateEvent
+	MatchingPolicy string       `json:"matchingPolicy,omitempty"` // "FIFO" (default) || "PRO_RATA"
+	MinAllocation  int          `json:"minAllocation,omitempty"`  // pro-rata minimum share
+	Fees           *FeeSchedule `json:"fees,omitempty"`
+	Limits         *Limits      `json:"limits,omitempty"` // per-user limits in this event
+	Payout         int          `json:"payout,omitempty"` // value of a winning share, default 1000
+
+	// CreateEvent: start in an opening call auction, uncrossed at AuctionEnd
+	// (unix seconds) or by an Uncross task
+	OpeningAuction bool  `json:"openingAuction,omitempty"`
+	AuctionEnd     int64 `json:"auctionEnd,omitempty"`
+
+	// CreateEvent / UpdateEvent price and quantity rules and trading hours
+	// (unix seconds); an UpdateEvent without an UpdateMask keeps whatever it
+	// leaves at zero
+	TickSize  int   `json:"tickSize,omitempty"`
+	MinPrice  int   `json:"minPrice,omitempty"`
+	MaxPrice  int   `json:"maxPrice,omitempty"`
+	LotSize   int   `json:"lotSize,omitempty"`
+	Outcomes  int   `json:"outcomes,omitempty"` // default 2
+	OpenTime  int64 `json:"openTime,omitempty"`
+	CloseTime int64 `json:"closeTime,omitempty"`
+
+	// CreateEvent: run an LMSR market maker with liquidity MakerLiquidity, in
+	// shares, quoting MakerQuantity (default the lot size) per side
+	MakerLiquidity int `json:"makerLiquidity,omitempty"`
+	MakerQuantity  int `json:"makerQuantity,omitempty"`
+
+	// UpdateEvent: the rules to set, by their JSON names, such as "maxPrice"
+	// or "closeTime". Listed rules are set even to zero, clearing them; the
+	// rest are kept.
+	UpdateMask []string `json:"updateMask,omitempty"`
+
+	// BatchOrder
+	BatchID        string            `json:"batchId,omitempty"`
+	Orders         []BatchOrderEntry `json:"orders,omitempty"`
+	BatchAllOrNone bool              `json:"batchAllOrNone,omitempty"` // reject the whole batch if any order is invalid
+
+	// MoveEvent names the partition taking the event over; AdoptEvent carries
+	// the book handed to it
+	Partition int    `json:"partition,omitempty"`
+	Snapshot  []byte `json:"snapshot,omitempty"` // JSON-encoded []orderbook.BookSnapshot, one per outcome book
+}
+
+// FeeSchedule sets maker and taker fees in basis points with a minimum fee per fill
+type FeeSchedule struct {
+	MakerBps int `json:"makerBps"`
+	TakerBps int `json:"takerBps"`
+	MinFee   int `json:"minFee"`
+}
+
+// BatchOrderEntry is one order of a BatchOrder task; the event comes from the enclosing message
+type BatchOrderEntry struct {
+	OrderID       string `json:"orderId"`
+	OrderPrice    int    `json:"price"`
+	OrderUserID   string `json:"userId"`
+	OrderQuantity int    `json:"quantity"`
+	Type          string `json:"type"` // "BUY" || "SELL"
+
+	DisplayQuantity int    `json:"displayQuantity,omitempty"`
+	Tier            string `json:"tier,omitempty"` // defaults to the batch message's tier
+
+	PostOnly        bool `json:"postOnly,omitempty"`
+	PostOnlyReprice bool `json:"postOnlyReprice,omitempty"`
+	MinQty          int  `json:"minQty,omitempty"`
+	AllOrNone       bool `json:"allOrNone,omitempty"` // this order only, unlike the batch flag
+}
+
+// Execution report statuses
+const (
+	StatusNew             = "NEW"
+	StatusPartiallyFilled = "PARTIALLY_FILLED"
+	StatusFilled          = "FILLED"
+	StatusRejected        = "REJECTED"
+	StatusCanceled        = "CANCELED"
+	StatusModified        = "MODIFIED" // amended in place, time priority kept
+)
+
+// ExecutionReport tells the order's owner what happened to it
+type ExecutionReport struct {
+	EventID           *big.Int `json:"event_id"`
+	OrderID           *big.Int `json:"order_id"`
+	UserID            *big.Int `json:"user_id"`
+	BatchID           string   `json:"batch_id,omitempty"`
+	Side              string   `json:"side"`
+	Price             int      `json:"price"`
+	Quantity          int      `json:"quantity"`
+	FilledQuantity    int      `json:"filled_quantity"`
+	RemainingQuantity int      `json:"remaining_quantity"`
+	Status            string   `json:"status"`
+	Reason            string   `json:"reason,omitempty"`
+	Timestamp         int64    `json:"timestamp"`
+
+	// A repriced post-only order rests at Price instead of RequestedPrice
+	Repriced       bool `json:"repriced,omitempty"`
+	RequestedPrice int  `json:"requested_price,omitempty"`
+
+	Outcome int `json:"outcome,omitempty"`
+}
+
+// TradeMessage is published on trade_exchange for each side of a fill
+type TradeMessage struct {
+	ID        *big.Int `json:"id"`
+	OrderID   *big.Int `json:"order_id"`
+	UserID    *big.Int `json:"user_id"`
+	Price     int      `json:"price"`
+	Quantity  int      `json:"quantity"`
+	Timestamp int64    `json:"timestamp"`
+	EventID   *big.Int `json:"event_id"`
+	Fee       int      `json:"fee"`
+	Liquidity string   `json:"liquidity"` // "MAKER" || "TAKER" || "AUCTION"
+	Side      string   `json:"side"`      // "BUY" || "SELL"
+	Outcome   int      `json:"outcome,omitempty"`
+}
+
+// PriceUpdate is published on price_exchange
+type PriceUpdate struct {
+	Price   int      `json:"price"`
+	EventID *big.Int `json:"event_id"`
+	Outcome int      `json:"outcome,omitempty"`
+}
+
+// Level is one aggregated price level of a depth update
+type Level struct {
+	Price    int `json:"Price"`
+	Quantity int `json:"Quantity"`
+}
+
+// OrderBookUpdate is the depth snapshot published on order_book_exchange
+type OrderBookUpdate struct {
+	BuyLevels  []Level  `json:"buy_levels"`
+	SellLevels []Level  `json:"sell_levels"`
+	EventID    *big.Int `json:"event_id"`
+	Outcome    int      `json:"outcome,omitempty"`
+}
+
+func (m *EventMessage) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	b = appendString(b, 1, m.Task)
+	if b, err = appendDecimal(b, 2, m.ID); err != nil {
+		return nil, err
+	}
+	if b, err = appendDecimal(b, 3, m.OrderID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 4, int64(m.OrderPrice))
+	if b, err = appendDecimal(b, 5, m.OrderUserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 6, int64(m.OrderQuantity))
+	b = appendString(b, 7, m.Type)
+	b = appendString(b, 8, m.BatchID)
+	for i := range m.Orders {
+		entry, err := m.Orders[i].marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, 9, entry)
+	}
+	b = appendBool(b, 10, m.BatchAllOrNone)
+	b = appendInt(b, 11, int64(m.DisplayQuantity))
+	b = appendString(b, 12, m.MatchingPolicy)
+	b = appendInt(b, 13, int64(m.MinAllocation))
+	if m.Fees != nil {
+		b = appendMessage(b, 14, m.Fees.marshal())
+	}
+	if m.Limits != nil {
+		b = appendMessage(b, 15, m.Limits.marshal())
+	}
+	b = appendInt(b, 16, int64(m.Payout))
+	b = appendInt(b, 17, int64(m.Partition))
+	b = appendBytes(b, 18, m.Snapshot)
+	b = appendString(b, 19, m.Tier)
+	b = appendBool(b, 20, m.PostOnly)
+	b = appendBool(b, 21, m.PostOnlyReprice)
+	b = appendInt(b, 22, int64(m.MinQty))
+	b = appendBool(b, 23, m.AllOrNone)
+	b = appendBool(b, 24, m.OpeningAuction)
+	b = appendInt(b, 25, m.AuctionEnd)
+	b = appendInt(b, 26, int64(m.TickSize))
+	b = appendInt(b, 27, int64(m.MinPrice))
+	b = appendInt(b, 28, int64(m.MaxPrice))
+	b = appendInt(b, 29, int64(m.LotSize))
+	b = appendInt(b, 30, int64(m.Outcomes))
+	b = appendInt(b, 31, m.OpenTime)
+	b = appendInt(b, 32, m.CloseTime)
+	b = appendInt(b, 33, int64(m.Outcome))
+	b = appendInt(b, 34, int64(m.MakerLiquidity))
+	b = appendInt(b, 35, int64(m.MakerQuantity))
+	b = appendOptionalInt(b, 36, m.WinningOutcome)
+	b = appendStrings(b, 37, m.UpdateMask)
+	return b, nil
+}
+
+func (m *EventMessage) UnmarshalProto(data []byte) error {
+	*m = EventMessage{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readString(typ, data, &m.Task)
+		case 2:
+			return readDecimal(typ, data, &m.ID)
+		case 3:
+			return readDecimal(typ, data, &m.OrderID)
+		case 4:
+			return readInt(typ, data, &m.OrderPrice)
+		case 5:
+			return readDecimal(typ, data, &m.OrderUserID)
+		case 6:
+			return readInt(typ, data, &m.OrderQuantity)
+		case 7:
+			return readString(typ, data, &m.Type)
+		case 8:
+			return readString(typ, data, &m.BatchID)
+		case 9:
+			return readBatchOrderEntry(typ, data, &m.Orders)
+		case 10:
+			return readBool(typ, data, &m.BatchAllOrNone)
+		case 11:
+			return readInt(typ, data, &m.DisplayQuantity)
+		case 12:
+			return readString(typ, data, &m.MatchingPolicy)
+		case 13:
+			return readInt(typ, data, &m.MinAllocation)
+		case 14:
+			m.Fees = &FeeSchedule{}
+			return m.Fees.read(typ, data)
+		case 15:
+			m.Limits = &Limits{}
+			return m.Limits.read(typ, data)
+		case 16:
+			return readInt(typ, data, &m.Payout)
+		case 17:
+			return readInt(typ, data, &m.Partition)
+		case 18:
+			return readBytes(typ, data, &m.Snapshot)
+		case 19:
+			return readString(typ, data, &m.Tier)
+		case 20:
+			return readBool(typ, data, &m.PostOnly)
+		case 21:
+			return readBool(typ, data, &m.PostOnlyReprice)
+		case 22:
+			return readInt(typ, data, &m.MinQty)
+		case 23:
+			return readBool(typ, data, &m.AllOrNone)
+		case 24:
+			return readBool(typ, data, &m.OpeningAuction)
+		case 25:
+			return readInt64(typ, data, &m.AuctionEnd)
+		case 26:
+			return readInt(typ, data, &m.TickSize)
+		case 27:
+			return readInt(typ, data, &m.MinPrice)
+		case 28:
+			return readInt(typ, data, &m.MaxPrice)
+		case 29:
+			return readInt(typ, data, &m.LotSize)
+		case 30:
+			return readInt(typ, data, &m.Outcomes)
+		case 31:
+			return readInt64(typ, data, &m.OpenTime)
+		case 32:
+			return readInt64(typ, data, &m.CloseTime)
+		case 33:
+			return readInt(typ, data, &m.Outcome)
+		case 34:
+			return readInt(typ, data, &m.MakerLiquidity)
+		case 35:
+			return readInt(typ, data, &m.MakerQuantity)
+		case 36:
+			return readOptionalInt(typ, data, &m.WinningOutcome)
+		case 37:
+			return readStrings(typ, data, &m.UpdateMask)
+		}
+		return 0
+	})
+}
+
+func (f *FeeSchedule) marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(f.MakerBps))
+	b = appendInt(b, 2, int64(f.TakerBps))
+	b = appendInt(b, 3, int64(f.MinFee))
+	return b
+}
+
+// read decodes an embedded FeeSchedule
+func (f *FeeSchedule) read(typ protowire.Type, data []byte) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	err := decode(raw, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readInt(typ, data, &f.MakerBps)
+		case 2:
+			return readInt(typ, data, &f.TakerBps)
+		case 3:
+			return readInt(typ, data, &f.MinFee)
+		}
+		return 0
+	})
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func (e *BatchOrderEntry) marshal() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendDecimal(b, 1, e.OrderID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(e.OrderPrice))
+	if b, err = appendDecimal(b, 3, e.OrderUserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 4, int64(e.OrderQuantity))
+	b = appendString(b, 5, e.Type)
+	b = appendInt(b, 6, int64(e.DisplayQuantity))
+	b = appendString(b, 7, e.Tier)
+	b = appendBool(b, 8, e.PostOnly)
+	b = appendBool(b, 9, e.PostOnlyReprice)
+	b = appendInt(b, 10, int64(e.MinQty))
+	b = appendBool(b, 11, e.AllOrNone)
+	return b, nil
+}
+
+// readBatchOrderEntry decodes one repeated BatchOrderEntry and appends it to dst
+func readBatchOrderEntry(typ protowire.Type, data []byte, dst *[]BatchOrderEntry) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	var e BatchOrderEntry
+	err := decode(raw, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readDecimal(typ, data, &e.OrderID)
+		case 2:
+			return readInt(typ, data, &e.OrderPrice)
+		case 3:
+			return readDecimal(typ, data, &e.OrderUserID)
+		case 4:
+			return readInt(typ, data, &e.OrderQuantity)
+		case 5:
+			return readString(typ, data, &e.Type)
+		case 6:
+			return readInt(typ, data, &e.DisplayQuantity)
+		case 7:
+			return readString(typ, data, &e.Tier)
+		case 8:
+			return readBool(typ, data, &e.PostOnly)
+		case 9:
+			return readBool(typ, data, &e.PostOnlyReprice)
+		case 10:
+			return readInt(typ, data, &e.MinQty)
+		case 11:
+			return readBool(typ, data, &e.AllOrNone)
+		}
+		return 0
+	})
+	if err != nil {
+		return -1
+	}
+	*dst = append(*dst, e)
+	return n
+}
+
+func (m *TradeMessage) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.ID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.OrderID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 3, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 4, int64(m.Price))
+	b = appendInt(b, 5, int64(m.Quantity))
+	b = appendInt(b, 6, m.Timestamp)
+	if b, err = appendBigInt(b, 7, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 8, int64(m.Fee))
+	b = appendString(b, 9, m.Liquidity)
+	b = appendString(b, 10, m.Side)
+	b = appendInt(b, 11, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *TradeMessage) UnmarshalProto(data []byte) error {
+	*m = TradeMessage{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.ID)
+		case 2:
+			return readBigInt(typ, data, &m.OrderID)
+		case 3:
+			return readBigInt(typ, data, &m.UserID)
+		case 4:
+			return readInt(typ, data, &m.Price)
+		case 5:
+			return readInt(typ, data, &m.Quantity)
+		case 6:
+			return readInt64(typ, data, &m.Timestamp)
+		case 7:
+			return readBigInt(typ, data, &m.EventID)
+		case 8:
+			return readInt(typ, data, &m.Fee)
+		case 9:
+			return readString(typ, data, &m.Liquidity)
+		case 10:
+			return readString(typ, data, &m.Side)
+		case 11:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (m *PriceUpdate) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	b = appendInt(b, 1, int64(m.Price))
+	if b, err = appendBigInt(b, 2, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 3, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *PriceUpdate) UnmarshalProto(data []byte) error {
+	*m = PriceUpdate{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readInt(typ, data, &m.Price)
+		case 2:
+			return readBigInt(typ, data, &m.EventID)
+		case 3:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (l *Level) marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(l.Price))
+	b = appendInt(b, 2, int64(l.Quantity))
+	return b
+}
+
+func (l *Level) unmarshal(data []byte) error {
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readInt(typ, data, &l.Price)
+		case 2:
+			return readInt(typ, data, &l.Quantity)
+		}
+		return 0
+	})
+}
+
+// readLevel decodes one repeated Level element and appends it to dst
+func readLevel(typ protowire.Type, data []byte, dst *[]Level) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	var l Level
+	if err := l.unmarshal(raw); err != nil {
+		return -1
+	}
+	*dst = append(*dst, l)
+	return n
+}
+
+func (m *OrderBookUpdate) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	for i := range m.BuyLevels {
+		b = appendMessage(b, 1, m.BuyLevels[i].marshal())
+	}
+	for i := range m.SellLevels {
+		b = appendMessage(b, 2, m.SellLevels[i].marshal())
+	}
+	if b, err = appendBigInt(b, 3, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 4, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *OrderBookUpdate) UnmarshalProto(data []byte) error {
+	*m = OrderBookUpdate{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readLevel(typ, data, &m.BuyLevels)
+		case 2:
+			return readLevel(typ, data, &m.SellLevels)
+		case 3:
+			return readBigInt(typ, data, &m.EventID)
+		case 4:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+func (m *ExecutionReport) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.OrderID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 3, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 4, m.BatchID)
+	b = appendString(b, 5, m.Side)
+	b = appendInt(b, 6, int64(m.Price))
+	b = appendInt(b, 7, int64(m.Quantity))
+	b = appendInt(b, 8, int64(m.FilledQuantity))
+	b = appendInt(b, 9, int64(m.RemainingQuantity))
+	b = appendString(b, 10, m.Status)
+	b = appendString(b, 11, m.Reason)
+	b = appendInt(b, 12, m.Timestamp)
+	b = appendBool(b, 13, m.Repriced)
+	b = appendInt(b, 14, int64(m.RequestedPrice))
+	b = appendInt(b, 15, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *ExecutionReport) UnmarshalProto(data []byte) error {
+	*m = ExecutionReport{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.OrderID)
+		case 3:
+			return readBigInt(typ, data, &m.UserID)
+		case 4:
+			return readString(typ, data, &m.BatchID)
+		case 5:
+			return readString(typ, data, &m.Side)
+		case 6:
+			return readInt(typ, data, &m.Price)
+		case 7:
+			return readInt(typ, data, &m.Quantity)
+		case 8:
+			return readInt(typ, data, &m.FilledQuantity)
+		case 9:
+			return readInt(typ, data, &m.RemainingQuantity)
+		case 10:
+			return readString(typ, data, &m.Status)
+		case 11:
+			return readString(typ, data, &m.Reason)
+		case 12:
+			return readInt64(typ, data, &m.Timestamp)
+		case 13:
+			return readBool(typ, data, &m.Repriced)
+		case 14:
+			return readInt(typ, data, &m.RequestedPrice)
+		case 15:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+// Limits mirrors the engine's per-user limits; zero means unlimited
+type Limits struct {
+	MaxOpenOrders   int   `json:"max_open_orders"`
+	MaxOpenNotional int64 `json:"max_open_notional"`
+	MaxPosition     int   `json:"max_position"`
+}
+
+// UsageReport answers a QueryUsage task with a user's footprint in one event
+// and their totals across all events
+type UsageReport struct {
+	EventID           *big.Int `json:"event_id"`
+	UserID            *big.Int `json:"user_id"`
+	OpenOrders        int      `json:"open_orders"`
+	OpenBuyQuantity   int      `json:"open_buy_quantity"`
+	OpenSellQuantity  int      `json:"open_sell_quantity"`
+	OpenNotional      int64    `json:"open_notional"`
+	NetPosition       int      `json:"net_position"`
+	TotalOpenOrders   int      `json:"total_open_orders"`
+	TotalOpenNotional int64    `json:"total_open_notional"`
+	Limits            *Limits  `json:"limits,omitempty"`
+	GlobalLimits      *Limits  `json:"global_limits,omitempty"`
+	Error             string   `json:"error,omitempty"`
+	Outcome           int      `json:"outcome,omitempty"`
+}
+
+func (l *Limits) marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, int64(l.MaxOpenOrders))
+	b = appendInt(b, 2, l.MaxOpenNotional)
+	b = appendInt(b, 3, int64(l.MaxPosition))
+	return b
+}
+
+// read decodes an embedded Limits
+func (l *Limits) read(typ protowire.Type, data []byte) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	err := decode(raw, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readInt(typ, data, &l.MaxOpenOrders)
+		case 2:
+			return readInt64(typ, data, &l.MaxOpenNotional)
+		case 3:
+			return readInt(typ, data, &l.MaxPosition)
+		}
+		return 0
+	})
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func (m *UsageReport) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 3, int64(m.OpenOrders))
+	b = appendInt(b, 4, int64(m.OpenBuyQuantity))
+	b = appendInt(b, 5, int64(m.OpenSellQuantity))
+	b = appendInt(b, 6, m.OpenNotional)
+	b = appendInt(b, 7, int64(m.NetPosition))
+	b = appendInt(b, 8, int64(m.TotalOpenOrders))
+	b = appendInt(b, 9, m.TotalOpenNotional)
+	if m.Limits != nil {
+		b = appendMessage(b, 10, m.Limits.marshal())
+	}
+	if m.GlobalLimits != nil {
+		b = appendMessage(b, 11, m.GlobalLimits.marshal())
+	}
+	b = appendString(b, 12, m.Error)
+	b = appendInt(b, 13, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *UsageReport) UnmarshalProto(data []byte) error {
+	*m = UsageReport{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.UserID)
+		case 3:
+			return readInt(typ, data, &m.OpenOrders)
+		case 4:
+			return readInt(typ, data, &m.OpenBuyQuantity)
+		case 5:
+			return readInt(typ, data, &m.OpenSellQuantity)
+		case 6:
+			return readInt64(typ, data, &m.OpenNotional)
+		case 7:
+			return readInt(typ, data, &m.NetPosition)
+		case 8:
+			return readInt(typ, data, &m.TotalOpenOrders)
+		case 9:
+			return readInt64(typ, data, &m.TotalOpenNotional)
+		case 10:
+			m.Limits = &Limits{}
+			return m.Limits.read(typ, data)
+		case 11:
+			m.GlobalLimits = &Limits{}
+			return m.GlobalLimits.read(typ, data)
+		case 12:
+			return readString(typ, data, &m.Error)
+		case 13:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+// EventInfo answers a QueryEvent task with the event's rules and phase
+type EventInfo struct {
+	EventID        *big.Int     `json:"event_id"`
+	MatchingPolicy string       `json:"matching_policy,omitempty"`
+	MinAllocation  int          `json:"min_allocation,omitempty"`
+	Fees           *FeeSchedule `json:"fees,omitempty"`
+	Limits         *Limits      `json:"limits,omitempty"`
+	Payout         int          `json:"payout"`
+	TickSize       int          `json:"tick_size"`
+	MinPrice       int          `json:"min_price"`
+	MaxPrice       int          `json:"max_price"` // 0: no upper bound
+	LotSize        int          `json:"lot_size"`
+	Outcomes       int          `json:"outcomes"`
+	OpenTime       int64        `json:"open_time,omitempty"`
+	CloseTime      int64        `json:"close_time,omitempty"`
+	OpeningAuction bool         `json:"opening_auction,omitempty"`
+	AuctionEnd     int64        `json:"auction_end,omitempty"`
+	Auction        bool         `json:"auction"` // still in the opening auction
+	Error          string       `json:"error,omitempty"`
+	MakerLiquidity int          `json:"maker_liquidity,omitempty"`
+	MakerQuantity  int          `json:"maker_quantity,omitempty"`
+	MakerBudget    int64        `json:"maker_budget,omitempty"` // most the maker can lose, in price units
+	MakerLoss      int64        `json:"maker_loss,omitempty"`   // its worst-case loss as things stand
+}
+
+func (m *EventInfo) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 2, m.MatchingPolicy)
+	b = appendInt(b, 3, int64(m.MinAllocation))
+	if m.Fees != nil {
+		b = appendMessage(b, 4, m.Fees.marshal())
+	}
+	if m.Limits != nil {
+		b = appendMessage(b, 5, m.Limits.marshal())
+	}
+	b = appendInt(b, 6, int64(m.Payout))
+	b = appendInt(b, 7, int64(m.TickSize))
+	b = appendInt(b, 8, int64(m.MinPrice))
+	b = appendInt(b, 9, int64(m.MaxPrice))
+	b = appendInt(b, 10, int64(m.LotSize))
+	b = appendInt(b, 11, int64(m.Outcomes))
+	b = appendInt(b, 12, m.OpenTime)
+	b = appendInt(b, 13, m.CloseTime)
+	b = appendBool(b, 14, m.OpeningAuction)
+	b = appendInt(b, 15, m.AuctionEnd)
+	b = appendBool(b, 16, m.Auction)
+	b = appendString(b, 17, m.Error)
+	b = appendInt(b, 18, int64(m.MakerLiquidity))
+	b = appendInt(b, 19, int64(m.MakerQuantity))
+	b = appendInt(b, 20, m.MakerBudget)
+	b = appendInt(b, 21, m.MakerLoss)
+	return b, nil
+}
+
+func (m *EventInfo) UnmarshalProto(data []byte) error {
+	*m = EventInfo{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readString(typ, data, &m.MatchingPolicy)
+		case 3:
+			return readInt(typ, data, &m.MinAllocation)
+		case 4:
+			m.Fees = &FeeSchedule{}
+			return m.Fees.read(typ, data)
+		case 5:
+			m.Limits = &Limits{}
+			return m.Limits.read(typ, data)
+		case 6:
+			return readInt(typ, data, &m.Payout)
+		case 7:
+			return readInt(typ, data, &m.TickSize)
+		case 8:
+			return readInt(typ, data, &m.MinPrice)
+		case 9:
+			return readInt(typ, data, &m.MaxPrice)
+		case 10:
+			return readInt(typ, data, &m.LotSize)
+		case 11:
+			return readInt(typ, data, &m.Outcomes)
+		case 12:
+			return readInt64(typ, data, &m.OpenTime)
+		case 13:
+			return readInt64(typ, data, &m.CloseTime)
+		case 14:
+			return readBool(typ, data, &m.OpeningAuction)
+		case 15:
+			return readInt64(typ, data, &m.AuctionEnd)
+		case 16:
+			return readBool(typ, data, &m.Auction)
+		case 17:
+			return readString(typ, data, &m.Error)
+		case 18:
+			return readInt(typ, data, &m.MakerLiquidity)
+		case 19:
+			return readInt(typ, data, &m.MakerQuantity)
+		case 20:
+			return readInt64(typ, data, &m.MakerBudget)
+		case 21:
+			return readInt64(typ, data, &m.MakerLoss)
+		}
+		return 0
+	})
+}
+
+// Candle is one closed OHLCV bar, published on price_exchange with routing key "candles"
+type Candle struct {
+	EventID   *big.Int `json:"event_id"`
+	Interval  string   `json:"interval"`   // "1m" || "5m" || "1h" || "1d"
+	OpenTime  int64    `json:"open_time"`  // unix seconds, inclusive
+	CloseTime int64    `json:"close_time"` // unix seconds, exclusive
+	Open      int      `json:"open"`
+	High      int      `json:"high"`
+	Low       int      `json:"low"`
+	Close     int      `json:"close"`
+	Volume    int64    `json:"volume"`
+	Trades    int      `json:"trades"`
+	Outcome   int      `json:"outcome,omitempty"`
+}
+
+func (m *Candle) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 2, m.Interval)
+	b = appendInt(b, 3, m.OpenTime)
+	b = appendInt(b, 4, m.CloseTime)
+	b = appendInt(b, 5, int64(m.Open))
+	b = appendInt(b, 6, int64(m.High))
+	b = appendInt(b, 7, int64(m.Low))
+	b = appendInt(b, 8, int64(m.Close))
+	b = appendInt(b, 9, m.Volume)
+	b = appendInt(b, 10, int64(m.Trades))
+	b = appendInt(b, 11, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *Candle) UnmarshalProto(data []byte) error {
+	*m = Candle{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readString(typ, data, &m.Interval)
+		case 3:
+			return readInt64(typ, data, &m.OpenTime)
+		case 4:
+			return readInt64(typ, data, &m.CloseTime)
+		case 5:
+			return readInt(typ, data, &m.Open)
+		case 6:
+			return readInt(typ, data, &m.High)
+		case 7:
+			return readInt(typ, data, &m.Low)
+		case 8:
+			return readInt(typ, data, &m.Close)
+		case 9:
+			return readInt64(typ, data, &m.Volume)
+		case 10:
+			return readInt(typ, data, &m.Trades)
+		case 11:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+// Ticker summarises an event's market, published on price_exchange with
+// routing key "ticker.update" whenever any of its values change
+type Ticker struct {
+	EventID            *big.Int `json:"event_id"`
+	LastPrice          int      `json:"last_price"`
+	LastQuantity       int      `json:"last_quantity"`
+	BestBid            int      `json:"best_bid"`
+	BestBidQuantity    int      `json:"best_bid_quantity"`
+	BestAsk            int      `json:"best_ask"`
+	BestAskQuantity    int      `json:"best_ask_quantity"`
+	ImpliedProbability float64  `json:"implied_probability"` // last price, else mid, over the payout
+	Volume24h          int64    `json:"volume_24h"`
+	High24h            int      `json:"high_24h"`
+	Low24h             int      `json:"low_24h"`
+	VWAP24h            float64  `json:"vwap_24h"`
+	Timestamp          int64    `json:"timestamp"`
+	Outcome            int      `json:"outcome,omitempty"`
+}
+
+func (m *Ticker) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(m.LastPrice))
+	b = appendInt(b, 3, int64(m.LastQuantity))
+	b = appendInt(b, 4, int64(m.BestBid))
+	b = appendInt(b, 5, int64(m.BestBidQuantity))
+	b = appendInt(b, 6, int64(m.BestAsk))
+	b = appendInt(b, 7, int64(m.BestAskQuantity))
+	b = appendDouble(b, 8, m.ImpliedProbability)
+	b = appendInt(b, 9, m.Volume24h)
+	b = appendInt(b, 10, int64(m.High24h))
+	b = appendInt(b, 11, int64(m.Low24h))
+	b = appendDouble(b, 12, m.VWAP24h)
+	b = appendInt(b, 13, m.Timestamp)
+	b = appendInt(b, 14, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *Ticker) UnmarshalProto(data []byte) error {
+	*m = Ticker{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readInt(typ, data, &m.LastPrice)
+		case 3:
+			return readInt(typ, data, &m.LastQuantity)
+		case 4:
+			return readInt(typ, data, &m.BestBid)
+		case 5:
+			return readInt(typ, data, &m.BestBidQuantity)
+		case 6:
+			return readInt(typ, data, &m.BestAsk)
+		case 7:
+			return readInt(typ, data, &m.BestAskQuantity)
+		case 8:
+			return readDouble(typ, data, &m.ImpliedProbability)
+		case 9:
+			return readInt64(typ, data, &m.Volume24h)
+		case 10:
+			return readInt(typ, data, &m.High24h)
+		case 11:
+			return readInt(typ, data, &m.Low24h)
+		case 12:
+			return readDouble(typ, data, &m.VWAP24h)
+		case 13:
+			return readInt64(typ, data, &m.Timestamp)
+		case 14:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+// AuctionUpdate is published on price_exchange with routing key
+// "auction.update" while a book is in its opening auction: the indicative
+// uncross after each change, then the uncross itself
+type AuctionUpdate struct {
+	EventID   *big.Int `json:"event_id"`
+	Price     int      `json:"price"` // 0 while nothing crosses
+	Volume    int      `json:"volume"`
+	Surplus   int      `json:"surplus"` // unmatched at Price, positive on the buy side
+	Uncrossed bool     `json:"uncrossed"`
+	Timestamp int64    `json:"timestamp"`
+	Outcome   int      `json:"outcome,omitempty"`
+}
+
+func (m *AuctionUpdate) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(m.Price))
+	b = appendInt(b, 3, int64(m.Volume))
+	b = appendInt(b, 4, int64(m.Surplus))
+	b = appendBool(b, 5, m.Uncrossed)
+	b = appendInt(b, 6, m.Timestamp)
+	b = appendInt(b, 7, int64(m.Outcome))
+	return b, nil
+}
+
+func (m *AuctionUpdate) UnmarshalProto(data []byte) error {
+	*m = AuctionUpdate{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readInt(typ, data, &m.Price)
+		case 3:
+			return readInt(typ, data, &m.Volume)
+		case 4:
+			return readInt(typ, data, &m.Surplus)
+		case 5:
+			return readBool(typ, data, &m.Uncrossed)
+		case 6:
+			return readInt64(typ, data, &m.Timestamp)
+		case 7:
+			return readInt(typ, data, &m.Outcome)
+		}
+		return 0
+	})
+}
+
+// MassCancelReport summarises a MassCancel task, published on
+// execution_exchange with routing key "mass_cancel.report" and sent to the
+// request's reply_to queue. Empty filters matched anything.
+type MassCancelReport struct {
+	EventID   *big.Int `json:"event_id,omitempty"`
+	UserID    *big.Int `json:"user_id,omitempty"`
+	Side      string   `json:"side,omitempty"`
+	Canceled  int      `json:"canceled"` // orders removed
+	Quantity  int      `json:"quantity"` // open quantity removed, hidden included
+	Events    int      `json:"events"`   // books that lost at least one order
+	Timestamp int64    `json:"timestamp"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (m *MassCancelReport) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 3, m.Side)
+	b = appendInt(b, 4, int64(m.Canceled))
+	b = appendInt(b, 5, int64(m.Quantity))
+	b = appendInt(b, 6, int64(m.Events))
+	b = appendInt(b, 7, m.Timestamp)
+	b = appendString(b, 8, m.Error)
+	return b, nil
+}
+
+func (m *MassCancelReport) UnmarshalProto(data []byte) error {
+	*m = MassCancelReport{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.UserID)
+		case 3:
+			return readString(typ, data, &m.Side)
+		case 4:
+			return readInt(typ, data, &m.Canceled)
+		case 5:
+			return readInt(typ, data, &m.Quantity)
+		case 6:
+			return readInt(typ, data, &m.Events)
+		case 7:
+			return readInt64(typ, data, &m.Timestamp)
+		case 8:
+			return readString(typ, data, &m.Error)
+		}
+		return 0
+	})
+}
+
+// CompleteSetReport answers a MintSet or MergeSet task, published on
+// execution_exchange with routing key "complete_set.report". A complete set
+// is one share of every outcome and is worth the payout: minting charges
+// Amount for Quantity sets, merging pays it back.
+type CompleteSetReport struct {
+	EventID   *big.Int `json:"event_id"`
+	UserID    *big.Int `json:"user_id"`
+	Action    string   `json:"action"` // "MINT" || "MERGE"
+	Quantity  int      `json:"quantity"`
+	Amount    int64    `json:"amount"`
+	Timestamp int64    `json:"timestamp"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (m *CompleteSetReport) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	if b, err = appendBigInt(b, 2, m.UserID); err != nil {
+		return nil, err
+	}
+	b = appendString(b, 3, m.Action)
+	b = appendInt(b, 4, int64(m.Quantity))
+	b = appendInt(b, 5, m.Amount)
+	b = appendInt(b, 6, m.Timestamp)
+	b = appendString(b, 7, m.Error)
+	return b, nil
+}
+
+func (m *CompleteSetReport) UnmarshalProto(data []byte) error {
+	*m = CompleteSetReport{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readBigInt(typ, data, &m.UserID)
+		case 3:
+			return readString(typ, data, &m.Action)
+		case 4:
+			return readInt(typ, data, &m.Quantity)
+		case 5:
+			return readInt64(typ, data, &m.Amount)
+		case 6:
+			return readInt64(typ, data, &m.Timestamp)
+		case 7:
+			return readString(typ, data, &m.Error)
+		}
+		return 0
+	})
+}
+
+// SettlementReport is published on execution_exchange with routing key
+// "settlement.report" when an event settles, after its resting orders are
+// canceled. A long share of the winning outcome and a short share of any
+// other outcome are each worth the payout; every other position is worthless.
+type SettlementReport struct {
+	EventID        *big.Int `json:"event_id"`
+	WinningOutcome int      `json:"winning_outcome"`
+	Payout         int      `json:"payout"` // value of one winning share
+	Payouts        []Payout `json:"payouts"`
+	Canceled       int      `json:"canceled"` // resting orders canceled
+	Timestamp      int64    `json:"timestamp"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Payout is what one user's position in one outcome settles to
+type Payout struct {
+	UserID   *big.Int `json:"user_id"`
+	Outcome  int      `json:"outcome"`
+	Position int      `json:"position"` // net shares, negative when short
+	Amount   int64    `json:"amount"`
+}
+
+func (p *Payout) marshal() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, p.UserID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(p.Outcome))
+	b = appendInt(b, 3, int64(p.Position))
+	b = appendInt(b, 4, p.Amount)
+	return b, nil
+}
+
+// readPayout decodes one repeated Payout element and appends it to dst
+func readPayout(typ protowire.Type, data []byte, dst *[]Payout) int {
+	var raw []byte
+	n := readBytes(typ, data, &raw)
+	if n <= 0 {
+		return n
+	}
+	var p Payout
+	err := decode(raw, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &p.UserID)
+		case 2:
+			return readInt(typ, data, &p.Outcome)
+		case 3:
+			return readInt(typ, data, &p.Position)
+		case 4:
+			return readInt64(typ, data, &p.Amount)
+		}
+		return 0
+	})
+	if err != nil {
+		return -1
+	}
+	*dst = append(*dst, p)
+	return n
+}
+
+func (m *SettlementReport) MarshalProto() ([]byte, error) {
+	var b []byte
+	var err error
+	if b, err = appendBigInt(b, 1, m.EventID); err != nil {
+		return nil, err
+	}
+	b = appendInt(b, 2, int64(m.WinningOutcome))
+	b = appendInt(b, 3, int64(m.Payout))
+	for i := range m.Payouts {
+		payout, err := m.Payouts[i].marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, 4, payout)
+	}
+	b = appendInt(b, 5, int64(m.Canceled))
+	b = appendInt(b, 6, m.Timestamp)
+	b = appendString(b, 7, m.Error)
+	return b, nil
+}
+
+func (m *SettlementReport) UnmarshalProto(data []byte) error {
+	*m = SettlementReport{}
+	return decode(data, func(num protowire.Number, typ protowire.Type, data []byte) int {
+		switch num {
+		case 1:
+			return readBigInt(typ, data, &m.EventID)
+		case 2:
+			return readInt(typ, data, &m.WinningOutcome)
+		case 3:
+			return readInt(typ, data, &m.Payout)
+		case 4:
+			return readPayout(typ, data, &m.Payouts)
+		case 5:
+			return readInt(typ, data, &m.Canceled)
+		case 6:
+			return readInt64(typ, data, &m.Timestamp)
+		case 7:
+			return readString(typ, data, &m.Error)
+		}
+		return 0
+	})
+}
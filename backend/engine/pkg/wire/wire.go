@@ -0,0 +1,112 @@
+// dummyengine/pkg/wire/wire.go
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Content types understood by the engine. JSON stays the default so existing
+// producers keep working; the binary schema is described in engine.proto.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/vnd.opinex.engine.v1+protobuf"
+)
+
+// Message is implemented by every type that has a binary encoding
+type Message interface {
+	MarshalProto() ([]byte, error)
+	UnmarshalProto(data []byte) error
+}
+
+// Negotiate maps a delivery's content type to one the engine can decode.
+// An empty content type is treated as JSON for backwards compatibility.
+func Negotiate(contentType string) (string, error) {
+	if contentType == "" {
+		return ContentTypeJSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	switch strings.ToLower(mediaType) {
+	case ContentTypeJSON, "text/json":
+		return ContentTypeJSON, nil
+	case ContentTypeProtobuf:
+		return ContentTypeProtobuf, nil
+	}
+	return "", fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// FormatContentType maps the ENGINE_WIRE_FORMAT setting to a content type
+func FormatContentType(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return ContentTypeJSON, nil
+	case "protobuf", "proto", "binary":
+		return ContentTypeProtobuf, nil
+	}
+	return "", fmt.Errorf("unknown wire format %q", format)
+}
+
+// Marshal encodes v with the given content type
+func Marshal(contentType string, v interface{}) ([]byte, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return json.Marshal(v)
+	case ContentTypeProtobuf:
+		m, ok := v.(Message)
+		if !ok {
+			return nil, fmt.Errorf("%T has no binary encoding", v)
+		}
+		return m.MarshalProto()
+	}
+	return nil, fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// Unmarshal decodes data with the given content type into v
+func Unmarshal(contentType string, data []byte, v interface{}) error {
+	switch contentType {
+	case ContentTypeJSON:
+		return json.Unmarshal(data, v)
+	case ContentTypeProtobuf:
+		m, ok := v.(Message)
+		if !ok {
+			return fmt.Errorf("%T has no binary encoding", v)
+		}
+		return m.UnmarshalProto(data)
+	}
+	return fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// MarketKey names one outcome's market of an event. Outcome 0 keeps the bare
+// event ID, so a yes/no event is keyed as it always was.
+func MarketKey(eventID *big.Int, outcome int) string {
+	if outcome == 0 {
+		return eventID.String()
+	}
+	return fmt.Sprintf("%s:%d", eventID, outcome)
+}
+
+// ParseMarketKey reads a market key, "7" or "7:2", back into its event and
+// outcome, normalising the event's decimal form
+func ParseMarketKey(key string) (*big.Int, int, error) {
+	eventPart, outcomePart, hasOutcome := strings.Cut(key, ":")
+	eventID, ok := new(big.Int).SetString(eventPart, 10)
+	if !ok || eventID.Sign() < 0 {
+		return nil, 0, fmt.Errorf("invalid event_id %q", eventPart)
+	}
+	outcome := 0
+	if hasOutcome {
+		n, err := strconv.Atoi(outcomePart)
+		if err != nil || n < 0 {
+			return nil, 0, fmt.Errorf("invalid outcome %q", outcomePart)
+		}
+		outcome = n
+	}
+	return eventID, outcome, nil
+}
@@ -0,0 +1,189 @@
+// dummyengine/pkg/wsgateway/client.go
+package wsgateway
+
+import (
+	"dummyengine/pkg/logger"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxRequestSize bounds a client's subscribe and unsubscribe messages
+const maxRequestSize = 4096
+
+// Request is what clients send to change their subscriptions
+type Request struct {
+	Action  string `json:"action"`   // "subscribe" || "unsubscribe"
+	EventID string `json:"event_id"` // "7", or "7:2" for one outcome of a categorical event
+	Outcome int    `json:"outcome,omitempty"`
+}
+
+// Handler upgrades HTTP requests to WebSocket connections served by Hub.
+// Markets named by repeated ?event= parameters, as "7" or "7:2", are
+// subscribed on connect.
+type Handler struct {
+	Hub          *Hub
+	WriteTimeout time.Duration // a frame that takes longer to write evicts the client
+	PingInterval time.Duration // the connection is dropped after two intervals without a pong
+
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(hub *Hub, writeTimeout, pingInterval time.Duration) *Handler {
+	return &Handler{
+		Hub:          hub,
+		WriteTimeout: writeTimeout,
+		PingInterval: pingInterval,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+			// Market data is public and served to browser apps on other origins
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// client is one WebSocket connection. The hub queues encoded frames on send;
+// writeLoop is the only writer on conn.
+type client struct {
+	conn   *websocket.Conn
+	remote string
+	send   chan []byte
+	done   chan struct{}
+	once   sync.Once
+
+	events map[string]struct{} // guarded by the hub's mu
+}
+
+// enqueue hands a frame to the writer without blocking
+func (c *client) enqueue(data []byte) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+	}
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the request
+		logger.Debug("WebSocket upgrade failed", "remote", r.RemoteAddr, "error", err)
+		return
+	}
+
+	c := &client{
+		conn:   conn,
+		remote: r.RemoteAddr,
+		send:   make(chan []byte, h.Hub.SendBuffer),
+		done:   make(chan struct{}),
+		events: make(map[string]struct{}),
+	}
+	if !h.Hub.register(c) {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down"), time.Now().Add(time.Second))
+		conn.Close()
+		return
+	}
+	defer h.Hub.unregister(c)
+
+	go h.writeLoop(c)
+
+	for _, eventID := range r.URL.Query()["event"] {
+		h.handle(c, Request{Action: "subscribe", EventID: eventID})
+	}
+	h.readLoop(c)
+}
+
+// readLoop applies subscription requests until the connection fails
+func (h *Handler) readLoop(c *client) {
+	c.conn.SetReadLimit(maxRequestSize)
+	if h.PingInterval > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(2 * h.PingInterval))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(2 * h.PingInterval))
+		})
+	}
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			h.Hub.reply(c, &Frame{Type: FrameError, Error: "invalid request: " + err.Error()})
+			continue
+		}
+		h.handle(c, req)
+	}
+}
+
+func (h *Handler) handle(c *client, req Request) {
+	var err error
+	switch req.Action {
+	case "subscribe":
+		err = h.Hub.subscribe(c, req.EventID, req.Outcome)
+	case "unsubscribe":
+		err = h.Hub.unsubscribe(c, req.EventID, req.Outcome)
+	default:
+		h.Hub.reply(c, &Frame{Type: FrameError, Error: "unknown action " + req.Action})
+		return
+	}
+	if err != nil {
+		h.Hub.reply(c, &Frame{Type: FrameError, EventID: req.EventID, Outcome: req.Outcome, Error: err.Error()})
+	}
+}
+
+// writeLoop sends queued frames and keepalive pings. A write that misses its
+// deadline closes the connection, which also ends readLoop.
+func (h *Handler) writeLoop(c *client) {
+	var ping <-chan time.Time
+	if h.PingInterval > 0 {
+		ticker := time.NewTicker(h.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(h.deadline())
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				logger.Debug("WebSocket write failed", "remote", c.remote, "error", err)
+				c.close()
+				return
+			}
+		case <-ping:
+			c.conn.SetWriteDeadline(h.deadline())
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) deadline() time.Time {
+	if h.WriteTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(h.WriteTimeout)
+}
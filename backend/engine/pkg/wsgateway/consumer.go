@@ -0,0 +1,146 @@
+// dummyengine/pkg/wsgateway/consumer.go
+package wsgateway
+
+import (
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/wire"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Bindings are the engine's market data streams the gateway follows
+var Bindings = []struct {
+	Exchange   string
+	RoutingKey string
+}{
+	{"order_book_exchange", "order_book.update"},
+	{"price_exchange", "price.update"},
+	{"trade_exchange", "trade.executed"},
+}
+
+// maxBacklog caps the gateway's queue; when it stalls the broker drops the
+// oldest updates, which later book updates supersede anyway
+const maxBacklog = 10000
+
+// Consumer feeds a Hub from RabbitMQ. Its queue is exclusive to the process
+// and deleted with it: market data is latest-state, so nothing is replayed
+// after a restart.
+type Consumer struct {
+	URL   string
+	Queue string // empty for a broker-named queue
+	Hub   *Hub
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Run consumes until stop is closed, reconnecting with backoff
+func (c *Consumer) Run(stop <-chan struct{}) {
+	delay := c.MinBackoff
+	for {
+		started := time.Now()
+		err := c.consume(stop)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// A session that held up for a while starts the backoff over
+		if time.Since(started) > c.MaxBackoff {
+			delay = c.MinBackoff
+		}
+		logger.Warn("🔄 Market data session ended, reconnecting...", "reason", err, "retry_in", delay.String())
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(max(2*delay, c.MinBackoff), c.MaxBackoff)
+	}
+}
+
+// consume runs one broker session and returns why it ended
+func (c *Consumer) consume(stop <-chan struct{}) error {
+	conn, err := amqp.Dial(c.URL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+
+	q, err := ch.QueueDeclare(c.Queue, false, true, true, false, amqp.Table{"x-max-length": int32(maxBacklog)})
+	if err != nil {
+		return fmt.Errorf("declare queue: %w", err)
+	}
+	for _, b := range Bindings {
+		// Declared as the engine does, so the gateway may start first
+		if err := ch.ExchangeDeclare(b.Exchange, "topic", true, false, false, false, nil); err != nil {
+			return fmt.Errorf("declare exchange %q: %w", b.Exchange, err)
+		}
+		if err := ch.QueueBind(q.Name, b.RoutingKey, b.Exchange, false, nil); err != nil {
+			return fmt.Errorf("bind %q to %q: %w", q.Name, b.Exchange, err)
+		}
+	}
+
+	msgs, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume %q: %w", q.Name, err)
+	}
+	logger.Info("📡 Following market data", "queue", q.Name, "bindings", len(Bindings))
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-closed:
+			return err
+		case d, ok := <-msgs:
+			if !ok {
+				return fmt.Errorf("delivery channel closed")
+			}
+			if err := c.dispatch(d); err != nil {
+				logger.Error("❌ Failed to decode market data", "routing_key", d.RoutingKey, "error", err)
+			}
+		}
+	}
+}
+
+// dispatch decodes one update by its routing key and hands it to the hub
+func (c *Consumer) dispatch(d amqp.Delivery) error {
+	contentType, err := wire.Negotiate(d.ContentType)
+	if err != nil {
+		return err
+	}
+
+	switch d.RoutingKey {
+	case "order_book.update":
+		var book wire.OrderBookUpdate
+		if err := wire.Unmarshal(contentType, d.Body, &book); err != nil {
+			return err
+		}
+		c.Hub.OnBook(&book)
+	case "price.update":
+		var price wire.PriceUpdate
+		if err := wire.Unmarshal(contentType, d.Body, &price); err != nil {
+			return err
+		}
+		c.Hub.OnPrice(&price)
+	case "trade.executed":
+		var trade wire.TradeMessage
+		if err := wire.Unmarshal(contentType, d.Body, &trade); err != nil {
+			return err
+		}
+		c.Hub.OnTrade(&trade)
+	default:
+		return fmt.Errorf("unexpected routing key")
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+// dummyengine/pkg/wsgateway/hub.go
+package wsgateway
+
+import (
+	"dummyengine/pkg/fees"
+	"dummyengine/pkg/logger"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// Frame types sent to clients
+const (
+	FrameSnapshot = "snapshot" // latest book and price, sent once per subscription
+	FrameBook     = "book"
+	FramePrice    = "price"
+	FrameTrade    = "trade"
+	FrameError    = "error"
+)
+
+// Frame is one JSON message on a client connection
+type Frame struct {
+	Type    string                `json:"type"`
+	EventID string                `json:"event_id,omitempty"`
+	Outcome int                   `json:"outcome,omitempty"` // book of a categorical event
+	Book    *wire.OrderBookUpdate `json:"book,omitempty"`
+	Price   int                   `json:"price,omitempty"` // last traded price, 0 before the first trade
+	Trade   *wire.PublicTrade     `json:"trade,omitempty"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// Hub keeps the latest book and price of every market, an event or one
+// outcome of a categorical event, and fans updates out to the clients
+// subscribed to it. Each frame is encoded once and queued on every
+// client without blocking; a client whose queue is full is evicted rather than
+// slowing the others down.
+type Hub struct {
+	SendBuffer       int // frames a client may lag before it is evicted
+	MaxSubscriptions int // markets per client, zero means unlimited
+
+	mu      sync.Mutex
+	books   map[string]*wire.OrderBookUpdate // by wire.MarketKey, as are prices and subs
+	prices  map[string]int
+	subs    map[string]map[*client]struct{}
+	clients map[*client]struct{}
+	closed  bool
+}
+
+func NewHub(sendBuffer, maxSubscriptions int) *Hub {
+	return &Hub{
+		SendBuffer:       max(sendBuffer, 1),
+		MaxSubscriptions: maxSubscriptions,
+		books:            make(map[string]*wire.OrderBookUpdate),
+		prices:           make(map[string]int),
+		subs:             make(map[string]map[*client]struct{}),
+		clients:          make(map[*client]struct{}),
+	}
+}
+
+// OnBook records a market's latest depth and sends it to its subscribers
+func (h *Hub) OnBook(book *wire.OrderBookUpdate) {
+	if book.EventID == nil {
+		return
+	}
+	eventKey := wire.MarketKey(book.EventID, book.Outcome)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.books[eventKey] = book
+	h.broadcast(eventKey, &Frame{Type: FrameBook, EventID: book.EventID.String(), Outcome: book.Outcome, Book: book})
+}
+
+// OnPrice records a market's last traded price and sends it to its subscribers
+func (h *Hub) OnPrice(update *wire.PriceUpdate) {
+	if update.EventID == nil {
+		return
+	}
+	eventKey := wire.MarketKey(update.EventID, update.Outcome)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prices[eventKey] = update.Price
+	h.broadcast(eventKey, &Frame{Type: FramePrice, EventID: update.EventID.String(), Outcome: update.Outcome, Price: update.Price})
+}
+
+// OnTrade sends an execution to the market's subscribers. Each fill arrives
+// once per side; only the taker leg, or an auction's buy leg, is shown,
+// without order or user IDs.
+func (h *Hub) OnTrade(trade *wire.TradeMessage) {
+	if trade.EventID == nil {
+		return
+	}
+	if trade.Liquidity != fees.Taker && (trade.Liquidity != fees.Auction || trade.Side != "BUY") {
+		return
+	}
+	eventKey := wire.MarketKey(trade.EventID, trade.Outcome)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broadcast(eventKey, &Frame{Type: FrameTrade, EventID: trade.EventID.String(), Outcome: trade.Outcome, Trade: &wire.PublicTrade{
+		EventID:   trade.EventID,
+		Outcome:   trade.Outcome,
+		Price:     trade.Price,
+		Quantity:  trade.Quantity,
+		Timestamp: trade.Timestamp,
+	}})
+}
+
+// Stats returns the number of connected clients and of markets with subscribers
+func (h *Hub) Stats() (clients, events int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients), len(h.subs)
+}
+
+// CloseAll disconnects every client and refuses new ones
+func (h *Hub) CloseAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		h.drop(c)
+	}
+}
+
+// subscribe queues the market's snapshot and then adds c to its subscribers.
+// Both happen under mu, so no update can overtake the snapshot.
+func (h *Hub) subscribe(c *client, eventID string, outcome int) error {
+	id, outcome, err := normalize(eventID, outcome)
+	if err != nil {
+		return err
+	}
+	eventKey := wire.MarketKey(id, outcome)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := c.events[eventKey]; !ok && h.MaxSubscriptions > 0 && len(c.events) >= h.MaxSubscriptions {
+		return fmt.Errorf("at most %d subscriptions per connection", h.MaxSubscriptions)
+	}
+
+	book := h.books[eventKey]
+	if book == nil {
+		book = &wire.OrderBookUpdate{EventID: id, Outcome: outcome}
+	}
+	if !h.send(c, &Frame{Type: FrameSnapshot, EventID: id.String(), Outcome: outcome, Book: book, Price: h.prices[eventKey]}) {
+		return nil
+	}
+
+	if h.subs[eventKey] == nil {
+		h.subs[eventKey] = make(map[*client]struct{})
+	}
+	h.subs[eventKey][c] = struct{}{}
+	c.events[eventKey] = struct{}{}
+	return nil
+}
+
+func (h *Hub) unsubscribe(c *client, eventID string, outcome int) error {
+	id, outcome, err := normalize(eventID, outcome)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c, wire.MarketKey(id, outcome))
+	return nil
+}
+
+// register tracks a new connection; it fails once the hub has been closed
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	return true
+}
+
+// unregister forgets a closed connection
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+}
+
+// reply queues a frame for one client, evicting it if its queue is full
+func (h *Hub) reply(c *client, frame *Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.send(c, frame)
+}
+
+// broadcast queues a frame for every subscriber of the market; callers hold mu
+func (h *Hub) broadcast(eventKey string, frame *Frame) {
+	subs := h.subs[eventKey]
+	if len(subs) == 0 {
+		return
+	}
+	data, err := json.Marshal(frame)
+	if err != nil {
+		logger.Error("❌ Failed to encode frame", "type", frame.Type, "error", err)
+		return
+	}
+	for c := range subs {
+		if !c.enqueue(data) {
+			logger.Warn("🐢 Evicting slow WebSocket client", "remote", c.remote, "event_key", eventKey)
+			h.drop(c)
+		}
+	}
+}
+
+// send queues a frame for one client and reports whether it is still
+// connected; callers hold mu
+func (h *Hub) send(c *client, frame *Frame) bool {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		logger.Error("❌ Failed to encode frame", "type", frame.Type, "error", err)
+		return true
+	}
+	if !c.enqueue(data) {
+		logger.Warn("🐢 Evicting slow WebSocket client", "remote", c.remote)
+		h.drop(c)
+		return false
+	}
+	return true
+}
+
+// leave removes c from one market's subscribers; callers hold mu
+func (h *Hub) leave(c *client, eventKey string) {
+	delete(c.events, eventKey)
+	if subs, ok := h.subs[eventKey]; ok {
+		delete(subs, c)
+		if len(subs) == 0 {
+			delete(h.subs, eventKey)
+		}
+	}
+}
+
+// drop removes c from every market and closes its connection; callers hold mu
+func (h *Hub) drop(c *client) {
+	for eventKey := range c.events {
+		h.leave(c, eventKey)
+	}
+	delete(h.clients, c)
+	c.close()
+}
+
+// normalize reads the market a request names, so that "007" and "7" name the
+// same event. The outcome may be given in the event ID, as "7:2", or apart.
+func normalize(eventID string, outcome int) (*big.Int, int, error) {
+	id, keyed, err := wire.ParseMarketKey(eventID)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case outcome < 0:
+		return nil, 0, fmt.Errorf("invalid outcome %d", outcome)
+	case keyed != 0 && outcome != 0 && keyed != outcome:
+		return nil, 0, fmt.Errorf("event_id %q and outcome %d disagree", eventID, outcome)
+	}
+	return id, max(keyed, outcome), nil
+}
@@ -0,0 +1,362 @@
+package wsgateway
+
+import (
+	"dummyengine/pkg/fees"
+	"dummyengine/pkg/wire"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newClient returns a client on a live connection whose frames are left
+// queued on send for the test to read, as if its writer had stalled
+func newClient(t *testing.T, h *Hub) *client {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	c := &client{
+		conn:   <-conns,
+		send:   make(chan []byte, h.SendBuffer),
+		done:   make(chan struct{}),
+		events: make(map[string]struct{}),
+	}
+	if !h.register(c) {
+		t.Fatal("hub closed")
+	}
+	return c
+}
+
+// queued drains and decodes the client's queued frames
+func queued(t *testing.T, c *client) []Frame {
+	t.Helper()
+	var frames []Frame
+	for {
+		select {
+		case data := <-c.send:
+			var f Frame
+			if err := json.Unmarshal(data, &f); err != nil {
+				t.Fatal(err)
+			}
+			frames = append(frames, f)
+		default:
+			return frames
+		}
+	}
+}
+
+func evicted(c *client) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func book(eventID int64, bid int) *wire.OrderBookUpdate {
+	return &wire.OrderBookUpdate{EventID: big.NewInt(eventID), BuyLevels: []wire.Level{{Price: bid, Quantity: 1}}}
+}
+
+func TestSubscribeStartsWithTheSnapshot(t *testing.T) {
+	h := NewHub(8, 0)
+	h.OnBook(book(7, 400))
+	h.OnPrice(&wire.PriceUpdate{EventID: big.NewInt(7), Price: 410})
+	c := newClient(t, h)
+
+	if err := h.subscribe(c, "007", 0); err != nil {
+		t.Fatal(err)
+	}
+	h.OnBook(book(7, 405))
+	h.OnBook(book(8, 500)) // not subscribed
+
+	frames := queued(t, c)
+	if len(frames) != 2 {
+		t.Fatalf("%d frames, want the snapshot and one update", len(frames))
+	}
+	if f := frames[0]; f.Type != FrameSnapshot || f.EventID != "7" || f.Price != 410 || f.Book.BuyLevels[0].Price != 400 {
+		t.Errorf("first frame %+v", f)
+	}
+	if f := frames[1]; f.Type != FrameBook || f.Book.BuyLevels[0].Price != 405 {
+		t.Errorf("second frame %+v", f)
+	}
+
+	// An event with no book yet still gets an empty snapshot to start from
+	if err := h.subscribe(c, "9", 0); err != nil {
+		t.Fatal(err)
+	}
+	if f := queued(t, c); len(f) != 1 || f[0].Type != FrameSnapshot || f[0].Book == nil || f[0].Book.EventID.Int64() != 9 {
+		t.Errorf("frames %+v, want an empty snapshot", f)
+	}
+}
+
+func TestOutcomeBooksAreSeparateMarkets(t *testing.T) {
+	h := NewHub(8, 0)
+	second := book(7, 300)
+	second.Outcome = 2
+	h.OnBook(book(7, 400))
+	h.OnBook(second)
+	c := newClient(t, h)
+
+	if err := h.subscribe(c, "7:2", 0); err != nil {
+		t.Fatal(err)
+	}
+	h.OnPrice(&wire.PriceUpdate{EventID: big.NewInt(7), Price: 410}) // outcome 0
+	h.OnPrice(&wire.PriceUpdate{EventID: big.NewInt(7), Outcome: 2, Price: 310})
+
+	frames := queued(t, c)
+	if len(frames) != 2 {
+		t.Fatalf("%d frames, want outcome 2's snapshot and price", len(frames))
+	}
+	if f := frames[0]; f.Type != FrameSnapshot || f.EventID != "7" || f.Outcome != 2 || f.Book.BuyLevels[0].Price != 300 {
+		t.Errorf("snapshot %+v", f)
+	}
+	if f := frames[1]; f.Type != FramePrice || f.Outcome != 2 || f.Price != 310 {
+		t.Errorf("price %+v", f)
+	}
+
+	// The outcome given apart names the same market
+	if err := h.unsubscribe(c, "7", 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.events) != 0 {
+		t.Errorf("still subscribed to %v", c.events)
+	}
+}
+
+func TestNoUpdateOvertakesTheSnapshot(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		h := NewHub(1024, 0)
+		h.OnBook(book(7, 1))
+		c := newClient(t, h)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bid := 2; bid < 100; bid++ {
+				h.OnBook(book(7, bid))
+			}
+		}()
+		if err := h.subscribe(c, "7", 0); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+
+		// Updates after the snapshot are the ones following its book, in order
+		frames := queued(t, c)
+		if frames[0].Type != FrameSnapshot {
+			t.Fatalf("first frame %s, want the snapshot", frames[0].Type)
+		}
+		last := frames[0].Book.BuyLevels[0].Price
+		for _, f := range frames[1:] {
+			if bid := f.Book.BuyLevels[0].Price; f.Type != FrameBook || bid != last+1 {
+				t.Fatalf("frame %s at %d after %d", f.Type, bid, last)
+			}
+			last++
+		}
+	}
+}
+
+func TestBroadcastEvictsSlowClients(t *testing.T) {
+	h := NewHub(2, 0)
+	slow, fast := newClient(t, h), newClient(t, h)
+	for _, c := range []*client{slow, fast} {
+		if err := h.subscribe(c, "7", 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.subscribe(c, "8", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queued(t, fast)
+	queued(t, slow)
+
+	for bid := 1; bid <= 3; bid++ {
+		h.OnBook(book(7, bid))
+		queued(t, fast)
+	}
+	if !evicted(slow) || evicted(fast) {
+		t.Fatalf("slow evicted %v, fast evicted %v", evicted(slow), evicted(fast))
+	}
+	// The evicted client is gone from every event, not just the one that overflowed
+	if clients, events := h.Stats(); clients != 1 || events != 2 {
+		t.Errorf("%d clients on %d events, want 1 on 2", clients, events)
+	}
+	if _, ok := h.subs["8"][slow]; ok {
+		t.Error("slow client still subscribed to event 8")
+	}
+}
+
+func TestSnapshotIntoAFullQueueEvicts(t *testing.T) {
+	h := NewHub(1, 0)
+	c := newClient(t, h)
+	if err := h.subscribe(c, "7", 0); err != nil {
+		t.Fatal(err)
+	}
+	// The first snapshot is still queued, so the second has nowhere to go
+	if err := h.subscribe(c, "8", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !evicted(c) {
+		t.Fatal("client with a full queue kept")
+	}
+	if clients, events := h.Stats(); clients != 0 || events != 0 {
+		t.Errorf("%d clients on %d events after eviction", clients, events)
+	}
+}
+
+func TestMaxSubscriptions(t *testing.T) {
+	h := NewHub(8, 2)
+	c := newClient(t, h)
+	for _, id := range []string{"1", "2"} {
+		if err := h.subscribe(c, id, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.subscribe(c, "3", 0); err == nil || !strings.Contains(err.Error(), "at most 2") {
+		t.Errorf("third subscription: %v", err)
+	}
+	// Subscribing again to an event already held is not a new subscription
+	if err := h.subscribe(c, "02", 0); err != nil {
+		t.Errorf("resubscribe: %v", err)
+	}
+	if err := h.unsubscribe(c, "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.subscribe(c, "3", 0); err != nil {
+		t.Errorf("subscription after freeing one: %v", err)
+	}
+	if len(c.events) != 2 {
+		t.Errorf("%d subscriptions, want 2", len(c.events))
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		eventID string
+		outcome int
+		want    string // market key, empty when refused
+	}{
+		{"7", 0, "7"},
+		{"007", 0, "7"},
+		{"0", 0, "0"},
+		{"123456789012345678901234567890", 0, "123456789012345678901234567890"},
+		{"7:2", 0, "7:2"},
+		{"007:2", 2, "7:2"}, // the same outcome both ways
+		{"7", 2, "7:2"},
+		{"7:0", 0, "7"},
+		{"7:2", 3, ""},
+		{"7", -1, ""},
+		{"7:-1", 0, ""},
+		{"7:", 0, ""},
+		{"", 0, ""},
+		{"-1", 0, ""},
+		{"7x", 0, ""},
+		{"0x7", 0, ""},
+		{" 7", 0, ""},
+	}
+	for _, tt := range tests {
+		id, outcome, err := normalize(tt.eventID, tt.outcome)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("normalize(%q, %d) accepted", tt.eventID, tt.outcome)
+			}
+			continue
+		}
+		if err != nil || wire.MarketKey(id, outcome) != tt.want {
+			t.Errorf("normalize(%q, %d) = %v, %d, %v, want %s", tt.eventID, tt.outcome, id, outcome, err, tt.want)
+		}
+	}
+}
+
+func TestOnlyTheTakerLegIsShown(t *testing.T) {
+	h := NewHub(8, 0)
+	c := newClient(t, h)
+	h.subscribe(c, "7", 0)
+	queued(t, c)
+
+	fill := wire.TradeMessage{EventID: big.NewInt(7), OrderID: big.NewInt(1), UserID: big.NewInt(2), Price: 400, Quantity: 3, Timestamp: 1700000000}
+	maker, taker := fill, fill
+	maker.Liquidity, taker.Liquidity = fees.Maker, fees.Taker
+	h.OnTrade(&maker)
+	h.OnTrade(&taker)
+
+	frames := queued(t, c)
+	if len(frames) != 1 || frames[0].Type != FrameTrade {
+		t.Fatalf("frames %+v, want one trade", frames)
+	}
+	if tr := frames[0].Trade; tr.Price != 400 || tr.Quantity != 3 || tr.Timestamp != 1700000000 {
+		t.Errorf("trade %+v", tr)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHub(8, 0)
+	h.OnBook(book(7, 400))
+	srv := httptest.NewServer(NewHandler(h, time.Second, 0))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?event=007", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	read := func() Frame {
+		t.Helper()
+		var f Frame
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.ReadJSON(&f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	if f := read(); f.Type != FrameSnapshot || f.EventID != "7" {
+		t.Errorf("first frame %+v", f)
+	}
+	conn.WriteJSON(Request{Action: "subscribe", EventID: "seven"})
+	if f := read(); f.Type != FrameError || f.EventID != "seven" {
+		t.Errorf("bad event frame %+v", f)
+	}
+	conn.WriteJSON(Request{Action: "watch", EventID: "7"})
+	if f := read(); f.Type != FrameError || !strings.Contains(f.Error, "unknown action") {
+		t.Errorf("bad action frame %+v", f)
+	}
+
+	// Shutting down closes open connections and turns new ones away
+	h.CloseAll()
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("connection open after CloseAll")
+	}
+	late, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer late.Close()
+	if _, _, err := late.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("late connection got %v, want going away", err)
+	}
+}